@@ -23,9 +23,9 @@ import (
 //
 // Errors:
 //   - common.ErrBadParam: If the token or table are nil.
-//   - fmt.Errorf("unknown type: %s", strconv.Quote(token.Type)): If the token's type is not recognized.
+//   - fmt.Errorf("unknown type: %s", slgr.KindString(token.Type)): If the token's type is not recognized.
 //   - any other error: Function-specific.
-func ApplyAST[N slgr.TreeNode](tk *slgr.Token, table ASTMaker[N]) (N, error) {
+func ApplyAST[K slgr.Kind, N slgr.TreeNode](tk *slgr.TokenOf[K], table ASTMakerOf[K, N]) (N, error) {
 	if tk == nil {
 		err := common.NewErrNilParam("tk")
 		return *new(N), err
@@ -38,7 +38,7 @@ func ApplyAST[N slgr.TreeNode](tk *slgr.Token, table ASTMaker[N]) (N, error) {
 
 	fn, ok := table[tk.Type]
 	if !ok || fn == nil {
-		err := fmt.Errorf("unknown type: %s", tk.Type)
+		err := fmt.Errorf("unknown type: %s", slgr.KindString(tk.Type))
 		return *new(N), err
 	}
 
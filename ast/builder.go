@@ -5,7 +5,7 @@ import (
 	"github.com/PlayerR9/SlParser/mygo-lib/common"
 )
 
-// ASTFn is a function that converts a token tree into a node tree.
+// ASTFnOf is a function that converts a token tree into a node tree.
 //
 // Parameters:
 //   - tk: The root token of the tree. (Assumed to not be nil)
@@ -16,9 +16,12 @@ import (
 //
 // Errors:
 //   - any error: Implementation-specific error.
-type ASTFn[N slgr.TreeNode] func(tk *slgr.Token) (N, error)
+type ASTFnOf[K slgr.Kind, N slgr.TreeNode] func(tk *slgr.TokenOf[K]) (N, error)
 
-// ASTMaker is a map of functions that convert a token tree into a node tree.
+// ASTFn is the conversion function of the string-based API.
+type ASTFn[N slgr.TreeNode] = ASTFnOf[string, N]
+
+// ASTMakerOf is a map of functions that convert a token tree into a node tree.
 //
 // Parameters:
 //   - tk: The root token of the tree. (Assumed to not be nil)
@@ -29,16 +32,22 @@ type ASTFn[N slgr.TreeNode] func(tk *slgr.Token) (N, error)
 //
 // Errors:
 //   - any error: Implementation-specific error.
-type ASTMaker[N slgr.TreeNode] map[string]ASTFn[N]
+type ASTMakerOf[K slgr.Kind, N slgr.TreeNode] map[K]ASTFnOf[K, N]
+
+// ASTMaker is the table of the string-based API.
+type ASTMaker[N slgr.TreeNode] = ASTMakerOf[string, N]
 
-// Builder is a struct that builds an ASTMaker.
-type Builder[N slgr.TreeNode] struct {
+// BuilderOf is a struct that builds an ASTMakerOf.
+type BuilderOf[K slgr.Kind, N slgr.TreeNode] struct {
 	// table is the map of functions that convert a token tree into a node tree.
-	table map[string]ASTFn[N]
+	table map[K]ASTFnOf[K, N]
 }
 
+// Builder is the builder of the string-based API.
+type Builder[N slgr.TreeNode] = BuilderOf[string, N]
+
 // Reset implements common.Resetter.
-func (b *Builder[N]) Reset() error {
+func (b *BuilderOf[K, N]) Reset() error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
@@ -53,7 +62,7 @@ func (b *Builder[N]) Reset() error {
 	return nil
 }
 
-// Add adds a function to the ASTMaker.
+// Add adds a function to the ASTMakerOf.
 //
 // Parameters:
 //   - type_: The type of the token that the function will be associated with.
@@ -65,7 +74,7 @@ func (b *Builder[N]) Reset() error {
 // Errors:
 //   - common.ErrNilReceiver: If the receiver is nil.
 //   - common.ErrBadParam: If the function is nil.
-func (b *Builder[N]) Add(type_ string, fn ASTFn[N]) error {
+func (b *BuilderOf[K, N]) Add(type_ K, fn ASTFnOf[K, N]) error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
@@ -76,7 +85,7 @@ func (b *Builder[N]) Add(type_ string, fn ASTFn[N]) error {
 	}
 
 	if b.table == nil {
-		b.table = make(map[string]ASTFn[N])
+		b.table = make(map[K]ASTFnOf[K, N])
 	}
 
 	b.table[type_] = fn
@@ -89,15 +98,15 @@ func (b *Builder[N]) Add(type_ string, fn ASTFn[N]) error {
 // The returned table is a copy of the internal table of the builder.
 //
 // Returns:
-//   - ASTMaker[N]: The table of functions that convert a token tree into a node tree. Never
+//   - ASTMakerOf[K, N]: The table of functions that convert a token tree into a node tree. Never
 //     returns nil.
-func (b Builder[N]) Build() ASTMaker[N] {
+func (b BuilderOf[K, N]) Build() ASTMakerOf[K, N] {
 	if len(b.table) == 0 {
-		table := make(ASTMaker[N], 0)
+		table := make(ASTMakerOf[K, N], 0)
 		return table
 	}
 
-	table := make(ASTMaker[N], len(b.table))
+	table := make(ASTMakerOf[K, N], len(b.table))
 
 	for k, v := range b.table {
 		table[k] = v
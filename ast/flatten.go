@@ -0,0 +1,74 @@
+package ast
+
+import (
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// flatten returns the children of tk with every helper child replaced by its
+// own (flattened) children.
+//
+// Parameters:
+//   - tk: The token whose children are to be flattened. (Assumed to not be nil)
+//   - helpers: The set of helper non-terminals.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The flattened children.
+func flatten[K slgr.Kind](tk *slgr.TokenOf[K], helpers map[K]struct{}) []*slgr.TokenOf[K] {
+	var children []*slgr.TokenOf[K]
+
+	for _, child := range tk.Children {
+		if child == nil {
+			continue
+		}
+
+		child.Children = flatten(child, helpers)
+
+		_, ok := helpers[child.Type]
+		if ok {
+			children = append(children, child.Children...)
+		} else {
+			children = append(children, child)
+		}
+	}
+
+	return children
+}
+
+// Flatten removes the helper non-terminals generated by slgr.Desugar from the
+// given token tree, in place.
+//
+// Every token whose type is a helper is replaced, in its parent, by its own
+// children. As such, a repetition such as "RhsCls = Rhs { Rhs } ." yields a
+// single RhsCls token with one Rhs child per occurrence rather than a nested
+// chain of helper tokens, and an empty optional yields no children at all.
+//
+// Parameters:
+//   - tk: The root token of the tree. The root itself is never removed.
+//   - helpers: The helper non-terminals to remove.
+//
+// Returns:
+//   - error: An error if the root token is nil.
+//
+// Errors:
+//   - common.ErrBadParam: If the root token is nil.
+func Flatten[K slgr.Kind](tk *slgr.TokenOf[K], helpers []K) error {
+	if tk == nil {
+		err := common.NewErrNilParam("tk")
+		return err
+	}
+
+	if len(helpers) == 0 {
+		return nil
+	}
+
+	set := make(map[K]struct{}, len(helpers))
+
+	for _, helper := range helpers {
+		set[helper] = struct{}{}
+	}
+
+	tk.Children = flatten(tk, set)
+
+	return nil
+}
@@ -0,0 +1,111 @@
+package ast
+
+import (
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	slpx "github.com/PlayerR9/SlParser/parser"
+)
+
+// childTypes returns the types of the children of the given token.
+func childTypes(tk *slgr.Token) []string {
+	types := make([]string, 0, len(tk.Children))
+
+	for _, child := range tk.Children {
+		types = append(types, child.Type)
+	}
+
+	return types
+}
+
+func TestFlatten(t *testing.T) {
+	// List = Item { comma Item } [ semicolon ] .
+	// Item = a .
+	prods := []*slgr.Production{
+		slgr.NewProduction("List", slgr.NewSequence(
+			slgr.NewSymbol("Item"),
+			slgr.NewRepetition(slgr.NewSequence(slgr.NewSymbol("comma"), slgr.NewSymbol("Item"))),
+			slgr.NewOptional(slgr.NewSymbol("semicolon")),
+		)),
+		slgr.NewProduction("Item", slgr.NewSymbol("a")),
+	}
+
+	rules, helpers, err := slgr.Desugar(prods)
+	if err != nil {
+		t.Fatalf("Desugar() returned an error: %v", err)
+	}
+
+	table, err := slpx.NewTable(rules)
+	if err != nil {
+		t.Fatalf("NewTable() returned an error: %v", err)
+	}
+
+	var builder slpx.Builder
+
+	_ = builder.SetTable(table)
+
+	tests := []struct {
+		name  string
+		input []string
+		want  []string
+	}{
+		{
+			name:  "single item",
+			input: []string{"a"},
+			want:  []string{"Item"},
+		},
+		{
+			name:  "repeated items",
+			input: []string{"a", "comma", "a", "comma", "a"},
+			want:  []string{"Item", "comma", "Item", "comma", "Item"},
+		},
+		{
+			name:  "optional present",
+			input: []string{"a", "comma", "a", "semicolon"},
+			want:  []string{"Item", "comma", "Item", "semicolon"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tokens := make([]*slgr.Token, 0, len(test.input))
+
+			for _, type_ := range test.input {
+				tokens = append(tokens, slgr.NewToken(type_, type_))
+			}
+
+			forest, err := slpx.Parse(builder.Build(), tokens)
+			if err != nil {
+				t.Fatalf("Parse() returned an error: %v", err)
+			} else if len(forest) != 1 {
+				t.Fatalf("Parse() returned %d trees, want 1", len(forest))
+			}
+
+			root := forest[0]
+
+			err = Flatten(root, helpers)
+			if err != nil {
+				t.Fatalf("Flatten() returned an error: %v", err)
+			}
+
+			got := childTypes(root)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("children = %q, want %q", got, test.want)
+			}
+
+			for _, child := range root.Children {
+				if child.Type == "Item" && !slices.Equal(childTypes(child), []string{"a"}) {
+					t.Errorf("Item children = %q, want [a]", childTypes(child))
+				}
+			}
+		})
+	}
+}
+
+func TestFlattenNil(t *testing.T) {
+	err := Flatten(nil, []string{"A_1"})
+	if err == nil {
+		t.Errorf("Flatten(nil) returned no error")
+	}
+}
@@ -0,0 +1,303 @@
+// Package gen generates the node types of an AST, and the slpast.ASTMaker
+// table that builds them, from the annotations of the rules of a grammar.
+//
+// An annotation follows a rule and names the node the rule builds:
+//
+//	Expr = Expr plus Term . -> Binary(left=$1, op=$2, right=$3)
+//	Expr = Term .           -> $1
+//	Term = number .         -> Number(value=$1)
+//
+// Each field takes the right-hand side symbol at the given position: the data
+// of the token for terminals, which gives a string field; or the node of the
+// token for non-terminals, which gives a Node field. An annotation "$n" makes
+// the rule build the node of its n-th symbol, which must be a non-terminal;
+// and it is the default annotation of the rules with a single right-hand side
+// symbol. Several rules may build the same node, as long as they give it the
+// same fields.
+package gen
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// Field is a field of an annotated node.
+type Field struct {
+	// Name is the name of the field, as written in the annotation.
+	Name string
+
+	// Index is the position, from 1, of the right-hand side symbol of the
+	// field.
+	Index int
+}
+
+// Annotation is the annotation of a rule; that is, the node the rule builds.
+type Annotation struct {
+	// Node is the name of the node type, or an empty string if the rule
+	// builds the node of one of its symbols.
+	Node string
+
+	// Fields is the fields of the node, in order.
+	Fields []Field
+
+	// Index is the position, from 1, of the symbol whose node the rule
+	// builds; or 0 if the rule builds a new node.
+	Index int
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<node>(<field>=$<index>, ...)"
+//	"$<index>"
+func (a Annotation) String() string {
+	if a.Node == "" {
+		str := "$" + strconv.Itoa(a.Index)
+		return str
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(a.Node)
+	_, _ = builder.WriteRune('(')
+
+	for i, f := range a.Fields {
+		if i > 0 {
+			_, _ = builder.WriteString(", ")
+		}
+
+		_, _ = builder.WriteString(f.Name)
+		_, _ = builder.WriteString("=$")
+		_, _ = builder.WriteString(strconv.Itoa(f.Index))
+	}
+
+	_, _ = builder.WriteRune(')')
+
+	str := builder.String()
+	return str
+}
+
+// isIdentifier checks whether the given string is a Go identifier.
+//
+// Parameters:
+//   - str: The string to check.
+//
+// Returns:
+//   - bool: True if the string is an identifier, false otherwise.
+func isIdentifier(str string) bool {
+	if str == "" || str == "_" {
+		return false
+	}
+
+	for i, c := range str {
+		if c != '_' && !unicode.IsLetter(c) && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// parseIndex parses a "$<index>" reference.
+//
+// Parameters:
+//   - str: The reference.
+//
+// Returns:
+//   - int: The index.
+//   - error: An error if the reference is not valid.
+func parseIndex(str string) (int, error) {
+	digits, ok := strings.CutPrefix(str, "$")
+	if !ok {
+		err := common.NewErrBadParam("annotation", "has "+strconv.Quote(str)+" instead of a $<index> reference")
+		return 0, err
+	}
+
+	idx, err := strconv.Atoi(digits)
+	if err != nil || idx < 1 {
+		err := common.NewErrBadParam("annotation", "has an invalid reference "+strconv.Quote(str))
+		return 0, err
+	}
+
+	return idx, nil
+}
+
+// ParseAnnotation parses the given annotation; either "$<index>" or
+// "<Node>(<field>=$<index>, ...)", where the fields are optional.
+//
+// Parameters:
+//   - src: The annotation.
+//
+// Returns:
+//   - *Annotation: The annotation, or nil if it is not valid.
+//   - error: An error if the annotation is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If the annotation is not valid.
+func ParseAnnotation(src string) (*Annotation, error) {
+	src = strings.TrimSpace(src)
+
+	if strings.HasPrefix(src, "$") {
+		idx, err := parseIndex(src)
+		if err != nil {
+			return nil, err
+		}
+
+		a := &Annotation{
+			Index: idx,
+		}
+
+		return a, nil
+	}
+
+	node, args, ok := strings.Cut(src, "(")
+	node = strings.TrimSpace(node)
+
+	if ok {
+		args, ok = strings.CutSuffix(args, ")")
+		if !ok {
+			err := common.NewErrBadParam("annotation", "is missing ')'")
+			return nil, err
+		}
+	}
+
+	if !isIdentifier(node) || !unicode.IsUpper([]rune(node)[0]) {
+		err := common.NewErrBadParam("annotation", "has an invalid node name "+strconv.Quote(node))
+		return nil, err
+	}
+
+	a := &Annotation{
+		Node: node,
+	}
+
+	if strings.TrimSpace(args) == "" {
+		return a, nil
+	}
+
+	seen := make(map[string]struct{})
+
+	for _, arg := range strings.Split(args, ",") {
+		name, ref, ok := strings.Cut(arg, "=")
+		if !ok {
+			err := common.NewErrBadParam("annotation", "has a field without '='")
+			return nil, err
+		}
+
+		name = strings.TrimSpace(name)
+
+		if !isIdentifier(name) {
+			err := common.NewErrBadParam("annotation", "has an invalid field name "+strconv.Quote(name))
+			return nil, err
+		}
+
+		_, ok = seen[fieldName(name)]
+		if ok {
+			err := common.NewErrBadParam("annotation", "has the field "+strconv.Quote(name)+" twice")
+			return nil, err
+		}
+
+		seen[fieldName(name)] = struct{}{}
+
+		idx, err := parseIndex(strings.TrimSpace(ref))
+		if err != nil {
+			return nil, err
+		}
+
+		a.Fields = append(a.Fields, Field{
+			Name:  name,
+			Index: idx,
+		})
+	}
+
+	return a, nil
+}
+
+// Rule is a rule of the grammar along with its annotation.
+type Rule struct {
+	// Rule is the rule.
+	Rule *slgr.Rule
+
+	// Annotation is the annotation of the rule, or nil for the default one.
+	Annotation *Annotation
+}
+
+// ParseRules parses the given annotated grammar: one rule per line, in BNF
+// form, optionally followed by "->" and its annotation. Empty lines and lines
+// starting with "//" are ignored.
+//
+// Format:
+//
+//	<lhs> = <rhs> <rhs> ... . [-> <annotation>]
+//
+// Parameters:
+//   - src: The annotated grammar.
+//
+// Returns:
+//   - []*Rule: The annotated rules, in order.
+//   - error: An error if a line is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If a line is not valid.
+func ParseRules(src string) ([]*Rule, error) {
+	var rules []*Rule
+
+	for i, line := range strings.Split(src, "\n") {
+		line = strings.TrimSpace(line)
+
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		text, annotation, ok := strings.Cut(line, "->")
+
+		fields := strings.Fields(text)
+
+		if len(fields) < 3 || fields[1] != "=" || fields[len(fields)-1] != "." {
+			err := common.NewErrBadParam("src", "has an invalid rule at line "+strconv.Itoa(i+1))
+			return nil, err
+		}
+
+		rule := &Rule{
+			Rule: slgr.NewRule(fields[0], fields[2:len(fields)-1]...),
+		}
+
+		if ok {
+			a, err := ParseAnnotation(annotation)
+			if err != nil {
+				err := common.NewErrBadParam("src", "has an invalid annotation at line "+strconv.Itoa(i+1)+": "+err.Error())
+				return nil, err
+			}
+
+			rule.Annotation = a
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// Rules returns the rules of the given annotated rules; such as for building
+// the parsing table.
+//
+// Parameters:
+//   - rules: The annotated rules.
+//
+// Returns:
+//   - []*slgr.Rule: The rules, in order.
+func Rules(rules []*Rule) []*slgr.Rule {
+	slgr_rules := make([]*slgr.Rule, 0, len(rules))
+
+	for _, r := range rules {
+		if r != nil {
+			slgr_rules = append(slgr_rules, r.Rule)
+		}
+	}
+
+	return slgr_rules
+}
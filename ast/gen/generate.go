@@ -0,0 +1,492 @@
+package gen
+
+import (
+	"errors"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// fieldName returns the Go name of the given field; that is, the name with
+// its first letter in upper case.
+//
+// Parameters:
+//   - name: The name of the field.
+//
+// Returns:
+//   - string: The Go name of the field.
+func fieldName(name string) string {
+	c, size := utf8.DecodeRuneInString(name)
+
+	str := string(unicode.ToUpper(c)) + name[size:]
+	return str
+}
+
+// nodeField is a field of a generated node type.
+type nodeField struct {
+	// name is the name of the field, as written in the annotation.
+	name string
+
+	// is_node is true if the field is a Node, false if it is a string.
+	is_node bool
+}
+
+// nodeType is a generated node type.
+type nodeType struct {
+	// name is the name of the type.
+	name string
+
+	// fields is the fields of the type, in order.
+	fields []nodeField
+
+	// rules is the rules that build the type.
+	rules []*slgr.Rule
+}
+
+// generator generates the code of an AST.
+type generator struct {
+	// rules is the annotated rules, with their default annotations.
+	rules []*Rule
+
+	// non_terminals is the set of the left-hand sides of the rules.
+	non_terminals map[string]struct{}
+
+	// types is the generated node types, in order.
+	types []*nodeType
+
+	// b is the builder of the code.
+	b strings.Builder
+}
+
+// resolve checks the annotations of the rules and gathers the node types.
+//
+// Returns:
+//   - error: An error if an annotation is missing or not valid.
+func (g *generator) resolve() error {
+	by_name := make(map[string]*nodeType)
+
+	for _, r := range g.rules {
+		a := r.Annotation
+
+		if a == nil {
+			if len(r.Rule.Rhss) != 1 {
+				err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " has no annotation")
+				return err
+			}
+
+			a = &Annotation{
+				Index: 1,
+			}
+
+			r.Annotation = a
+		}
+
+		if a.Node == "" {
+			if a.Index > len(r.Rule.Rhss) {
+				err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " has no symbol $" + strconv.Itoa(a.Index))
+				return err
+			}
+
+			_, ok := g.non_terminals[r.Rule.Rhss[a.Index-1]]
+			if !ok {
+				err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " builds the node of the terminal $" + strconv.Itoa(a.Index))
+				return err
+			}
+
+			continue
+		}
+
+		if a.Node == "Node" {
+			err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " uses the reserved node name Node")
+			return err
+		}
+
+		fields := make([]nodeField, 0, len(a.Fields))
+
+		for _, f := range a.Fields {
+			if f.Index > len(r.Rule.Rhss) {
+				err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " has no symbol $" + strconv.Itoa(f.Index))
+				return err
+			}
+
+			_, is_node := g.non_terminals[r.Rule.Rhss[f.Index-1]]
+
+			fields = append(fields, nodeField{
+				name:    f.Name,
+				is_node: is_node,
+			})
+		}
+
+		t, ok := by_name[a.Node]
+		if !ok {
+			t = &nodeType{
+				name:   a.Node,
+				fields: fields,
+			}
+
+			by_name[a.Node] = t
+			g.types = append(g.types, t)
+		} else if !sameFields(t.fields, fields) {
+			err := errors.New("rule " + strconv.Quote(r.Rule.String()) + " gives other fields to the node " + a.Node)
+			return err
+		}
+
+		t.rules = append(t.rules, r.Rule)
+	}
+
+	return nil
+}
+
+// sameFields checks whether the given fields have the same names and kinds,
+// in any order.
+//
+// Parameters:
+//   - a: The first fields.
+//   - b: The second fields.
+//
+// Returns:
+//   - bool: True if the fields are the same, false otherwise.
+func sameFields(a, b []nodeField) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	kinds := make(map[string]bool, len(a))
+
+	for _, f := range a {
+		kinds[fieldName(f.name)] = f.is_node
+	}
+
+	for _, f := range b {
+		is_node, ok := kinds[fieldName(f.name)]
+		if !ok || is_node != f.is_node {
+			return false
+		}
+	}
+
+	return true
+}
+
+// line writes the given parts followed by a newline.
+//
+// Parameters:
+//   - parts: The parts of the line.
+func (g *generator) line(parts ...string) {
+	for _, part := range parts {
+		_, _ = g.b.WriteString(part)
+	}
+
+	_, _ = g.b.WriteRune('\n')
+}
+
+// header writes the package clause, the imports and the Node interface.
+//
+// Parameters:
+//   - pkg: The name of the package.
+func (g *generator) header(pkg string) {
+	g.line("// Code generated by github.com/PlayerR9/SlParser/ast/gen. DO NOT EDIT.")
+	g.line()
+	g.line("package ", pkg)
+	g.line()
+	g.line("import (")
+
+	if len(g.rules) > 0 {
+		g.line(`"fmt"`)
+	}
+
+	var has_data bool
+
+	for _, t := range g.types {
+		for _, f := range t.fields {
+			if !f.is_node {
+				has_data = true
+			}
+		}
+	}
+
+	if has_data {
+		g.line(`"strconv"`)
+	}
+
+	if len(g.types) > 0 {
+		g.line(`"strings"`)
+	}
+
+	g.line()
+	g.line(`slpast "github.com/PlayerR9/SlParser/ast"`)
+	g.line(`slgr "github.com/PlayerR9/SlParser/grammar"`)
+	g.line(")")
+	g.line()
+	g.line("// Node is a node of the AST.")
+	g.line("type Node interface {")
+	g.line("slgr.TreeNode")
+	g.line()
+	g.line("// GetChildren returns the child nodes of the node.")
+	g.line("//")
+	g.line("// Returns:")
+	g.line("//   - []Node: The child nodes, or nil if there are none.")
+	g.line("GetChildren() []Node")
+	g.line("}")
+}
+
+// nodeType writes the given node type and its methods.
+//
+// Parameters:
+//   - t: The node type. (Assumed to not be nil)
+func (g *generator) nodeType(t *nodeType) {
+	g.line()
+	g.line("// ", t.name, " is the node built by:")
+	g.line("//")
+
+	for _, r := range t.rules {
+		g.line("//\t", r.String())
+	}
+
+	g.line("type ", t.name, " struct {")
+
+	for i, f := range t.fields {
+		if i > 0 {
+			g.line()
+		}
+
+		if f.is_node {
+			g.line("// ", fieldName(f.name), " is the node of the ", strconv.Quote(f.name), " field.")
+			g.line(fieldName(f.name), " Node")
+		} else {
+			g.line("// ", fieldName(f.name), " is the data of the ", strconv.Quote(f.name), " field.")
+			g.line(fieldName(f.name), " string")
+		}
+	}
+
+	g.line("}")
+	g.line()
+	g.line("// String implements Node.")
+	g.line("func (n ", t.name, ") String() string {")
+	g.line("var builder strings.Builder")
+	g.line()
+	g.line(`_, _ = builder.WriteString(`, strconv.Quote(t.name+"["), `)`)
+
+	first := true
+
+	for _, f := range t.fields {
+		if f.is_node {
+			continue
+		}
+
+		sep := ""
+		if !first {
+			sep = ", "
+		}
+
+		first = false
+
+		g.line(`_, _ = builder.WriteString(`, strconv.Quote(sep+f.name+"="), `)`)
+		g.line(`_, _ = builder.WriteString(strconv.Quote(n.`, fieldName(f.name), `))`)
+	}
+
+	g.line(`_, _ = builder.WriteRune(']')`)
+	g.line()
+	g.line("str := builder.String()")
+	g.line("return str")
+	g.line("}")
+	g.line()
+	g.line("// GetChildren implements Node.")
+	g.line("func (n ", t.name, ") GetChildren() []Node {")
+	g.line("var children []Node")
+
+	for _, f := range t.fields {
+		if !f.is_node {
+			continue
+		}
+
+		g.line()
+		g.line("if n.", fieldName(f.name), " != nil {")
+		g.line("children = append(children, n.", fieldName(f.name), ")")
+		g.line("}")
+	}
+
+	g.line()
+	g.line("return children")
+	g.line("}")
+}
+
+// maker writes the function that returns the ASTMaker table.
+func (g *generator) maker() {
+	g.line()
+	g.line("// isRule checks whether the children of the given token are the right-hand")
+	g.line("// side of a rule.")
+	g.line("func isRule(tk *slgr.Token, rhss ...string) bool {")
+	g.line("if len(tk.Children) != len(rhss) {")
+	g.line("return false")
+	g.line("}")
+	g.line()
+	g.line("for i, rhs := range rhss {")
+	g.line("if tk.Children[i] == nil || tk.Children[i].Type != rhs {")
+	g.line("return false")
+	g.line("}")
+	g.line("}")
+	g.line()
+	g.line("return true")
+	g.line("}")
+	g.line()
+	g.line("// NewASTMaker returns the table that builds the nodes of the parse trees.")
+	g.line("//")
+	g.line("// Returns:")
+	g.line("//   - slpast.ASTMaker[Node]: The table. Never returns nil.")
+	g.line("func NewASTMaker() slpast.ASTMaker[Node] {")
+	g.line("table := make(slpast.ASTMaker[Node])")
+
+	var lhss []string
+	by_lhs := make(map[string][]*Rule)
+
+	for _, r := range g.rules {
+		_, ok := by_lhs[r.Rule.Lhs]
+		if !ok {
+			lhss = append(lhss, r.Rule.Lhs)
+		}
+
+		by_lhs[r.Rule.Lhs] = append(by_lhs[r.Rule.Lhs], r)
+	}
+
+	for _, lhs := range lhss {
+		g.line()
+		g.line("table[", strconv.Quote(lhs), "] = func(tk *slgr.Token) (Node, error) {")
+
+		for _, r := range by_lhs[lhs] {
+			g.rule(r)
+		}
+
+		g.line("err := fmt.Errorf(\"unexpected children of %s\", tk.Type)")
+		g.line("return nil, err")
+		g.line("}")
+	}
+
+	g.line()
+	g.line("return table")
+	g.line("}")
+}
+
+// rule writes the case of the given rule in the function of its left-hand
+// side.
+//
+// Parameters:
+//   - r: The rule, with its annotation. (Assumed to not be nil)
+func (g *generator) rule(r *Rule) {
+	rhss := make([]string, 0, len(r.Rule.Rhss))
+
+	for _, rhs := range r.Rule.Rhss {
+		rhss = append(rhss, ", "+strconv.Quote(rhs))
+	}
+
+	g.line("if isRule(tk", strings.Join(rhss, ""), ") {")
+
+	a := r.Annotation
+
+	if a.Node == "" {
+		g.line("node, err := slpast.ApplyAST(tk.Children[", strconv.Itoa(a.Index-1), "], table)")
+		g.line("return node, err")
+		g.line("}")
+		g.line()
+
+		return
+	}
+
+	for _, f := range a.Fields {
+		_, ok := g.non_terminals[r.Rule.Rhss[f.Index-1]]
+		if !ok {
+			continue
+		}
+
+		g.line(f.Name, "_node, err := slpast.ApplyAST(tk.Children[", strconv.Itoa(f.Index-1), "], table)")
+		g.line("if err != nil {")
+		g.line("return nil, err")
+		g.line("}")
+		g.line()
+	}
+
+	g.line("node := &", a.Node, "{")
+
+	for _, f := range a.Fields {
+		_, ok := g.non_terminals[r.Rule.Rhss[f.Index-1]]
+		if ok {
+			g.line(fieldName(f.Name), ": ", f.Name, "_node,")
+		} else {
+			g.line(fieldName(f.Name), ": tk.Children[", strconv.Itoa(f.Index-1), "].Data,")
+		}
+	}
+
+	g.line("}")
+	g.line()
+	g.line("return node, nil")
+	g.line("}")
+	g.line()
+}
+
+// Generate generates the Go code of the AST of the given annotated rules: the
+// Node interface, one type per annotated node, and a NewASTMaker function
+// that returns the slpast.ASTMaker table building them from parse trees. The
+// parse trees must have one child per right-hand side symbol of their rule;
+// so they must not be flattened.
+//
+// Parameters:
+//   - pkg: The name of the package of the code.
+//   - rules: The annotated rules. See ParseRules.
+//
+// Returns:
+//   - []byte: The formatted Go code.
+//   - error: An error if the rules cannot be generated.
+//
+// Errors:
+//   - common.ErrBadParam: If the package name is not valid or if a rule is
+//     nil.
+//   - any other error: If an annotation is missing or not valid.
+func Generate(pkg string, rules []*Rule) ([]byte, error) {
+	if !isIdentifier(pkg) {
+		err := common.NewErrBadParam("pkg", "is not a valid package name")
+		return nil, err
+	}
+
+	g := &generator{
+		rules:         make([]*Rule, 0, len(rules)),
+		non_terminals: make(map[string]struct{}),
+	}
+
+	for _, r := range rules {
+		if r == nil || r.Rule == nil {
+			err := common.NewErrBadParam("rules", "must not contain nil rules")
+			return nil, err
+		}
+
+		g.rules = append(g.rules, &Rule{
+			Rule:       r.Rule,
+			Annotation: r.Annotation,
+		})
+
+		g.non_terminals[r.Rule.Lhs] = struct{}{}
+	}
+
+	err := g.resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	g.header(pkg)
+
+	for _, t := range g.types {
+		g.nodeType(t)
+	}
+
+	g.maker()
+
+	code, err := format.Source([]byte(g.b.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
@@ -0,0 +1,167 @@
+package gen
+
+import (
+	"errors"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// typeCheck parses and type-checks the given generated code.
+//
+// Returns the paths of the imports of the code.
+func typeCheck(t *testing.T, code []byte) []string {
+	t.Helper()
+
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "ast.go", code, 0)
+	if err != nil {
+		t.Fatalf("the generated code does not parse: %v\n%s", err, code)
+	}
+
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+	}
+
+	_, err = conf.Check("example/ast", fset, []*ast.File{file}, nil)
+	if err != nil {
+		t.Fatalf("the generated code does not type-check: %v\n%s", err, code)
+	}
+
+	var imports []string
+
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		imports = append(imports, path)
+	}
+
+	return imports
+}
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     string
+		imports []string
+	}{
+		{
+			name: "data and node fields",
+			src: `
+				// Expressions.
+				Expr = Expr plus Term . -> Binary(left=$1, op=$2, right=$3)
+				Expr = Term .
+				Term = number .         -> Number(value=$1)
+				Term = op Expr cp .     -> $2
+			`,
+			imports: []string{"fmt", "strconv", "strings", "github.com/PlayerR9/SlParser/ast", "github.com/PlayerR9/SlParser/grammar"},
+		},
+		{
+			name: "node fields only",
+			src: `
+				List = List Item . -> Cons(head=$1, tail=$2)
+				List = Item .      -> Single(item=$1)
+				Item = x .         -> Leaf()
+			`,
+			imports: []string{"fmt", "strings", "github.com/PlayerR9/SlParser/ast", "github.com/PlayerR9/SlParser/grammar"},
+		},
+		{
+			name:    "no rules",
+			src:     "",
+			imports: []string{"github.com/PlayerR9/SlParser/ast", "github.com/PlayerR9/SlParser/grammar"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := ParseRules(test.src)
+			if err != nil {
+				t.Fatalf("ParseRules() returned an error: %v", err)
+			}
+
+			code, err := Generate("ast", rules)
+			if err != nil {
+				t.Fatalf("Generate() returned an error: %v", err)
+			}
+
+			imports := typeCheck(t, code)
+
+			if !slices.Equal(imports, test.imports) {
+				t.Errorf("the generated code imports %q, want %q", imports, test.imports)
+			}
+		})
+	}
+}
+
+// TestGenerateGolden checks that the generated code of the exprast package,
+// whose makers are tested there, is up to date.
+func TestGenerateGolden(t *testing.T) {
+	dir := filepath.Join("internal", "exprast")
+
+	src, err := os.ReadFile(filepath.Join(dir, "grammar.txt"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned an error: %v", err)
+	}
+
+	want, err := os.ReadFile(filepath.Join(dir, "ast.go"))
+	if err != nil {
+		t.Fatalf("ReadFile() returned an error: %v", err)
+	}
+
+	rules, err := ParseRules(string(src))
+	if err != nil {
+		t.Fatalf("ParseRules() returned an error: %v", err)
+	}
+
+	code, err := Generate("exprast", rules)
+	if err != nil {
+		t.Fatalf("Generate() returned an error: %v", err)
+	}
+
+	if string(code) != string(want) {
+		t.Errorf("Generate() differs from %s; run go generate in %s:\n%s", filepath.Join(dir, "ast.go"), dir, code)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		pkg  string
+		src  string
+	}{
+		{"bad package", "not a name", "A = x . -> Leaf()"},
+		{"missing annotation", "ast", "A = x y ."},
+		{"missing symbol", "ast", "A = x . -> Leaf(v=$2)"},
+		{"node of a terminal", "ast", "A = x . -> $1"},
+		{"reserved name", "ast", "A = x . -> Node()"},
+		{"other fields", "ast", "A = x . -> Leaf(v=$1)\nA = B . -> Leaf(v=$1)\nB = y . -> Other()"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, err := ParseRules(test.src)
+			if err != nil {
+				t.Fatalf("ParseRules() returned an error: %v", err)
+			}
+
+			code, err := Generate(test.pkg, rules)
+			if err == nil {
+				t.Fatalf("Generate() returned no error:\n%s", code)
+			}
+
+			var bad *common.ErrBadParam
+			if errors.As(err, &bad) != (test.pkg != "ast") {
+				t.Errorf("Generate() returned %v", err)
+			}
+		})
+	}
+}
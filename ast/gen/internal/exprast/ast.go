@@ -0,0 +1,167 @@
+// Code generated by github.com/PlayerR9/SlParser/ast/gen. DO NOT EDIT.
+
+package exprast
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	slpast "github.com/PlayerR9/SlParser/ast"
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// Node is a node of the AST.
+type Node interface {
+	slgr.TreeNode
+
+	// GetChildren returns the child nodes of the node.
+	//
+	// Returns:
+	//   - []Node: The child nodes, or nil if there are none.
+	GetChildren() []Node
+}
+
+// Binary is the node built by:
+//
+//	Expr = Expr plus Term .
+type Binary struct {
+	// Left is the node of the "left" field.
+	Left Node
+
+	// Op is the data of the "op" field.
+	Op string
+
+	// Right is the node of the "right" field.
+	Right Node
+}
+
+// String implements Node.
+func (n Binary) String() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("Binary[")
+	_, _ = builder.WriteString("op=")
+	_, _ = builder.WriteString(strconv.Quote(n.Op))
+	_, _ = builder.WriteRune(']')
+
+	str := builder.String()
+	return str
+}
+
+// GetChildren implements Node.
+func (n Binary) GetChildren() []Node {
+	var children []Node
+
+	if n.Left != nil {
+		children = append(children, n.Left)
+	}
+
+	if n.Right != nil {
+		children = append(children, n.Right)
+	}
+
+	return children
+}
+
+// Number is the node built by:
+//
+//	Term = number .
+type Number struct {
+	// Value is the data of the "value" field.
+	Value string
+}
+
+// String implements Node.
+func (n Number) String() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("Number[")
+	_, _ = builder.WriteString("value=")
+	_, _ = builder.WriteString(strconv.Quote(n.Value))
+	_, _ = builder.WriteRune(']')
+
+	str := builder.String()
+	return str
+}
+
+// GetChildren implements Node.
+func (n Number) GetChildren() []Node {
+	var children []Node
+
+	return children
+}
+
+// isRule checks whether the children of the given token are the right-hand
+// side of a rule.
+func isRule(tk *slgr.Token, rhss ...string) bool {
+	if len(tk.Children) != len(rhss) {
+		return false
+	}
+
+	for i, rhs := range rhss {
+		if tk.Children[i] == nil || tk.Children[i].Type != rhs {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewASTMaker returns the table that builds the nodes of the parse trees.
+//
+// Returns:
+//   - slpast.ASTMaker[Node]: The table. Never returns nil.
+func NewASTMaker() slpast.ASTMaker[Node] {
+	table := make(slpast.ASTMaker[Node])
+
+	table["Expr"] = func(tk *slgr.Token) (Node, error) {
+		if isRule(tk, "Expr", "plus", "Term") {
+			left_node, err := slpast.ApplyAST(tk.Children[0], table)
+			if err != nil {
+				return nil, err
+			}
+
+			right_node, err := slpast.ApplyAST(tk.Children[2], table)
+			if err != nil {
+				return nil, err
+			}
+
+			node := &Binary{
+				Left:  left_node,
+				Op:    tk.Children[1].Data,
+				Right: right_node,
+			}
+
+			return node, nil
+		}
+
+		if isRule(tk, "Term") {
+			node, err := slpast.ApplyAST(tk.Children[0], table)
+			return node, err
+		}
+
+		err := fmt.Errorf("unexpected children of %s", tk.Type)
+		return nil, err
+	}
+
+	table["Term"] = func(tk *slgr.Token) (Node, error) {
+		if isRule(tk, "number") {
+			node := &Number{
+				Value: tk.Children[0].Data,
+			}
+
+			return node, nil
+		}
+
+		if isRule(tk, "op", "Expr", "cp") {
+			node, err := slpast.ApplyAST(tk.Children[1], table)
+			return node, err
+		}
+
+		err := fmt.Errorf("unexpected children of %s", tk.Type)
+		return nil, err
+	}
+
+	return table
+}
@@ -0,0 +1,148 @@
+package exprast
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	slpast "github.com/PlayerR9/SlParser/ast"
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	slpx "github.com/PlayerR9/SlParser/parser"
+)
+
+// exprRules is the grammar of grammar.txt.
+var exprRules = []*slgr.Rule{
+	slgr.NewRule("Expr", "Expr", "plus", "Term"),
+	slgr.NewRule("Expr", "Term"),
+	slgr.NewRule("Term", "number"),
+	slgr.NewRule("Term", "op", "Expr", "cp"),
+}
+
+// exprTypes is the token type of each symbol of the expressions.
+var exprTypes = map[string]string{
+	"+": "plus",
+	"(": "op",
+	")": "cp",
+}
+
+// parse parses the given space-separated expression.
+func parse(t *testing.T, src string) *slgr.Token {
+	t.Helper()
+
+	table, err := slpx.NewTable(exprRules)
+	if err != nil {
+		t.Fatalf("NewTable() returned an error: %v", err)
+	}
+
+	var b slpx.Builder
+
+	_ = b.SetTable(table)
+
+	var tokens []*slgr.Token
+
+	for _, field := range strings.Fields(src) {
+		type_, ok := exprTypes[field]
+		if !ok {
+			type_ = "number"
+		}
+
+		tokens = append(tokens, slgr.NewToken(type_, field))
+	}
+
+	forest, err := b.Compile().Parse(tokens)
+	if err != nil {
+		t.Fatalf("Parse(%q) returned an error: %v", src, err)
+	} else if len(forest) != 1 {
+		t.Fatalf("Parse(%q) returned %d trees, want 1", src, len(forest))
+	}
+
+	return forest[0]
+}
+
+func TestNewASTMaker(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want Node
+	}{
+		{
+			name: "number",
+			src:  "1",
+			want: &Number{Value: "1"},
+		},
+		{
+			name: "binary",
+			src:  "1 + 2",
+			want: &Binary{Left: &Number{Value: "1"}, Op: "+", Right: &Number{Value: "2"}},
+		},
+		{
+			name: "pass-through",
+			src:  "( ( 1 ) + 2 )",
+			want: &Binary{Left: &Number{Value: "1"}, Op: "+", Right: &Number{Value: "2"}},
+		},
+		{
+			name: "left-associative",
+			src:  "1 + ( 2 ) + 3",
+			want: &Binary{
+				Left:  &Binary{Left: &Number{Value: "1"}, Op: "+", Right: &Number{Value: "2"}},
+				Op:    "+",
+				Right: &Number{Value: "3"},
+			},
+		},
+	}
+
+	table := NewASTMaker()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := slpast.ApplyAST(parse(t, test.src), table)
+			if err != nil {
+				t.Fatalf("ApplyAST() returned an error: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ApplyAST() = %#v, want %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestNewASTMakerUnexpectedChildren(t *testing.T) {
+	tests := []struct {
+		name string
+		tk   *slgr.Token
+		want string
+	}{
+		{
+			name: "Expr",
+			tk: &slgr.Token{
+				Type:     "Expr",
+				Children: []*slgr.Token{slgr.NewToken("plus", "+")},
+			},
+			want: "unexpected children of Expr",
+		},
+		{
+			name: "Term",
+			tk: &slgr.Token{
+				Type:     "Term",
+				Children: []*slgr.Token{slgr.NewToken("number", "1"), slgr.NewToken("number", "2")},
+			},
+			want: "unexpected children of Term",
+		},
+	}
+
+	table := NewASTMaker()
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := slpast.ApplyAST(test.tk, table)
+			if err == nil {
+				t.Fatalf("ApplyAST() = %v, want an error", node)
+			}
+
+			if !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ApplyAST() returned %q, want it to contain %q", err.Error(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,5 @@
+// Package exprast is the AST generated from the grammar of grammar.txt. It is
+// the golden file of the generator and checks that the generated makers work.
+package exprast
+
+//go:generate go run github.com/PlayerR9/SlParser/cmd/astgen -pkg exprast -o ast.go grammar.txt
@@ -0,0 +1,87 @@
+package ast
+
+import (
+	"errors"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// listKind is the typed kind of the tokens of lists.
+type listKind int
+
+const (
+	listList listKind = iota
+	listItems
+	listId
+	listSemi
+)
+
+// String implements fmt.Stringer.
+func (k listKind) String() string {
+	switch k {
+	case listList:
+		return "List"
+	case listItems:
+		return "Items"
+	case listId:
+		return "id"
+	case listSemi:
+		return "semi"
+	default:
+		return "listKind?"
+	}
+}
+
+func TestTypedKindAST(t *testing.T) {
+	id := func(data string, offset int) *slgr.TokenOf[listKind] {
+		tk := slgr.NewToken(listId, data)
+		tk.Pos = slgr.Position{Offset: offset, Line: 1, Column: offset + 1}
+
+		return tk
+	}
+
+	items := slgr.NewToken(listItems, "")
+	_ = items.AppendChildren([]*slgr.TokenOf[listKind]{id("a", 0), id("b", 2)})
+
+	root := slgr.NewToken(listList, "")
+	_ = root.AppendChildren([]*slgr.TokenOf[listKind]{items, id("c", 4)})
+
+	err := Flatten(root, []listKind{listItems})
+	if err != nil {
+		t.Fatalf("Flatten() returned an error: %v", err)
+	}
+
+	var builder BuilderOf[listKind, leaf]
+
+	_ = builder.Add(listId, func(tk *slgr.TokenOf[listKind]) (leaf, error) {
+		return leaf{data: tk.Data}, nil
+	})
+
+	table := builder.Build()
+
+	d, err := Destructure(root, Some(listId), Optional(listSemi))
+	if err != nil {
+		t.Fatalf("Destructure() returned an error: %v", err)
+	}
+
+	nodes, err := Nodes(d, 0, table)
+	if err != nil || len(nodes) != 3 || nodes[2].data != "c" {
+		t.Errorf("Nodes() = %v, %v, want the leaves a, b and c", nodes, err)
+	}
+
+	_, err = Destructure(root, One(listSemi))
+
+	var ce *ChildErrorOf[listKind]
+	if !errors.As(err, &ce) || ce.Parent != listList {
+		t.Fatalf("Destructure() returned %v, want a ChildErrorOf[listKind] of List", err)
+	}
+
+	if want := `1:1: List child 0: want token type to be "semi", got "id"`; err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+
+	if _, err := ApplyAST(root, table); err == nil || err.Error() != "unknown type: List" {
+		t.Errorf("ApplyAST() returned %v, want an unknown type error", err)
+	}
+}
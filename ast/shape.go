@@ -0,0 +1,495 @@
+package ast
+
+import (
+	"errors"
+	"strconv"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// ChildErrorOf occurs when a child of a token does not have the expected
+// shape or cannot be converted into a node.
+type ChildErrorOf[K slgr.Kind] struct {
+	// Parent is the type of the parent token; that is, the left-hand side of
+	// its rule.
+	Parent K
+
+	// Index is the index of the child, or the number of children if a child
+	// is missing.
+	Index int
+
+	// Pos is the position of the child; or, if it is missing, of the parent.
+	Pos slgr.Position
+
+	// Err is the reason of the error.
+	Err error
+}
+
+// ChildError is the child error of the string-based API.
+type ChildError = ChildErrorOf[string]
+
+// Error implements error.
+//
+// Format:
+//
+//	"<pos>: <parent> child <index>: <err>"
+//
+// Where the position is omitted if it is not valid.
+func (e ChildErrorOf[K]) Error() string {
+	var str string
+
+	if e.Pos.IsValid() {
+		str = e.Pos.String() + ": "
+	}
+
+	str += slgr.KindString(e.Parent) + " child " + strconv.Itoa(e.Index) + ": " + e.Err.Error()
+	return str
+}
+
+// Unwrap returns the reason of the error.
+//
+// Returns:
+//   - error: The reason of the error.
+func (e ChildErrorOf[K]) Unwrap() error {
+	return e.Err
+}
+
+// NewChildError returns an error for the given child of the given token.
+//
+// Parameters:
+//   - tk: The parent token. (Assumed to not be nil)
+//   - idx: The index of the child, or the number of children if it is
+//     missing.
+//   - reason: The reason of the error.
+//
+// Returns:
+//   - error: An instance of ChildErrorOf. Never returns nil.
+func NewChildError[K slgr.Kind](tk *slgr.TokenOf[K], idx int, reason error) error {
+	pos := tk.Pos
+
+	if idx < len(tk.Children) && tk.Children[idx] != nil && tk.Children[idx].Pos.IsValid() {
+		pos = tk.Children[idx].Pos
+	}
+
+	e := &ChildErrorOf[K]{
+		Parent: tk.Type,
+		Index:  idx,
+		Pos:    pos,
+		Err:    reason,
+	}
+
+	return e
+}
+
+// PartOf is a part of the expected shape of the children of a token. See
+// One, Optional, Many and Some.
+type PartOf[K slgr.Kind] struct {
+	// type_ is the type of the children of the part.
+	type_ K
+
+	// min is the minimum number of children of the part.
+	min int
+
+	// max is the maximum number of children of the part, or -1 if there is no
+	// maximum.
+	max int
+}
+
+// Part is the part of the string-based API.
+type Part = PartOf[string]
+
+// One returns a part that matches exactly one child of the given type.
+//
+// Parameters:
+//   - type_: The type of the child.
+//
+// Returns:
+//   - PartOf[K]: The part.
+func One[K slgr.Kind](type_ K) PartOf[K] {
+	p := PartOf[K]{
+		type_: type_,
+		min:   1,
+		max:   1,
+	}
+
+	return p
+}
+
+// Optional returns a part that matches zero or one child of the given type.
+//
+// Parameters:
+//   - type_: The type of the child.
+//
+// Returns:
+//   - PartOf[K]: The part.
+func Optional[K slgr.Kind](type_ K) PartOf[K] {
+	p := PartOf[K]{
+		type_: type_,
+		min:   0,
+		max:   1,
+	}
+
+	return p
+}
+
+// Many returns a part that matches zero or more children of the given type.
+//
+// Parameters:
+//   - type_: The type of the children.
+//
+// Returns:
+//   - PartOf[K]: The part.
+func Many[K slgr.Kind](type_ K) PartOf[K] {
+	p := PartOf[K]{
+		type_: type_,
+		min:   0,
+		max:   -1,
+	}
+
+	return p
+}
+
+// Some returns a part that matches one or more children of the given type.
+//
+// Parameters:
+//   - type_: The type of the children.
+//
+// Returns:
+//   - PartOf[K]: The part.
+func Some[K slgr.Kind](type_ K) PartOf[K] {
+	p := PartOf[K]{
+		type_: type_,
+		min:   1,
+		max:   -1,
+	}
+
+	return p
+}
+
+// DestructuredOf is the children of a token split according to a shape. See
+// Destructure.
+type DestructuredOf[K slgr.Kind] struct {
+	// Parent is the destructured token.
+	Parent *slgr.TokenOf[K]
+
+	// parts is, for each part of the shape, the indices of its children.
+	parts [][]int
+}
+
+// Destructured is the split children of the string-based API.
+type Destructured = DestructuredOf[string]
+
+// Tokens returns the children of the given part.
+//
+// Parameters:
+//   - part: The index of the part in the shape.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The children, or nil if there are none.
+func (d DestructuredOf[K]) Tokens(part int) []*slgr.TokenOf[K] {
+	if part < 0 || part >= len(d.parts) || len(d.parts[part]) == 0 {
+		return nil
+	}
+
+	tokens := make([]*slgr.TokenOf[K], 0, len(d.parts[part]))
+
+	for _, idx := range d.parts[part] {
+		tokens = append(tokens, d.Parent.Children[idx])
+	}
+
+	return tokens
+}
+
+// Token returns the first child of the given part; such as the child of a
+// One or of an Optional part.
+//
+// Parameters:
+//   - part: The index of the part in the shape.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The child, or nil if there is none.
+func (d DestructuredOf[K]) Token(part int) *slgr.TokenOf[K] {
+	if part < 0 || part >= len(d.parts) || len(d.parts[part]) == 0 {
+		return nil
+	}
+
+	return d.Parent.Children[d.parts[part][0]]
+}
+
+// destructurer matches the children of a token against a shape.
+type destructurer[K slgr.Kind] struct {
+	// children is the children of the token.
+	children []*slgr.TokenOf[K]
+
+	// shape is the expected shape.
+	shape []PartOf[K]
+
+	// parts is, for each part, the indices of its children so far.
+	parts [][]int
+
+	// furthest is the index of the furthest child that could not be matched.
+	furthest int
+
+	// want is the type expected at the furthest child, if wanted is true.
+	want K
+
+	// wanted is false if no more children were expected at the furthest
+	// child.
+	wanted bool
+
+	// failed is the set of (part, child) index pairs already known not to
+	// match, so that backtracking tries each pair at most once.
+	failed map[[2]int]bool
+}
+
+// fail records that the given child could not be matched.
+//
+// Parameters:
+//   - idx: The index of the child.
+//   - want: The expected type, if wanted is true.
+//   - wanted: False if no child was expected.
+func (d *destructurer[K]) fail(idx int, want K, wanted bool) {
+	if idx > d.furthest || idx == d.furthest && !d.wanted {
+		d.furthest = idx
+		d.want = want
+		d.wanted = wanted
+	}
+}
+
+// match matches the children from the given index against the parts from
+// the given index. Repeated parts take as many children as possible. Failures
+// are memoized, so the whole match takes at most O(children * parts) calls.
+//
+// Parameters:
+//   - part: The index of the part.
+//   - idx: The index of the child.
+//
+// Returns:
+//   - bool: True if the children match, false otherwise.
+func (d *destructurer[K]) match(part, idx int) bool {
+	if part == len(d.shape) {
+		if idx == len(d.children) {
+			return true
+		}
+
+		var zero K
+
+		d.fail(idx, zero, false)
+		return false
+	}
+
+	key := [2]int{part, idx}
+	if d.failed[key] {
+		return false
+	}
+
+	p := d.shape[part]
+
+	// Children of the part's type from idx.
+	n := 0
+
+	for idx+n < len(d.children) && (p.max < 0 || n < p.max) && d.children[idx+n] != nil && d.children[idx+n].Type == p.type_ {
+		n++
+	}
+
+	if n < p.min {
+		d.fail(idx+n, p.type_, true)
+		d.failed[key] = true
+
+		return false
+	}
+
+	for ; n >= p.min; n-- {
+		indices := make([]int, 0, n)
+
+		for i := range n {
+			indices = append(indices, idx+i)
+		}
+
+		d.parts[part] = indices
+
+		if d.match(part+1, idx+n) {
+			return true
+		}
+	}
+
+	d.parts[part] = nil
+	d.failed[key] = true
+
+	return false
+}
+
+// Destructure splits the children of the given token according to the given
+// shape; that is, one part after the other. Repeated parts take as many
+// children as possible.
+//
+// Parameters:
+//   - tk: The token.
+//   - shape: The expected shape of the children.
+//
+// Returns:
+//   - *DestructuredOf[K]: The split children, or nil if they do not match
+//     the shape.
+//   - error: An error if the children do not match the shape.
+//
+// Errors:
+//   - common.ErrBadParam: If the token is nil.
+//   - *ChildErrorOf[K]: If the children do not match the shape. It names the
+//     first child that does not match; and its reason is a *slgr.ErrWant,
+//     unless the child is past the end of the shape.
+func Destructure[K slgr.Kind](tk *slgr.TokenOf[K], shape ...PartOf[K]) (*DestructuredOf[K], error) {
+	if tk == nil {
+		err := common.NewErrNilParam("tk")
+		return nil, err
+	}
+
+	d := &destructurer[K]{
+		children: tk.Children,
+		shape:    shape,
+		parts:    make([][]int, len(shape)),
+		failed:   make(map[[2]int]bool),
+	}
+
+	if !d.match(0, 0) {
+		var got *string
+
+		if d.furthest < len(tk.Children) && tk.Children[d.furthest] != nil {
+			type_ := slgr.KindString(tk.Children[d.furthest].Type)
+			got = &type_
+		}
+
+		var reason error
+
+		if !d.wanted && got == nil {
+			reason = errors.New("unexpected child")
+		} else if !d.wanted {
+			reason = errors.New("unexpected child " + strconv.Quote(*got))
+		} else {
+			reason = slgr.NewErrWant(true, "token type", slgr.KindString(d.want), got)
+		}
+
+		err := NewChildError(tk, d.furthest, reason)
+		return nil, err
+	}
+
+	destructured := &DestructuredOf[K]{
+		Parent: tk,
+		parts:  d.parts,
+	}
+
+	return destructured, nil
+}
+
+// ApplyChild converts the given child of the given token into a node, with
+// the given table. See ApplyAST.
+//
+// Parameters:
+//   - tk: The parent token.
+//   - idx: The index of the child.
+//   - table: A table of functions that convert a token into a node of type N.
+//
+// Returns:
+//   - N: The node of the child.
+//   - error: An error if the child cannot be converted.
+//
+// Errors:
+//   - common.ErrBadParam: If the token is nil.
+//   - *ChildErrorOf[K]: If the child is missing or cannot be converted.
+func ApplyChild[K slgr.Kind, N slgr.TreeNode](tk *slgr.TokenOf[K], idx int, table ASTMakerOf[K, N]) (N, error) {
+	if tk == nil {
+		err := common.NewErrNilParam("tk")
+		return *new(N), err
+	}
+
+	if idx < 0 || idx >= len(tk.Children) {
+		err := NewChildError(tk, len(tk.Children), errors.New("missing child"))
+		return *new(N), err
+	}
+
+	node, err := ApplyAST(tk.Children[idx], table)
+	if err != nil {
+		err := NewChildError(tk, idx, err)
+		return *new(N), err
+	}
+
+	return node, nil
+}
+
+// Nodes converts the children of the given part into nodes, with the given
+// table. Every child is converted, even if some fail, so that every error is
+// reported.
+//
+// Parameters:
+//   - d: The destructured children.
+//   - part: The index of the part in the shape.
+//   - table: A table of functions that convert a token into a node of type N.
+//
+// Returns:
+//   - []N: The nodes of the children that were converted.
+//   - error: The errors of the children that could not be converted, joined
+//     with errors.Join; or nil if there are none.
+//
+// Errors:
+//   - common.ErrBadParam: If the destructured children are nil.
+//   - *ChildErrorOf[K]: For each child that cannot be converted.
+func Nodes[K slgr.Kind, N slgr.TreeNode](d *DestructuredOf[K], part int, table ASTMakerOf[K, N]) ([]N, error) {
+	if d == nil {
+		err := common.NewErrNilParam("d")
+		return nil, err
+	}
+
+	if part < 0 || part >= len(d.parts) {
+		return nil, nil
+	}
+
+	nodes := make([]N, 0, len(d.parts[part]))
+
+	var errs []error
+
+	for _, idx := range d.parts[part] {
+		node, err := ApplyChild(d.Parent, idx, table)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			nodes = append(nodes, node)
+		}
+	}
+
+	err := errors.Join(errs...)
+	return nodes, err
+}
+
+// Node converts the first child of the given part into a node, with the given
+// table; such as the child of a One or of an Optional part.
+//
+// Parameters:
+//   - d: The destructured children.
+//   - part: The index of the part in the shape.
+//   - table: A table of functions that convert a token into a node of type N.
+//
+// Returns:
+//   - N: The node of the child, or the zero value if the part has no child.
+//   - bool: True if the part has a child, false otherwise.
+//   - error: An error if the child cannot be converted.
+//
+// Errors:
+//   - common.ErrBadParam: If the destructured children are nil.
+//   - *ChildErrorOf[K]: If the child cannot be converted.
+func Node[K slgr.Kind, N slgr.TreeNode](d *DestructuredOf[K], part int, table ASTMakerOf[K, N]) (N, bool, error) {
+	if d == nil {
+		err := common.NewErrNilParam("d")
+		return *new(N), false, err
+	}
+
+	if part < 0 || part >= len(d.parts) || len(d.parts[part]) == 0 {
+		return *new(N), false, nil
+	}
+
+	node, err := ApplyChild(d.Parent, d.parts[part][0], table)
+	if err != nil {
+		return *new(N), true, err
+	}
+
+	return node, true, nil
+}
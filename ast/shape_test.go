@@ -0,0 +1,292 @@
+package ast
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// positioned returns a token of the given type with the given children, whose
+// children are positioned on line 1, two columns apart.
+func positioned(type_ string, children ...string) *slgr.Token {
+	tk := &slgr.Token{
+		Type: type_,
+		Pos:  slgr.Position{Offset: 0, Line: 1, Column: 1},
+	}
+
+	for i, child := range children {
+		tk.Children = append(tk.Children, &slgr.Token{
+			Type: child,
+			Data: child + string(rune('0'+i)),
+			Pos:  slgr.Position{Offset: 2 * i, Line: 1, Column: 2*i + 1},
+		})
+	}
+
+	return tk
+}
+
+// partData returns the data of the children of each part.
+func partData(d *Destructured, parts int) [][]string {
+	data := make([][]string, 0, parts)
+
+	for i := range parts {
+		var tokens []string
+
+		for _, tk := range d.Tokens(i) {
+			tokens = append(tokens, tk.Data)
+		}
+
+		data = append(data, tokens)
+	}
+
+	return data
+}
+
+func TestDestructure(t *testing.T) {
+	tests := []struct {
+		name     string
+		children []string
+		shape    []Part
+		want     [][]string
+	}{
+		{
+			name:     "one, many and optional",
+			children: []string{"kw", "id", "id", "semi"},
+			shape:    []Part{One("kw"), Many("id"), Optional("semi")},
+			want:     [][]string{{"kw0"}, {"id1", "id2"}, {"semi3"}},
+		},
+		{
+			name:     "absent optional and many",
+			children: []string{"kw"},
+			shape:    []Part{One("kw"), Many("id"), Optional("semi")},
+			want:     [][]string{{"kw0"}, nil, nil},
+		},
+		{
+			name:     "repetition gives back children",
+			children: []string{"id", "id", "id"},
+			shape:    []Part{Some("id"), One("id")},
+			want:     [][]string{{"id0", "id1"}, {"id2"}},
+		},
+		{
+			name:     "no children",
+			children: nil,
+			shape:    []Part{Optional("kw"), Many("id")},
+			want:     [][]string{nil, nil},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tk := positioned("Stmt", test.children...)
+
+			d, err := Destructure(tk, test.shape...)
+			if err != nil {
+				t.Fatalf("Destructure() returned an error: %v", err)
+			}
+
+			got := partData(d, len(test.shape))
+
+			if !slices.EqualFunc(got, test.want, slices.Equal) {
+				t.Errorf("Destructure() = %q, want %q", got, test.want)
+			}
+
+			for i, want := range test.want {
+				first := d.Token(i)
+
+				if len(want) == 0 && first != nil {
+					t.Errorf("Token(%d) = %v, want nil", i, first)
+				} else if len(want) > 0 && (first == nil || first.Data != want[0]) {
+					t.Errorf("Token(%d) = %v, want %s", i, first, want[0])
+				}
+			}
+
+			if d.Tokens(-1) != nil || d.Token(len(test.shape)) != nil {
+				t.Errorf("out of range parts have children")
+			}
+		})
+	}
+}
+
+func TestDestructureErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		children []string
+		shape    []Part
+		want     string
+		index    int
+	}{
+		{
+			name:     "missing child",
+			children: []string{"kw"},
+			shape:    []Part{One("kw"), Some("id")},
+			want:     `1:1: Stmt child 1: want token type to be "id", got nothing`,
+			index:    1,
+		},
+		{
+			name:     "wrong child",
+			children: []string{"kw", "semi"},
+			shape:    []Part{One("kw"), One("id")},
+			want:     `1:3: Stmt child 1: want token type to be "id", got "semi"`,
+			index:    1,
+		},
+		{
+			name:     "extra child",
+			children: []string{"kw", "id", "id"},
+			shape:    []Part{One("kw"), One("id")},
+			want:     `1:5: Stmt child 2: unexpected child "id"`,
+			index:    2,
+		},
+		{
+			name:     "furthest failure",
+			children: []string{"id", "id", "kw"},
+			shape:    []Part{Many("id"), One("id"), One("semi")},
+			want:     `1:5: Stmt child 2: want token type to be "id", got "kw"`,
+			index:    2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Destructure(positioned("Stmt", test.children...), test.shape...)
+			if err == nil {
+				t.Fatalf("Destructure() returned no error")
+			}
+
+			if err.Error() != test.want {
+				t.Errorf("Destructure() returned %q, want %q", err.Error(), test.want)
+			}
+
+			var ce *ChildError
+			if !errors.As(err, &ce) {
+				t.Fatalf("Destructure() returned %T, want a *ChildError", err)
+			} else if ce.Index != test.index {
+				t.Errorf("ChildError.Index = %d, want %d", ce.Index, test.index)
+			}
+		})
+	}
+
+	// Without memoization, the failing match below backtracks through every
+	// split of the ids between the repeated parts.
+	ids := make([]string, 300)
+	for i := range ids {
+		ids[i] = "id"
+	}
+
+	_, err := Destructure(positioned("Stmt", ids...), Many("id"), Many("id"), Many("id"), Many("id"), One("semi"))
+
+	var ce *ChildError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Destructure() returned %v, want a *ChildError", err)
+	} else if ce.Index != len(ids) {
+		t.Errorf("ChildError.Index = %d, want %d", ce.Index, len(ids))
+	}
+
+	_, err = Destructure(nil, One("kw"))
+	if err == nil {
+		t.Errorf("Destructure(nil) returned no error")
+	}
+}
+
+// leaf is a node of the tests.
+type leaf struct {
+	// data is the data of the token of the node.
+	data string
+}
+
+// String implements slgr.TreeNode.
+func (l leaf) String() string {
+	return "leaf " + l.data
+}
+
+// errBad is the error of the "bad" tokens.
+var errBad = errors.New("bad token")
+
+// leafTable converts "id" tokens into leaves, and fails on "bad" tokens.
+var leafTable = ASTMaker[leaf]{
+	"id": func(tk *slgr.Token) (leaf, error) {
+		return leaf{data: tk.Data}, nil
+	},
+	"bad": func(tk *slgr.Token) (leaf, error) {
+		return leaf{}, errBad
+	},
+}
+
+func TestNodes(t *testing.T) {
+	tk := positioned("List", "kw", "id", "bad", "id", "bad")
+
+	d, err := Destructure(tk, Optional("kw"), Many("id"), Many("bad"), Many("id"), Many("bad"), Optional("semi"))
+	if err != nil {
+		t.Fatalf("Destructure() returned an error: %v", err)
+	}
+
+	nodes, err := Nodes(d, 1, leafTable)
+	if err != nil || !slices.Equal(nodes, []leaf{{data: "id1"}}) {
+		t.Errorf("Nodes() = %v, %v, want [leaf id1], nil", nodes, err)
+	}
+
+	node, ok, err := Node(d, 5, leafTable)
+	if ok || err != nil || node != (leaf{}) {
+		t.Errorf("Node() of an absent child = %v, %t, %v, want the zero value, false, nil", node, ok, err)
+	}
+
+	node, ok, err = Node(d, 2, leafTable)
+
+	var ce *ChildError
+	if !ok || !errors.As(err, &ce) || ce.Index != 2 || ce.Pos.Column != 5 || !errors.Is(err, errBad) {
+		t.Errorf("Node() of a bad child = %v, %t, %v, want a ChildError of child 2 at 1:5", node, ok, err)
+	}
+
+	// Every child is converted, even after a failure.
+	d, err = Destructure(positioned("List", "bad", "bad"), Many("bad"))
+	if err != nil {
+		t.Fatalf("Destructure() returned an error: %v", err)
+	}
+
+	nodes, err = Nodes(d, 0, leafTable)
+	if len(nodes) != 0 {
+		t.Errorf("Nodes() = %v, want no nodes", nodes)
+	}
+
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Fatalf("Nodes() returned %v, want two joined errors", err)
+	}
+
+	for i, err := range joined.Unwrap() {
+		if !errors.As(err, &ce) || ce.Index != i {
+			t.Errorf("error %d = %v, want a ChildError of child %d", i, err, i)
+		}
+	}
+
+	_, err = Nodes[string, leaf](nil, 0, leafTable)
+	if err == nil {
+		t.Errorf("Nodes(nil) returned no error")
+	}
+}
+
+func TestApplyChild(t *testing.T) {
+	tk := positioned("Pair", "id", "kw")
+
+	node, err := ApplyChild(tk, 0, leafTable)
+	if err != nil || node.data != "id0" {
+		t.Errorf("ApplyChild(0) = %v, %v, want leaf id0, nil", node, err)
+	}
+
+	tests := []struct {
+		idx  int
+		want string
+	}{
+		{1, "1:3: Pair child 1: unknown type: kw"},
+		{2, "1:1: Pair child 2: missing child"},
+		{-1, "1:1: Pair child 2: missing child"},
+	}
+
+	for _, test := range tests {
+		_, err := ApplyChild(tk, test.idx, leafTable)
+		if err == nil || err.Error() != test.want {
+			t.Errorf("ApplyChild(%d) returned %v, want %q", test.idx, err, test.want)
+		}
+	}
+}
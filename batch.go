@@ -0,0 +1,210 @@
+package slp
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strconv"
+	"sync"
+
+	slpast "github.com/PlayerR9/SlParser/ast"
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	sllx "github.com/PlayerR9/SlParser/lexer"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	slpx "github.com/PlayerR9/SlParser/parser"
+)
+
+// Options is the configuration of ParseAll.
+type Options[N slgr.TreeNode] struct {
+	// Lexer is the definition of the lexers of the workers. Must not be nil.
+	Lexer *sllx.Definition
+
+	// Parser is the definition of the parsers of the workers. Must not be nil.
+	Parser *slpx.Definition
+
+	// Helpers is the list of helper non-terminals removed from the root
+	// tokens, if any. See Result.Flatten.
+	Helpers []string
+
+	// Ast is the table used to build the nodes of the root tokens. If nil, the
+	// results hold no node.
+	Ast slpast.ASTMaker[N]
+
+	// Workers is the maximum number of inputs processed at the same time. If
+	// not positive, runtime.GOMAXPROCS(0) is used.
+	Workers int
+}
+
+// InputError is the error of one of the inputs of ParseAll.
+type InputError struct {
+	// Index is the index of the input.
+	Index int
+
+	// Err is the error of the input.
+	Err error
+}
+
+// Error implements error.
+//
+// Format:
+//
+//	"input <index>: <err>"
+func (e InputError) Error() string {
+	str := "input " + strconv.Itoa(e.Index) + ": " + e.Err.Error()
+	return str
+}
+
+// Unwrap returns the error of the input.
+//
+// Returns:
+//   - error: The error of the input.
+func (e InputError) Unwrap() error {
+	return e.Err
+}
+
+// process lexes, parses and builds the node of one input data.
+//
+// Parameters:
+//   - ctx: The context. (Assumed to not be nil)
+//   - lexer: The lexer of the worker. (Assumed to not be nil)
+//   - parser: The parser of the worker. (Assumed to not be nil)
+//   - data: The input data.
+//   - opts: The options of ParseAll.
+//
+// Returns:
+//   - Result[N]: The result of the input data.
+func process[N slgr.TreeNode](ctx context.Context, lexer *sllx.Lexer, parser *slpx.Parser, data []byte, opts Options[N]) Result[N] {
+	r := NewResult[N](data).LexContext(ctx, lexer)
+	if r.HasError() {
+		return r
+	}
+
+	r = r.ParseContext(ctx, parser)
+	if r.HasError() {
+		return r
+	}
+
+	if len(opts.Helpers) > 0 {
+		r = r.Flatten(opts.Helpers)
+		if r.HasError() {
+			return r
+		}
+	}
+
+	if opts.Ast != nil {
+		r = r.Ast(opts.Ast)
+	}
+
+	return r
+}
+
+// ParseAll lexes, parses and builds the nodes of many input data
+// concurrently, with at most opts.Workers of them at the same time. Each
+// worker has its own lexer and parser, spawned from the definitions of the
+// options.
+//
+// Once the context is done, the inputs that were not processed yet are not
+// processed at all and their results hold the error of the context.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - inputs: The input data.
+//   - opts: The options.
+//
+// Returns:
+//   - []Result[N]: The results, in the order of the inputs; or nil if the
+//     options are not valid.
+//   - error: The errors of the inputs, each one as an InputError and joined
+//     with errors.Join; or nil if every input succeeded.
+//
+// Errors:
+//   - common.ErrBadParam: If the context, the lexer or the parser definition
+//     is nil.
+//   - InputError: If an input fails.
+func ParseAll[N slgr.TreeNode](ctx context.Context, inputs [][]byte, opts Options[N]) ([]Result[N], error) {
+	if ctx == nil {
+		err := common.NewErrNilParam("ctx")
+		return nil, err
+	} else if opts.Lexer == nil {
+		err := common.NewErrNilParam("opts.Lexer")
+		return nil, err
+	} else if opts.Parser == nil {
+		err := common.NewErrNilParam("opts.Parser")
+		return nil, err
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	workers = min(workers, len(inputs))
+
+	results := make([]Result[N], len(inputs))
+
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			lexer := opts.Lexer.NewLexer()
+			parser := opts.Parser.NewParser()
+
+			for i := range indices {
+				// The context may be done since the input was dispatched.
+				err := ctx.Err()
+				if err != nil {
+					r := NewResult[N](inputs[i])
+					r.err = err
+
+					results[i] = r
+					continue
+				}
+
+				results[i] = process(ctx, lexer, parser, inputs[i], opts)
+			}
+		}()
+	}
+
+	for i, data := range inputs {
+		// When both cases are ready, select chooses one at random; so the
+		// context is checked first, for no input to be dispatched once it is
+		// done.
+		err := ctx.Err()
+		if err == nil {
+			select {
+			case indices <- i:
+				continue
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+		}
+
+		r := NewResult[N](data)
+		r.err = err
+
+		results[i] = r
+	}
+
+	close(indices)
+	wg.Wait()
+
+	var errs []error
+
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, &InputError{
+				Index: i,
+				Err:   r.err,
+			})
+		}
+	}
+
+	err := errors.Join(errs...)
+	return results, err
+}
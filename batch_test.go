@@ -0,0 +1,108 @@
+package slp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+func TestParseAll(t *testing.T) {
+	ld, pd := sumDefinitions(t)
+
+	inputs := [][]byte{
+		[]byte("1 + 2"),
+		[]byte("(3)"),
+		[]byte("4 +"),
+		[]byte("5 + (6 + 7)"),
+		[]byte("8 ? 9"),
+	}
+
+	opts := Options[*slgr.Token]{
+		Lexer:   ld,
+		Parser:  pd,
+		Workers: 2,
+	}
+
+	results, err := ParseAll(context.Background(), inputs, opts)
+	if len(results) != len(inputs) {
+		t.Fatalf("ParseAll() returned %d results, want %d", len(results), len(inputs))
+	}
+
+	for i, r := range results {
+		data, _ := r.GetData()
+		if string(data) != string(inputs[i]) {
+			t.Errorf("result #%d holds %q, want %q", i, data, inputs[i])
+		}
+	}
+
+	var failed []int
+
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ie *InputError
+		if !errors.As(e, &ie) {
+			t.Fatalf("error %v is not an *InputError", e)
+		}
+
+		failed = append(failed, ie.Index)
+	}
+
+	if len(failed) != 2 || failed[0] != 2 || failed[1] != 4 {
+		t.Errorf("failed inputs = %v, want [2 4]", failed)
+	}
+
+	for _, i := range []int{0, 1, 3} {
+		if results[i].HasError() {
+			t.Errorf("result #%d has an error: %v", i, results[i].GetError())
+		}
+	}
+}
+
+func TestParseAllCanceled(t *testing.T) {
+	ld, pd := sumDefinitions(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	inputs := make([][]byte, 100)
+	for i := range inputs {
+		inputs[i] = []byte("1 + 2")
+	}
+
+	results, err := ParseAll(ctx, inputs, Options[*slgr.Token]{Lexer: ld, Parser: pd})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ParseAll() returned %v, want context.Canceled", err)
+	}
+
+	for i, r := range results {
+		if !errors.Is(r.GetError(), context.Canceled) {
+			t.Errorf("result #%d has error %v, want context.Canceled", i, r.GetError())
+		}
+
+		if _, err := r.GetTokens(); err == nil {
+			t.Errorf("input #%d was lexed after the context was done", i)
+		}
+	}
+}
+
+func TestParseAllBadOptions(t *testing.T) {
+	ld, pd := sumDefinitions(t)
+
+	tests := []struct {
+		name string
+		opts Options[*slgr.Token]
+	}{
+		{"no lexer", Options[*slgr.Token]{Parser: pd}},
+		{"no parser", Options[*slgr.Token]{Lexer: ld}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results, err := ParseAll(context.Background(), [][]byte{[]byte("1")}, test.opts)
+			if err == nil || results != nil {
+				t.Errorf("ParseAll() = %v, %v, want an error", results, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Command astgen generates the AST of an annotated grammar. See the package
+// github.com/PlayerR9/SlParser/ast/gen for the annotations.
+//
+// Usage:
+//
+//	astgen -pkg <package> [-o <output>] <grammar>
+//
+// It is meant to be run by go generate, so that the AST stays in sync with the
+// grammar:
+//
+//	//go:generate go run github.com/PlayerR9/SlParser/cmd/astgen -pkg ast -o ast.go grammar.txt
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	gen "github.com/PlayerR9/SlParser/ast/gen"
+)
+
+var (
+	// pkg is the name of the package of the generated code.
+	pkg = flag.String("pkg", "", "the name of the package of the generated code")
+
+	// output is the file the generated code is written to.
+	output = flag.String("o", "", "the output file; the standard output if empty")
+)
+
+// run generates the AST of the given grammar file.
+//
+// Parameters:
+//   - path: The path of the grammar file.
+//
+// Returns:
+//   - error: An error if the generation fails.
+func run(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	rules, err := gen.ParseRules(string(data))
+	if err != nil {
+		err := fmt.Errorf("in %s: %w", path, err)
+		return err
+	}
+
+	code, err := gen.Generate(*pkg, rules)
+	if err != nil {
+		err := fmt.Errorf("in %s: %w", path, err)
+		return err
+	}
+
+	if *output == "" {
+		_, err := os.Stdout.Write(code)
+		return err
+	}
+
+	err = os.WriteFile(*output, code, 0o644)
+	return err
+}
+
+func main() {
+	flag.Parse()
+
+	if *pkg == "" || flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: astgen -pkg <package> [-o <output>] <grammar>")
+		os.Exit(2)
+	}
+
+	err := run(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "astgen:", err)
+		os.Exit(1)
+	}
+}
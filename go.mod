@@ -1,6 +1,6 @@
 module github.com/PlayerR9/SlParser
 
-go 1.23.4
+go 1.24
 
 require github.com/PlayerR9/go-verify v0.1.4
 require github.com/PlayerR9/mygo-data v0.1.2
@@ -0,0 +1,673 @@
+package grammar
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Path is the path from the root of a tree to one of its nodes; that is, the
+// index of the child taken at each level. The root has an empty path.
+type Path []int
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"/<index>/<index>/..."
+//
+// Where the root is "/".
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+
+	var builder strings.Builder
+
+	for _, idx := range p {
+		_, _ = builder.WriteRune('/')
+		_, _ = builder.WriteString(strconv.Itoa(idx))
+	}
+
+	str := builder.String()
+	return str
+}
+
+// child returns the path of the given child of the node at this path.
+//
+// Parameters:
+//   - idx: The index of the child.
+//
+// Returns:
+//   - Path: The path of the child.
+func (p Path) child(idx int) Path {
+	path := append(slices.Clip(p), idx)
+	return path
+}
+
+// ChangeKind is the kind of a change between two trees.
+type ChangeKind int
+
+const (
+	// Insert is a subtree of the new tree that is not in the old one.
+	Insert ChangeKind = iota
+
+	// Delete is a subtree of the old tree that is not in the new one.
+	Delete
+
+	// Relabel is a node whose type or data changed; its children are compared
+	// separately.
+	Relabel
+
+	// Move is a subtree of the old tree found, unchanged, at another place of
+	// the new tree.
+	Move
+)
+
+// String implements fmt.Stringer.
+func (k ChangeKind) String() string {
+	switch k {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	case Relabel:
+		return "relabel"
+	case Move:
+		return "move"
+	default:
+		return "ChangeKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// ChangeOf is a change between two trees whose types are kinds of type K. See
+// Diff.
+type ChangeOf[K Kind] struct {
+	// Kind is the kind of the change.
+	Kind ChangeKind
+
+	// OldPath is the path of the node in the old tree, or nil for an Insert.
+	OldPath Path
+
+	// NewPath is the path of the node in the new tree, or nil for a Delete.
+	NewPath Path
+
+	// Old is the node in the old tree, or nil for an Insert.
+	Old *TokenOf[K]
+
+	// New is the node in the new tree, or nil for a Delete.
+	New *TokenOf[K]
+}
+
+// Change is a change between two trees of the string-based API.
+type Change = ChangeOf[string]
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"insert at <new path>: <new>"
+//	"delete at <old path>: <old>"
+//	"relabel at <old path> (now <new path>): <old> to <new>"
+//	"move from <old path> to <new path>: <old>"
+//
+// Where "(now <new path>)" is omitted if both paths are the same.
+func (c ChangeOf[K]) String() string {
+	var str string
+
+	switch c.Kind {
+	case Insert:
+		str = "insert at " + c.NewPath.String() + ": " + tokenString(c.New)
+	case Delete:
+		str = "delete at " + c.OldPath.String() + ": " + tokenString(c.Old)
+	case Relabel:
+		str = "relabel at " + c.OldPath.String()
+
+		if !slices.Equal(c.OldPath, c.NewPath) {
+			str += " (now " + c.NewPath.String() + ")"
+		}
+
+		str += ": " + tokenString(c.Old) + " to " + tokenString(c.New)
+	case Move:
+		str = "move from " + c.OldPath.String() + " to " + c.NewPath.String() + ": " + tokenString(c.Old)
+	default:
+		str = c.Kind.String()
+	}
+
+	return str
+}
+
+// tokenString returns the string representation of the given token.
+//
+// Parameters:
+//   - tk: The token.
+//
+// Returns:
+//   - string: The string representation, or "<nil>" if the token is nil.
+func tokenString[K Kind](tk *TokenOf[K]) string {
+	if tk == nil {
+		return "<nil>"
+	}
+
+	return tk.String()
+}
+
+// lcs returns the longest common subsequence of two sequences.
+//
+// Parameters:
+//   - n: The length of the first sequence.
+//   - m: The length of the second sequence.
+//   - eq: The function that checks whether the i-th element of the first
+//     sequence matches the j-th element of the second one.
+//
+// Returns:
+//   - [][2]int: The indices of the matched elements, in order.
+func lcs(n, m int, eq func(i, j int) bool) [][2]int {
+	// table[i][j] is the length of the LCS of the sequences from i and j.
+	table := make([][]int, n+1)
+
+	for i := range table {
+		table[i] = make([]int, m+1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if eq(i, j) {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+
+	var pairs [][2]int
+
+	for i, j := 0, 0; i < n && j < m; {
+		if eq(i, j) {
+			pairs = append(pairs, [2]int{i, j})
+			i++
+			j++
+		} else if table[i+1][j] >= table[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return pairs
+}
+
+// differ compares two trees.
+type differ[K Kind] struct {
+	// kinds maps the types of the nodes to their ids.
+	kinds map[K]int
+
+	// keys maps the structure of the subtrees to their ids.
+	keys map[string]int
+
+	// ids is the id of each subtree; two subtrees have the same id if and only
+	// if they have the same structure.
+	ids map[*TokenOf[K]]int
+
+	// changes is the changes so far.
+	changes []ChangeOf[K]
+}
+
+// id returns the id of the given subtree; that is, of its type, data and
+// children, ignoring the positions.
+//
+// Parameters:
+//   - tk: The root of the subtree.
+//
+// Returns:
+//   - int: The id of the subtree, or 0 if it is nil.
+func (d *differ[K]) id(tk *TokenOf[K]) int {
+	if tk == nil {
+		return 0
+	}
+
+	id, ok := d.ids[tk]
+	if ok {
+		return id
+	}
+
+	kind, ok := d.kinds[tk.Type]
+	if !ok {
+		kind = len(d.kinds)
+		d.kinds[tk.Type] = kind
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(strconv.Itoa(kind))
+	_, _ = builder.WriteString(strconv.Quote(tk.Data))
+
+	for _, child := range tk.Children {
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(strconv.Itoa(d.id(child)))
+	}
+
+	key := builder.String()
+
+	id, ok = d.keys[key]
+	if !ok {
+		id = len(d.keys) + 1
+		d.keys[key] = id
+	}
+
+	d.ids[tk] = id
+
+	return id
+}
+
+// node compares two matched nodes and their children.
+//
+// Parameters:
+//   - old: The node in the old tree. (Assumed to not be nil)
+//   - new: The node in the new tree. (Assumed to not be nil)
+//   - old_path: The path of the old node.
+//   - new_path: The path of the new node.
+func (d *differ[K]) node(old, new *TokenOf[K], old_path, new_path Path) {
+	if d.id(old) == d.id(new) {
+		return
+	}
+
+	if old.Type != new.Type || old.Data != new.Data {
+		d.changes = append(d.changes, ChangeOf[K]{
+			Kind:    Relabel,
+			OldPath: old_path,
+			NewPath: new_path,
+			Old:     old,
+			New:     new,
+		})
+	}
+
+	d.children(old, new, old_path, new_path)
+}
+
+// sameShape checks whether two nodes have children of the same types, in the
+// same order.
+//
+// Parameters:
+//   - a: The first node. (Assumed to not be nil)
+//   - b: The second node. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the children have the same types, false otherwise.
+func sameShape[K Kind](a, b *TokenOf[K]) bool {
+	if len(a.Children) != len(b.Children) {
+		return false
+	}
+
+	for i, child := range a.Children {
+		other := b.Children[i]
+
+		if child == nil || other == nil {
+			if child != other {
+				return false
+			}
+		} else if child.Type != other.Type {
+			return false
+		}
+	}
+
+	return true
+}
+
+// pair pairs the old children in [oi, oe) with the new children in [nj, ne)
+// by the longest common subsequence under the first equivalence; then,
+// between the paired children, by the next equivalences. Paired children are
+// compared recursively, and the unpaired ones are deleted or inserted.
+//
+// Parameters:
+//   - oc: The old children.
+//   - nc: The new children.
+//   - oi: The index of the first old child.
+//   - oe: The index past the last old child.
+//   - nj: The index of the first new child.
+//   - ne: The index past the last new child.
+//   - old_path: The path of the old parent.
+//   - new_path: The path of the new parent.
+//   - eqs: The equivalences, from the strongest to the weakest.
+func (d *differ[K]) pair(oc, nc []*TokenOf[K], oi, oe, nj, ne int, old_path, new_path Path, eqs []func(a, b *TokenOf[K]) bool) {
+	if len(eqs) == 0 {
+		for i := oi; i < oe; i++ {
+			if oc[i] == nil {
+				continue
+			}
+
+			d.changes = append(d.changes, ChangeOf[K]{
+				Kind:    Delete,
+				OldPath: old_path.child(i),
+				Old:     oc[i],
+			})
+		}
+
+		for j := nj; j < ne; j++ {
+			if nc[j] == nil {
+				continue
+			}
+
+			d.changes = append(d.changes, ChangeOf[K]{
+				Kind:    Insert,
+				NewPath: new_path.child(j),
+				New:     nc[j],
+			})
+		}
+
+		return
+	}
+
+	pairs := lcs(oe-oi, ne-nj, func(a, b int) bool {
+		return oc[oi+a] != nil && nc[nj+b] != nil && eqs[0](oc[oi+a], nc[nj+b])
+	})
+
+	pairs = append(pairs, [2]int{oe - oi, ne - nj})
+
+	i, j := oi, nj
+
+	for _, p := range pairs {
+		d.pair(oc, nc, i, oi+p[0], j, nj+p[1], old_path, new_path, eqs[1:])
+
+		i, j = oi+p[0], nj+p[1]
+
+		// The last pair only marks the end of the children.
+		if i < oe && j < ne {
+			d.node(oc[i], nc[j], old_path.child(i), new_path.child(j))
+		}
+
+		i++
+		j++
+	}
+}
+
+// children compares the children of two matched nodes. Identical subtrees are
+// matched first; then, between them, the children of the same type; and,
+// between those, the children whose own children have the same types, so
+// that a child whose type changed is relabeled. Paired children are compared
+// recursively, and the unpaired ones are deleted or inserted.
+//
+// Parameters:
+//   - old: The node in the old tree. (Assumed to not be nil)
+//   - new: The node in the new tree. (Assumed to not be nil)
+//   - old_path: The path of the old node.
+//   - new_path: The path of the new node.
+func (d *differ[K]) children(old, new *TokenOf[K], old_path, new_path Path) {
+	eqs := []func(a, b *TokenOf[K]) bool{
+		func(a, b *TokenOf[K]) bool { return d.id(a) == d.id(b) },
+		func(a, b *TokenOf[K]) bool { return a.Type == b.Type },
+		sameShape,
+	}
+
+	d.pair(old.Children, new.Children, 0, len(old.Children), 0, len(new.Children), old_path, new_path, eqs)
+}
+
+// find returns the first descendant, in pre-order, of the given subtree with
+// the given id that is not used yet.
+//
+// Parameters:
+//   - tk: The root of the subtree. (Assumed to not be nil)
+//   - path: The path of the root.
+//   - id: The id to find.
+//   - used: The nodes that are already part of a move.
+//
+// Returns:
+//   - *Token: The descendant, or nil if there is none.
+//   - Path: The path of the descendant.
+func (d *differ[K]) find(tk *TokenOf[K], path Path, id int, used map[*TokenOf[K]]struct{}) (*TokenOf[K], Path) {
+	for i, child := range tk.Children {
+		if child == nil {
+			continue
+		}
+
+		child_path := path.child(i)
+
+		_, ok := used[child]
+		if !ok && d.id(child) == id {
+			return child, child_path
+		}
+
+		found, found_path := d.find(child, child_path, id, used)
+		if found != nil {
+			return found, found_path
+		}
+	}
+
+	return nil, nil
+}
+
+// moves turns the deleted subtrees that are found, unchanged, elsewhere in the
+// new tree into moves. A deleted subtree is first paired, in order, with an
+// identical inserted subtree, which is then no longer reported. Otherwise,
+// it is looked for inside the inserted subtrees; and an inserted subtree is
+// likewise looked for inside the deleted ones. In both cases, the enclosing
+// subtree is still reported.
+func (d *differ[K]) moves() {
+	removed := make([]bool, len(d.changes))
+	used_old := make(map[*TokenOf[K]]struct{})
+	used_new := make(map[*TokenOf[K]]struct{})
+
+	for i, c := range d.changes {
+		if c.Kind != Delete {
+			continue
+		}
+
+		for j, other := range d.changes {
+			if removed[j] || other.Kind != Insert || d.id(other.New) != d.id(c.Old) {
+				continue
+			}
+
+			removed[j] = true
+			used_old[c.Old] = struct{}{}
+			used_new[other.New] = struct{}{}
+
+			d.changes[i] = ChangeOf[K]{
+				Kind:    Move,
+				OldPath: c.OldPath,
+				NewPath: other.NewPath,
+				Old:     c.Old,
+				New:     other.New,
+			}
+
+			break
+		}
+	}
+
+	for i, c := range d.changes {
+		if c.Kind != Delete {
+			continue
+		}
+
+		for j, other := range d.changes {
+			if removed[j] || other.Kind != Insert {
+				continue
+			}
+
+			found, path := d.find(other.New, other.NewPath, d.id(c.Old), used_new)
+			if found == nil {
+				continue
+			}
+
+			used_new[found] = struct{}{}
+
+			d.changes[i] = ChangeOf[K]{
+				Kind:    Move,
+				OldPath: c.OldPath,
+				NewPath: path,
+				Old:     c.Old,
+				New:     found,
+			}
+
+			break
+		}
+	}
+
+	for i, c := range d.changes {
+		if removed[i] || c.Kind != Insert {
+			continue
+		}
+
+		_, ok := used_new[c.New]
+		if ok {
+			continue
+		}
+
+		for _, other := range d.changes {
+			if other.Kind != Delete {
+				continue
+			}
+
+			found, path := d.find(other.Old, other.OldPath, d.id(c.New), used_old)
+			if found == nil {
+				continue
+			}
+
+			used_old[found] = struct{}{}
+
+			d.changes[i] = ChangeOf[K]{
+				Kind:    Move,
+				OldPath: path,
+				NewPath: c.NewPath,
+				Old:     found,
+				New:     c.New,
+			}
+
+			break
+		}
+	}
+
+	changes := make([]ChangeOf[K], 0, len(d.changes))
+
+	for i, c := range d.changes {
+		if !removed[i] {
+			changes = append(changes, c)
+		}
+	}
+
+	d.changes = changes
+}
+
+// Diff compares two token trees structurally; that is, by the types, data and
+// children of their nodes, ignoring the positions.
+//
+// The roots are always matched. Within matched nodes, identical children are
+// matched first, then the children of the same type, then the children whose
+// own children have the same types, in order; the latter are compared
+// recursively and reported as relabeled if their type or data differs. The
+// remaining children are reported as deleted or inserted, except for the
+// subtrees found unchanged elsewhere in the other tree, which are reported as
+// moved; even across parents, and into or out of inserted or deleted
+// subtrees. A subtree that is both moved and changed is reported as deleted
+// and inserted.
+//
+// Parameters:
+//   - old: The root of the old tree.
+//   - new: The root of the new tree.
+//
+// Returns:
+//   - []Change: The changes, in the order of the trees; or nil if the trees
+//     are the same.
+func Diff[K Kind](old, new *TokenOf[K]) []ChangeOf[K] {
+	if old == nil && new == nil {
+		return nil
+	} else if old == nil {
+		c := ChangeOf[K]{
+			Kind:    Insert,
+			NewPath: Path{},
+			New:     new,
+		}
+
+		return []ChangeOf[K]{c}
+	} else if new == nil {
+		c := ChangeOf[K]{
+			Kind:    Delete,
+			OldPath: Path{},
+			Old:     old,
+		}
+
+		return []ChangeOf[K]{c}
+	}
+
+	d := &differ[K]{
+		kinds: make(map[K]int),
+		keys:  make(map[string]int),
+		ids:   make(map[*TokenOf[K]]int),
+	}
+
+	d.node(old, new, Path{}, Path{})
+	d.moves()
+
+	if len(d.changes) == 0 {
+		return nil
+	}
+
+	return d.changes
+}
+
+// writeSubtree writes the given subtree, one node per line, each line
+// prefixed by the given prefix.
+//
+// Parameters:
+//   - b: The builder to write to. (Assumed to not be nil)
+//   - prefix: The prefix of each line.
+//   - tk: The root of the subtree.
+func writeSubtree[K Kind](b *strings.Builder, prefix string, tk *TokenOf[K]) {
+	if tk == nil {
+		return
+	}
+
+	tree := strings.TrimSuffix(TreeToString(tk), "\n")
+
+	for _, line := range strings.Split(tree, "\n") {
+		_, _ = b.WriteString(prefix)
+		_, _ = b.WriteString(line)
+		_, _ = b.WriteRune('\n')
+	}
+}
+
+// FormatDiff returns a human-readable form of the given changes: a header
+// line per change, followed by the subtrees it concerns, prefixed by "-" for
+// the old tree and "+" for the new one.
+//
+// Format:
+//
+//	@@ <change> @@
+//	- <old subtree>
+//	+ <new subtree>
+//
+// Where a relabeled node is shown without its children, and a moved subtree
+// is shown once, prefixed by spaces.
+//
+// Parameters:
+//   - changes: The changes. See Diff.
+//
+// Returns:
+//   - string: The human-readable form, or an empty string if there are no
+//     changes.
+func FormatDiff[K Kind](changes []ChangeOf[K]) string {
+	var builder strings.Builder
+
+	for _, c := range changes {
+		_, _ = builder.WriteString("@@ ")
+		_, _ = builder.WriteString(c.String())
+		_, _ = builder.WriteString(" @@\n")
+
+		switch c.Kind {
+		case Insert:
+			writeSubtree(&builder, "+ ", c.New)
+		case Delete:
+			writeSubtree(&builder, "- ", c.Old)
+		case Relabel:
+			_, _ = builder.WriteString("- ")
+			_, _ = builder.WriteString(tokenString(c.Old))
+			_, _ = builder.WriteString("\n+ ")
+			_, _ = builder.WriteString(tokenString(c.New))
+			_, _ = builder.WriteRune('\n')
+		case Move:
+			writeSubtree(&builder, "  ", c.Old)
+		}
+	}
+
+	str := builder.String()
+	return str
+}
@@ -0,0 +1,212 @@
+package grammar
+
+import (
+	"slices"
+	"testing"
+)
+
+// mustSExpr decodes the given S-expression.
+func mustSExpr(t *testing.T, src string) *Token {
+	t.Helper()
+
+	tk, err := DecodeSExpr(src)
+	if err != nil {
+		t.Fatalf("DecodeSExpr(%q) returned an error: %v", src, err)
+	}
+
+	return tk
+}
+
+// changeStrings returns the string representations of the given changes.
+func changeStrings(changes []Change) []string {
+	var strs []string
+
+	for _, c := range changes {
+		strs = append(strs, c.String())
+	}
+
+	return strs
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []string
+	}{
+		{
+			name: "same trees at other positions",
+			old:  `(S 1:1:0 (id "x" 1:1:0) (semi 1:2:1))`,
+			new:  `(S 2:1:5 (id "x" 2:1:5) (semi 2:3:7))`,
+			want: nil,
+		},
+		{
+			name: "relabel",
+			old:  `(S (id "x") (semi))`,
+			new:  `(S (id "y") (semi))`,
+			want: []string{`relabel at /0: Token[id ("x")] to Token[id ("y")]`},
+		},
+		{
+			name: "relabel at another path",
+			old:  `(S (a) (id "x"))`,
+			new:  `(S (id "y"))`,
+			want: []string{
+				"delete at /0: Token[a]",
+				`relabel at /1 (now /0): Token[id ("x")] to Token[id ("y")]`,
+			},
+		},
+		{
+			name: "insert",
+			old:  "(S (a) (c))",
+			new:  "(S (a) (b) (c))",
+			want: []string{"insert at /1: Token[b]"},
+		},
+		{
+			name: "delete",
+			old:  "(S (a) (b (x)) (c))",
+			new:  "(S (a) (c))",
+			want: []string{"delete at /1: Token[b]"},
+		},
+		{
+			name: "nested change",
+			old:  `(S (Stmt (id "x") (semi)))`,
+			new:  `(S (Stmt (id "x") (eq) (semi)))`,
+			want: []string{"insert at /0/1: Token[eq]"},
+		},
+		{
+			name: "swapped siblings",
+			old:  "(S (A (x)) (B (y)))",
+			new:  "(S (B (y)) (A (x)))",
+			want: []string{"move from /0 to /1: Token[A]"},
+		},
+		{
+			name: "move across parents",
+			old:  `(S (P (x "1")) (Q))`,
+			new:  `(S (P) (Q (x "1")))`,
+			want: []string{`move from /0/0 to /1/0: Token[x ("1")]`},
+		},
+		{
+			name: "move out of a deleted subtree",
+			old:  "(S (W (k)))",
+			new:  "(S (k))",
+			want: []string{
+				"delete at /0: Token[W]",
+				"move from /0/0 to /0: Token[k]",
+			},
+		},
+		{
+			name: "move into an inserted subtree",
+			old:  "(S (k))",
+			new:  "(S (W (k)))",
+			want: []string{
+				"move from /0 to /0/0: Token[k]",
+				"insert at /0: Token[W]",
+			},
+		},
+		{
+			name: "relabeled type below the root",
+			old:  "(S (a))",
+			new:  "(S (b))",
+			want: []string{"relabel at /0: Token[a] to Token[b]"},
+		},
+		{
+			name: "relabeled type with the same children",
+			old:  `(S (E (id "x") (semi)) (c))`,
+			new:  `(S (F (id "y") (semi)) (c))`,
+			want: []string{
+				"relabel at /0: Token[E] to Token[F]",
+				`relabel at /0/0: Token[id ("x")] to Token[id ("y")]`,
+			},
+		},
+		{
+			name: "no move into a moved subtree",
+			old:  "(S (P (W (k))) (Q (k)))",
+			new:  "(S (P) (Q) (W (k)))",
+			want: []string{
+				"move from /0/0 to /2: Token[W]",
+				"delete at /1/0: Token[k]",
+			},
+		},
+		{
+			name: "relabeled root",
+			old:  "(S (a))",
+			new:  "(T (a))",
+			want: []string{"relabel at /: Token[S] to Token[T]"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old := mustSExpr(t, test.old)
+			new := mustSExpr(t, test.new)
+
+			got := changeStrings(Diff(old, new))
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Diff() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDiffNil(t *testing.T) {
+	tk := NewToken("S", "")
+
+	if changes := Diff[string](nil, nil); changes != nil {
+		t.Errorf("Diff(nil, nil) = %v, want nil", changes)
+	}
+
+	if got := changeStrings(Diff(nil, tk)); !slices.Equal(got, []string{"insert at /: Token[S]"}) {
+		t.Errorf("Diff(nil, tk) = %q", got)
+	}
+
+	if got := changeStrings(Diff(tk, nil)); !slices.Equal(got, []string{"delete at /: Token[S]"}) {
+		t.Errorf("Diff(tk, nil) = %q", got)
+	}
+}
+
+func TestFormatDiff(t *testing.T) {
+	old := mustSExpr(t, `(S (id "x") (B (y)) (A (z)) (c))`)
+	new := mustSExpr(t, `(S (id "w") (A (z)) (B (y)) (d (e)))`)
+
+	want := "@@ relabel at /0: Token[id (\"x\")] to Token[id (\"w\")] @@\n" +
+		"- Token[id (\"x\")]\n" +
+		"+ Token[id (\"w\")]\n" +
+		"@@ move from /1 to /2: Token[B] @@\n" +
+		"  Token[B]\n" +
+		"     Token[y]\n" +
+		"@@ delete at /3: Token[c] @@\n" +
+		"- Token[c]\n" +
+		"@@ insert at /3: Token[d] @@\n" +
+		"+ Token[d]\n" +
+		"+    Token[e]\n"
+
+	if got := FormatDiff(Diff(old, new)); got != want {
+		t.Errorf("FormatDiff() = %q, want %q", got, want)
+	}
+
+	if got := FormatDiff([]Change(nil)); got != "" {
+		t.Errorf("FormatDiff(nil) = %q, want an empty string", got)
+	}
+}
+
+func TestPathAndKindString(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{Path{}.String(), "/"},
+		{Path{0, 12, 3}.String(), "/0/12/3"},
+		{Insert.String(), "insert"},
+		{Delete.String(), "delete"},
+		{Relabel.String(), "relabel"},
+		{Move.String(), "move"},
+		{ChangeKind(9).String(), "ChangeKind(9)"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("String() = %q, want %q", test.got, test.want)
+		}
+	}
+}
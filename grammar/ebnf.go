@@ -0,0 +1,509 @@
+package grammar
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// Expr is an expression that appears on the right-hand side of an EBNF
+// production.
+type Expr interface {
+	// String returns the EBNF notation of the expression.
+	fmt.Stringer
+
+	// isExpr is a marker method that prevents other packages from implementing
+	// Expr.
+	isExpr()
+}
+
+// Symbol is an expression that refers to a single terminal or non-terminal.
+type Symbol struct {
+	// Name is the name of the symbol.
+	Name string
+}
+
+// isExpr implements Expr.
+func (*Symbol) isExpr() {}
+
+// String implements Expr.
+func (s Symbol) String() string {
+	return s.Name
+}
+
+// NewSymbol creates a new expression that refers to the given symbol.
+//
+// Parameters:
+//   - name: The name of the symbol.
+//
+// Returns:
+//   - *Symbol: The newly created expression. Never returns nil.
+func NewSymbol(name string) *Symbol {
+	s := &Symbol{
+		Name: name,
+	}
+
+	return s
+}
+
+// Sequence is an expression that matches its elements one after the other.
+type Sequence struct {
+	// Elems is the elements of the sequence.
+	Elems []Expr
+}
+
+// isExpr implements Expr.
+func (*Sequence) isExpr() {}
+
+// String implements Expr.
+func (s Sequence) String() string {
+	elems := make([]string, 0, len(s.Elems))
+
+	for _, elem := range s.Elems {
+		if elem != nil {
+			elems = append(elems, elem.String())
+		}
+	}
+
+	str := strings.Join(elems, " ")
+	return str
+}
+
+// NewSequence creates a new expression that matches the given elements one
+// after the other.
+//
+// Parameters:
+//   - elems: The elements of the sequence.
+//
+// Returns:
+//   - *Sequence: The newly created expression. Never returns nil.
+func NewSequence(elems ...Expr) *Sequence {
+	s := &Sequence{
+		Elems: elems,
+	}
+
+	return s
+}
+
+// Group is an expression that matches exactly one of its alternatives. When
+// nested inside another expression, it is written as "( a | b )".
+type Group struct {
+	// Alts is the alternatives of the group.
+	Alts []Expr
+}
+
+// isExpr implements Expr.
+func (*Group) isExpr() {}
+
+// String implements Expr.
+func (g Group) String() string {
+	alts := make([]string, 0, len(g.Alts))
+
+	for _, alt := range g.Alts {
+		if alt != nil {
+			alts = append(alts, alt.String())
+		}
+	}
+
+	str := "( " + strings.Join(alts, " | ") + " )"
+	return str
+}
+
+// NewGroup creates a new expression that matches exactly one of the given
+// alternatives.
+//
+// Parameters:
+//   - alts: The alternatives of the group.
+//
+// Returns:
+//   - *Group: The newly created expression. Never returns nil.
+func NewGroup(alts ...Expr) *Group {
+	g := &Group{
+		Alts: alts,
+	}
+
+	return g
+}
+
+// Optional is an expression that matches its inner expression zero or one
+// times. It is written as "[ a ]".
+type Optional struct {
+	// Inner is the optional expression.
+	Inner Expr
+}
+
+// isExpr implements Expr.
+func (*Optional) isExpr() {}
+
+// String implements Expr.
+func (o Optional) String() string {
+	if o.Inner == nil {
+		return "[ ]"
+	}
+
+	str := "[ " + o.Inner.String() + " ]"
+	return str
+}
+
+// NewOptional creates a new expression that matches the given expression zero
+// or one times.
+//
+// Parameters:
+//   - inner: The optional expression.
+//
+// Returns:
+//   - *Optional: The newly created expression. Never returns nil.
+func NewOptional(inner Expr) *Optional {
+	o := &Optional{
+		Inner: inner,
+	}
+
+	return o
+}
+
+// Repetition is an expression that matches its inner expression zero or more
+// times. It is written as "{ a }".
+type Repetition struct {
+	// Inner is the repeated expression.
+	Inner Expr
+}
+
+// isExpr implements Expr.
+func (*Repetition) isExpr() {}
+
+// String implements Expr.
+func (r Repetition) String() string {
+	if r.Inner == nil {
+		return "{ }"
+	}
+
+	str := "{ " + r.Inner.String() + " }"
+	return str
+}
+
+// NewRepetition creates a new expression that matches the given expression
+// zero or more times.
+//
+// Parameters:
+//   - inner: The repeated expression.
+//
+// Returns:
+//   - *Repetition: The newly created expression. Never returns nil.
+func NewRepetition(inner Expr) *Repetition {
+	r := &Repetition{
+		Inner: inner,
+	}
+
+	return r
+}
+
+// Production is a rule of the grammar in EBNF form.
+type Production struct {
+	// Lhs is the left-hand side of the production.
+	Lhs string
+
+	// Rhs is the right-hand side of the production. A top-level Group is
+	// treated as the list of alternatives of the production.
+	Rhs Expr
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<lhs> = <rhs> ."
+func (p Production) String() string {
+	var rhs string
+
+	if g, ok := p.Rhs.(*Group); ok {
+		alts := make([]string, 0, len(g.Alts))
+
+		for _, alt := range g.Alts {
+			if alt != nil {
+				alts = append(alts, alt.String())
+			}
+		}
+
+		rhs = strings.Join(alts, " | ")
+	} else if p.Rhs != nil {
+		rhs = p.Rhs.String()
+	}
+
+	str := p.Lhs + " = " + rhs + " ."
+	return str
+}
+
+// NewProduction creates a new production with the given left-hand side and
+// right-hand side.
+//
+// Parameters:
+//   - lhs: The left-hand side of the production.
+//   - rhs: The right-hand side of the production.
+//
+// Returns:
+//   - *Production: The newly created production. Never returns nil.
+func NewProduction(lhs string, rhs Expr) *Production {
+	p := &Production{
+		Lhs: lhs,
+		Rhs: rhs,
+	}
+
+	return p
+}
+
+// desugarer holds the state of a desugaring pass.
+type desugarer struct {
+	// rules is the list of helper rules generated for the current production.
+	rules []*Rule
+
+	// helpers is the list of helper non-terminals generated so far.
+	helpers []string
+
+	// used is the set of all the left-hand sides that are already taken.
+	used map[string]struct{}
+
+	// counts is the number of helpers generated for each production.
+	counts map[string]int
+}
+
+// fresh returns a new helper non-terminal for the given left-hand side.
+//
+// Helpers are named "<lhs>_<n>". Since uppercase identifiers cannot contain
+// underscores, helpers never clash with the user's non-terminals.
+//
+// Parameters:
+//   - lhs: The left-hand side of the production the helper belongs to.
+//
+// Returns:
+//   - string: The name of the new helper.
+func (d *desugarer) fresh(lhs string) string {
+	for {
+		d.counts[lhs]++
+
+		name := lhs + "_" + strconv.Itoa(d.counts[lhs])
+
+		_, ok := d.used[name]
+		if ok {
+			continue
+		}
+
+		d.used[name] = struct{}{}
+		d.helpers = append(d.helpers, name)
+
+		return name
+	}
+}
+
+// symbol returns the symbol that stands for the given expression inside a
+// sequence, generating a helper non-terminal if the expression is not a
+// plain symbol.
+//
+// Parameters:
+//   - lhs: The left-hand side of the production being desugared.
+//   - expr: The expression to turn into a symbol.
+//
+// Returns:
+//   - string: The symbol standing for the expression.
+//   - error: An error if the expression is not valid.
+func (d *desugarer) symbol(lhs string, expr Expr) (string, error) {
+	switch expr := expr.(type) {
+	case *Symbol:
+		if expr.Name == "" {
+			return "", errors.New("symbol must have a name")
+		}
+
+		return expr.Name, nil
+	case *Group:
+		helper := d.fresh(lhs)
+
+		alts, err := d.alternatives(lhs, expr)
+		if err != nil {
+			return "", err
+		}
+
+		for _, alt := range alts {
+			d.rules = append(d.rules, NewRule(helper, alt...))
+		}
+
+		return helper, nil
+	case *Optional:
+		helper := d.fresh(lhs)
+
+		alts, err := d.alternatives(lhs, expr.Inner)
+		if err != nil {
+			return "", err
+		}
+
+		d.rules = append(d.rules, NewRule(helper))
+
+		for _, alt := range alts {
+			d.rules = append(d.rules, NewRule(helper, alt...))
+		}
+
+		return helper, nil
+	case *Repetition:
+		helper := d.fresh(lhs)
+
+		alts, err := d.alternatives(lhs, expr.Inner)
+		if err != nil {
+			return "", err
+		}
+
+		// Left recursion keeps the parser stack shallow on long lists.
+		d.rules = append(d.rules, NewRule(helper))
+
+		for _, alt := range alts {
+			rhss := append([]string{helper}, alt...)
+			d.rules = append(d.rules, NewRule(helper, rhss...))
+		}
+
+		return helper, nil
+	case nil:
+		return "", errors.New("expression must not be nil")
+	default:
+		// *Sequence is inlined by the caller.
+		return "", fmt.Errorf("unexpected expression of type %T", expr)
+	}
+}
+
+// sequence appends the symbols of the given expression to rhss. Nested
+// sequences are inlined.
+//
+// Parameters:
+//   - lhs: The left-hand side of the production being desugared.
+//   - rhss: The symbols generated so far.
+//   - expr: The expression to append.
+//
+// Returns:
+//   - []string: The symbols with the expression appended.
+//   - error: An error if the expression is not valid.
+func (d *desugarer) sequence(lhs string, rhss []string, expr Expr) ([]string, error) {
+	seq, ok := expr.(*Sequence)
+	if !ok {
+		sym, err := d.symbol(lhs, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		rhss = append(rhss, sym)
+		return rhss, nil
+	}
+
+	for _, elem := range seq.Elems {
+		var err error
+
+		rhss, err = d.sequence(lhs, rhss, elem)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return rhss, nil
+}
+
+// alternatives returns the flat alternatives of the given expression.
+//
+// Parameters:
+//   - lhs: The left-hand side of the production being desugared.
+//   - expr: The expression to flatten.
+//
+// Returns:
+//   - [][]string: The right-hand sides of the alternatives.
+//   - error: An error if the expression is not valid.
+func (d *desugarer) alternatives(lhs string, expr Expr) ([][]string, error) {
+	g, ok := expr.(*Group)
+	if !ok {
+		rhss, err := d.sequence(lhs, nil, expr)
+		if err != nil {
+			return nil, err
+		}
+
+		return [][]string{rhss}, nil
+	}
+
+	if len(g.Alts) == 0 {
+		return nil, errors.New("group must have at least one alternative")
+	}
+
+	var alts [][]string
+
+	for _, alt := range g.Alts {
+		rhss, err := d.sequence(lhs, nil, alt)
+		if err != nil {
+			return nil, err
+		}
+
+		alts = append(alts, rhss)
+	}
+
+	return alts, nil
+}
+
+// Desugar rewrites the given EBNF productions into flat BNF rules.
+//
+// Every optional ("[ a ]"), repetition ("{ a }") and nested group ("( a | b )")
+// is replaced by a fresh helper non-terminal named "<lhs>_<n>" whose rules
+// derive the same language:
+//
+//	H = .        H = a .                     for "[ a ]"
+//	H = .        H = H a .                   for "{ a }"
+//	H = a .      H = b .                     for "( a | b )"
+//
+// A top-level group becomes one rule per alternative instead. The rules of a
+// production are emitted before the rules of its helpers and productions keep
+// their order; thus, the first rule's left-hand side is still the start
+// symbol.
+//
+// Parameters:
+//   - prods: The productions to desugar.
+//
+// Returns:
+//   - []*Rule: The flat BNF rules.
+//   - []string: The helper non-terminals that were generated, in order. They can
+//     be passed to ast.Flatten to turn helper chains back into list children.
+//   - error: An error if a production is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If a production is nil or has an empty left-hand side.
+//   - any other error: If the right-hand side of a production is not valid.
+func Desugar(prods []*Production) ([]*Rule, []string, error) {
+	d := &desugarer{
+		used:   make(map[string]struct{}),
+		counts: make(map[string]int),
+	}
+
+	for i, prod := range prods {
+		if prod == nil {
+			err := common.NewErrNilParam("prods[" + strconv.Itoa(i) + "]")
+			return nil, nil, err
+		} else if prod.Lhs == "" {
+			err := common.NewErrBadParam("prods["+strconv.Itoa(i)+"]", "must have a left-hand side")
+			return nil, nil, err
+		}
+
+		d.used[prod.Lhs] = struct{}{}
+	}
+
+	var rules []*Rule
+
+	for _, prod := range prods {
+		d.rules = nil
+
+		alts, err := d.alternatives(prod.Lhs, prod.Rhs)
+		if err != nil {
+			err := fmt.Errorf("in production %s: %w", strconv.Quote(prod.Lhs), err)
+			return nil, nil, err
+		}
+
+		for _, alt := range alts {
+			rules = append(rules, NewRule(prod.Lhs, alt...))
+		}
+
+		rules = append(rules, d.rules...)
+	}
+
+	return rules, d.helpers, nil
+}
@@ -0,0 +1,123 @@
+package grammar
+
+import (
+	"slices"
+	"testing"
+)
+
+// ruleStrings returns the string representation of the given rules.
+func ruleStrings(rules []*Rule) []string {
+	strs := make([]string, 0, len(rules))
+
+	for _, rule := range rules {
+		strs = append(strs, rule.String())
+	}
+
+	return strs
+}
+
+func TestDesugar(t *testing.T) {
+	tests := []struct {
+		name    string
+		prods   []*Production
+		rules   []string
+		helpers []string
+	}{
+		{
+			name: "flat sequence",
+			prods: []*Production{
+				NewProduction("Pair", NewSequence(NewSymbol("key"), NewSymbol("colon"), NewSymbol("value"))),
+			},
+			rules: []string{
+				"Pair = key colon value .",
+			},
+		},
+		{
+			name: "top-level alternatives",
+			prods: []*Production{
+				NewProduction("Value", NewGroup(NewSymbol("number"), NewSequence(NewSymbol("minus"), NewSymbol("number")))),
+			},
+			rules: []string{
+				"Value = number .",
+				"Value = minus number .",
+			},
+		},
+		{
+			name: "optional, repetition and nested group",
+			prods: []*Production{
+				NewProduction("List", NewSequence(
+					NewSymbol("Item"),
+					NewRepetition(NewSequence(NewSymbol("comma"), NewSymbol("Item"))),
+					NewOptional(NewSymbol("semicolon")),
+				)),
+				NewProduction("Item", NewGroup(NewSymbol("a"), NewSequence(NewSymbol("op"), NewGroup(NewSymbol("b"), NewSymbol("c")), NewSymbol("cl")))),
+			},
+			rules: []string{
+				"List = Item List_1 List_2 .",
+				"List_1 = .",
+				"List_1 = List_1 comma Item .",
+				"List_2 = .",
+				"List_2 = semicolon .",
+				"Item = a .",
+				"Item = op Item_1 cl .",
+				"Item_1 = b .",
+				"Item_1 = c .",
+			},
+			helpers: []string{"List_1", "List_2", "Item_1"},
+		},
+		{
+			name: "helper names do not clash with productions",
+			prods: []*Production{
+				NewProduction("A", NewOptional(NewSymbol("x"))),
+				NewProduction("A_1", NewSymbol("y")),
+			},
+			rules: []string{
+				"A = A_2 .",
+				"A_2 = .",
+				"A_2 = x .",
+				"A_1 = y .",
+			},
+			helpers: []string{"A_2"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rules, helpers, err := Desugar(test.prods)
+			if err != nil {
+				t.Fatalf("Desugar() returned an error: %v", err)
+			}
+
+			got := ruleStrings(rules)
+			if !slices.Equal(got, test.rules) {
+				t.Errorf("rules = %q, want %q", got, test.rules)
+			}
+
+			if !slices.Equal(helpers, test.helpers) {
+				t.Errorf("helpers = %q, want %q", helpers, test.helpers)
+			}
+		})
+	}
+}
+
+func TestDesugarErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		prods []*Production
+	}{
+		{"nil production", []*Production{nil}},
+		{"empty left-hand side", []*Production{NewProduction("", NewSymbol("a"))}},
+		{"nil right-hand side", []*Production{NewProduction("A", nil)}},
+		{"empty symbol", []*Production{NewProduction("A", NewSymbol(""))}},
+		{"empty group", []*Production{NewProduction("A", NewSequence(NewSymbol("a"), NewGroup()))}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Desugar(test.prods)
+			if err == nil {
+				t.Errorf("Desugar() returned no error")
+			}
+		})
+	}
+}
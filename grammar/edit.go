@@ -0,0 +1,119 @@
+package grammar
+
+import (
+	"strconv"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// Edit is a change of the input data: Deleted bytes at Offset are replaced by
+// Inserted.
+type Edit struct {
+	// Offset is the byte offset of the change.
+	Offset int
+
+	// Deleted is the number of bytes removed at Offset.
+	Deleted int
+
+	// Inserted is the text inserted at Offset.
+	Inserted string
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"@<offset> -<deleted> +<inserted>"
+func (e Edit) String() string {
+	str := "@" + strconv.Itoa(e.Offset) + " -" + strconv.Itoa(e.Deleted) + " +" + strconv.Quote(e.Inserted)
+	return str
+}
+
+// Apply applies the edit to the given data. The data is not modified.
+//
+// Parameters:
+//   - data: The data to edit.
+//
+// Returns:
+//   - []byte: The edited data.
+//   - error: An error if the edit is out of the bounds of the data.
+//
+// Errors:
+//   - common.ErrBadParam: If the edit is out of the bounds of the data.
+func (e Edit) Apply(data []byte) ([]byte, error) {
+	if e.Offset < 0 || e.Deleted < 0 || e.Offset+e.Deleted > len(data) {
+		err := common.NewErrBadParam("edit", "is out of bounds")
+		return nil, err
+	}
+
+	edited := make([]byte, 0, len(data)-e.Deleted+len(e.Inserted))
+	edited = append(edited, data[:e.Offset]...)
+	edited = append(edited, e.Inserted...)
+	edited = append(edited, data[e.Offset+e.Deleted:]...)
+
+	return edited, nil
+}
+
+// ApplyEdits applies the given edits, one after the other, to the given data;
+// so that the offset of each edit refers to the data edited by the previous
+// ones. The data is not modified.
+//
+// Parameters:
+//   - data: The data to edit.
+//   - edits: The edits to apply.
+//
+// Returns:
+//   - []byte: The edited data.
+//   - Edit: A single edit of the original data that has the same result; that
+//     is, the smallest region that was changed.
+//   - error: An error if an edit is out of bounds.
+//
+// Errors:
+//   - common.ErrBadParam: If an edit is out of the bounds of the data.
+func ApplyEdits(data []byte, edits []Edit) ([]byte, Edit, error) {
+	// [lo, hi) is the changed region of the edited data.
+	lo, hi := -1, -1
+
+	for _, e := range edits {
+		edited, err := e.Apply(data)
+		if err != nil {
+			return nil, Edit{}, err
+		}
+
+		data = edited
+
+		end := e.Offset + len(e.Inserted)
+
+		if lo < 0 {
+			lo, hi = e.Offset, end
+			continue
+		}
+
+		if hi > e.Offset+e.Deleted {
+			hi += len(e.Inserted) - e.Deleted
+		} else if hi > e.Offset {
+			hi = end
+		}
+
+		lo = min(lo, e.Offset)
+		hi = max(hi, end)
+	}
+
+	if lo < 0 {
+		return data, Edit{}, nil
+	}
+
+	delta := 0
+
+	for _, e := range edits {
+		delta += len(e.Inserted) - e.Deleted
+	}
+
+	combined := Edit{
+		Offset:   lo,
+		Deleted:  hi - delta - lo,
+		Inserted: string(data[lo:hi]),
+	}
+
+	return data, combined, nil
+}
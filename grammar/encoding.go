@@ -0,0 +1,448 @@
+package grammar
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// jsonToken is the JSON form of a token.
+type jsonToken[K Kind] struct {
+	// Type is the type of the token.
+	Type string `json:"type"`
+
+	// Data is the data of the token, if any.
+	Data string `json:"data,omitempty"`
+
+	// Pos is the position of the token, if it is valid.
+	Pos *Position `json:"pos,omitempty"`
+
+	// Children is the children of the token, if any.
+	Children []*TokenOf[K] `json:"children,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// Format:
+//
+//	{"type": "<type>", "data": "<data>", "pos": {"offset": <offset>, "line": <line>, "column": <column>}, "children": [...]}
+//
+// Where the type is written with KindString; and the data, the position and
+// the children are omitted if they are empty, not valid or missing,
+// respectively.
+func (tk TokenOf[K]) MarshalJSON() ([]byte, error) {
+	jt := jsonToken[K]{
+		Type:     KindString(tk.Type),
+		Data:     tk.Data,
+		Children: tk.Children,
+	}
+
+	if tk.Pos.IsValid() {
+		pos := tk.Pos
+		jt.Pos = &pos
+	}
+
+	data, err := json.Marshal(jt)
+	return data, err
+}
+
+// UnmarshalJSON implements json.Unmarshaler. See MarshalJSON. The type is read
+// with ParseKind.
+func (tk *TokenOf[K]) UnmarshalJSON(data []byte) error {
+	if tk == nil {
+		return common.ErrNilReceiver
+	}
+
+	var jt jsonToken[K]
+
+	err := json.Unmarshal(data, &jt)
+	if err != nil {
+		return err
+	}
+
+	type_, err := ParseKind[K](jt.Type)
+	if err != nil {
+		return err
+	}
+
+	tk.Type = type_
+	tk.Data = jt.Data
+	tk.Children = jt.Children
+	tk.Pos = Position{}
+
+	if jt.Pos != nil {
+		tk.Pos = *jt.Pos
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+//
+// Format:
+//
+//	{"offset": <offset>, "line": <line>, "column": <column>}
+func (p Position) MarshalJSON() ([]byte, error) {
+	str := `{"offset":` + strconv.Itoa(p.Offset) + `,"line":` + strconv.Itoa(p.Line) + `,"column":` + strconv.Itoa(p.Column) + `}`
+	return []byte(str), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. See MarshalJSON.
+func (p *Position) UnmarshalJSON(data []byte) error {
+	if p == nil {
+		return common.ErrNilReceiver
+	}
+
+	var jp struct {
+		Offset int `json:"offset"`
+		Line   int `json:"line"`
+		Column int `json:"column"`
+	}
+
+	err := json.Unmarshal(data, &jp)
+	if err != nil {
+		return err
+	}
+
+	p.Offset = jp.Offset
+	p.Line = jp.Line
+	p.Column = jp.Column
+
+	return nil
+}
+
+// isAtomByte checks whether the given byte can be part of an unquoted atom of
+// an S-expression.
+//
+// Parameters:
+//   - b: The byte to check.
+//
+// Returns:
+//   - bool: True if the byte can be part of an unquoted atom, false otherwise.
+func isAtomByte(b byte) bool {
+	return b > ' ' && b != '(' && b != ')' && b != '"' && b < 0x7f
+}
+
+// writeSExpr writes the S-expression of the given token.
+//
+// Parameters:
+//   - b: The builder to write to. (Assumed to not be nil)
+//   - tk: The token. (Assumed to not be nil)
+func writeSExpr[K Kind](b *strings.Builder, tk *TokenOf[K]) {
+	_, _ = b.WriteRune('(')
+
+	type_ := KindString(tk.Type)
+
+	quote := type_ == "" || '0' <= type_[0] && type_[0] <= '9'
+
+	for i := 0; i < len(type_) && !quote; i++ {
+		quote = !isAtomByte(type_[i])
+	}
+
+	if quote {
+		_, _ = b.WriteString(strconv.Quote(type_))
+	} else {
+		_, _ = b.WriteString(type_)
+	}
+
+	if tk.Data != "" {
+		_, _ = b.WriteRune(' ')
+		_, _ = b.WriteString(strconv.Quote(tk.Data))
+	}
+
+	if tk.Pos.IsValid() {
+		_, _ = b.WriteRune(' ')
+		_, _ = b.WriteString(strconv.Itoa(tk.Pos.Line))
+		_, _ = b.WriteRune(':')
+		_, _ = b.WriteString(strconv.Itoa(tk.Pos.Column))
+		_, _ = b.WriteRune(':')
+		_, _ = b.WriteString(strconv.Itoa(tk.Pos.Offset))
+	}
+
+	for _, child := range tk.Children {
+		if child == nil {
+			continue
+		}
+
+		_, _ = b.WriteRune(' ')
+		writeSExpr(b, child)
+	}
+
+	_, _ = b.WriteRune(')')
+}
+
+// EncodeSExpr returns the S-expression of the given token tree; a compact
+// form that DecodeSExpr turns back into the same tree. Nil children are
+// omitted.
+//
+// Format:
+//
+//	(<type> "<data>" <line>:<column>:<offset> <child> ...)
+//
+// Where the data and the position are omitted if they are empty or not valid,
+// respectively. The type is written with KindString, and quoted if it is
+// empty, starts with a digit, or contains white space, parentheses, quotes or
+// non-ASCII characters.
+//
+// Parameters:
+//   - tk: The root of the tree.
+//
+// Returns:
+//   - string: The S-expression, or "()" if the token is nil.
+func EncodeSExpr[K Kind](tk *TokenOf[K]) string {
+	if tk == nil {
+		return "()"
+	}
+
+	var builder strings.Builder
+
+	writeSExpr(&builder, tk)
+
+	str := builder.String()
+	return str
+}
+
+// MaxSExprDepth is the maximum nesting depth of the tokens of an
+// S-expression, so that decoding hostile input cannot exhaust the stack.
+const MaxSExprDepth int = 10000
+
+// sexprDecoder decodes an S-expression.
+type sexprDecoder struct {
+	// src is the S-expression.
+	src string
+
+	// pos is the byte offset of the next character to read.
+	pos int
+
+	// depth is the number of tokens being decoded.
+	depth int
+}
+
+// skipSpaces skips the white space at the current offset.
+func (d *sexprDecoder) skipSpaces() {
+	for d.pos < len(d.src) && strings.IndexByte(" \t\r\n", d.src[d.pos]) >= 0 {
+		d.pos++
+	}
+}
+
+// errorAt returns an error at the current offset.
+//
+// Parameters:
+//   - reason: The reason of the error.
+//
+// Returns:
+//   - error: The error. Never returns nil.
+func (d sexprDecoder) errorAt(reason string) error {
+	err := common.NewErrBadParam("src", reason+" at offset "+strconv.Itoa(d.pos))
+	return err
+}
+
+// atom reads an unquoted atom at the current offset.
+//
+// Returns:
+//   - string: The atom, or an empty string if there is none.
+func (d *sexprDecoder) atom() string {
+	start := d.pos
+
+	for d.pos < len(d.src) && isAtomByte(d.src[d.pos]) {
+		d.pos++
+	}
+
+	return d.src[start:d.pos]
+}
+
+// quoted reads a quoted string at the current offset.
+//
+// Returns:
+//   - string: The unquoted string.
+//   - error: An error if the string is not valid.
+func (d *sexprDecoder) quoted() (string, error) {
+	start := d.pos
+
+	d.pos++
+
+	for d.pos < len(d.src) && d.src[d.pos] != '"' {
+		if d.src[d.pos] == '\\' {
+			d.pos++
+		}
+
+		d.pos++
+	}
+
+	if d.pos >= len(d.src) {
+		d.pos = start
+		err := d.errorAt("unterminated string")
+		return "", err
+	}
+
+	d.pos++
+
+	str, err := strconv.Unquote(d.src[start:d.pos])
+	if err != nil {
+		d.pos = start
+		err := d.errorAt("invalid string")
+		return "", err
+	}
+
+	return str, nil
+}
+
+// position parses a "<line>:<column>:<offset>" atom.
+//
+// Parameters:
+//   - atom: The atom.
+//
+// Returns:
+//   - Position: The position.
+//   - bool: True if the atom is a valid position, false otherwise.
+func position(atom string) (Position, bool) {
+	parts := strings.Split(atom, ":")
+	if len(parts) != 3 {
+		return Position{}, false
+	}
+
+	var nums [3]int
+
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Position{}, false
+		}
+
+		nums[i] = n
+	}
+
+	pos := Position{
+		Line:   nums[0],
+		Column: nums[1],
+		Offset: nums[2],
+	}
+
+	return pos, pos.IsValid()
+}
+
+// token decodes the token at the current offset.
+//
+// Returns:
+//   - *Token: The token.
+//   - error: An error if the token is not valid.
+func (d *sexprDecoder) token() (*Token, error) {
+	d.skipSpaces()
+
+	if d.pos >= len(d.src) || d.src[d.pos] != '(' {
+		err := d.errorAt("expected '('")
+		return nil, err
+	}
+
+	if d.depth == MaxSExprDepth {
+		err := d.errorAt("too deeply nested")
+		return nil, err
+	}
+
+	d.depth++
+	defer func() { d.depth-- }()
+
+	d.pos++
+	d.skipSpaces()
+
+	tk := new(Token)
+
+	if d.pos < len(d.src) && d.src[d.pos] == '"' {
+		type_, err := d.quoted()
+		if err != nil {
+			return nil, err
+		}
+
+		tk.Type = type_
+	} else {
+		tk.Type = d.atom()
+
+		if tk.Type == "" {
+			err := d.errorAt("expected a type")
+			return nil, err
+		}
+	}
+
+	d.skipSpaces()
+
+	if d.pos < len(d.src) && d.src[d.pos] == '"' {
+		data, err := d.quoted()
+		if err != nil {
+			return nil, err
+		}
+
+		tk.Data = data
+		d.skipSpaces()
+	}
+
+	if d.pos < len(d.src) && '0' <= d.src[d.pos] && d.src[d.pos] <= '9' {
+		start := d.pos
+
+		pos, ok := position(d.atom())
+		if !ok {
+			d.pos = start
+			err := d.errorAt("invalid position")
+			return nil, err
+		}
+
+		tk.Pos = pos
+	}
+
+	for {
+		d.skipSpaces()
+
+		if d.pos >= len(d.src) {
+			err := d.errorAt("expected ')'")
+			return nil, err
+		}
+
+		if d.src[d.pos] == ')' {
+			d.pos++
+			break
+		}
+
+		child, err := d.token()
+		if err != nil {
+			return nil, err
+		}
+
+		tk.Children = append(tk.Children, child)
+	}
+
+	return tk, nil
+}
+
+// DecodeSExpr decodes the token tree of the given S-expression. See
+// EncodeSExpr.
+//
+// Parameters:
+//   - src: The S-expression.
+//
+// Returns:
+//   - *Token: The root of the tree, or nil if the S-expression is not valid.
+//   - error: An error if the S-expression is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If the S-expression is not valid, or if its tokens
+//     are nested deeper than MaxSExprDepth.
+func DecodeSExpr(src string) (*Token, error) {
+	d := &sexprDecoder{
+		src: src,
+	}
+
+	tk, err := d.token()
+	if err != nil {
+		return nil, err
+	}
+
+	d.skipSpaces()
+
+	if d.pos < len(d.src) {
+		err := d.errorAt("expected the end of the S-expression")
+		return nil, err
+	}
+
+	return tk, nil
+}
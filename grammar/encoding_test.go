@@ -0,0 +1,199 @@
+package grammar
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// encodingTree returns a tree with positions, data that needs escaping and
+// types that need quoting in S-expressions.
+func encodingTree() *Token {
+	return &Token{
+		Type: "Source",
+		Pos:  Position{Offset: 0, Line: 1, Column: 1},
+		Children: []*Token{
+			{
+				Type: "Stmt",
+				Pos:  Position{Offset: 0, Line: 1, Column: 1},
+				Children: []*Token{
+					{Type: "id", Data: "x", Pos: Position{Offset: 0, Line: 1, Column: 1}},
+					{Type: "str", Data: "say \"hi\"\n\tnow ()", Pos: Position{Offset: 4, Line: 1, Column: 5}},
+				},
+			},
+			{Type: "a b", Data: "é"},
+			{Type: "9lives"},
+			{Type: ""},
+			{Type: "op(", Data: "+", Pos: Position{Offset: 20, Line: 2, Column: 3}},
+		},
+	}
+}
+
+func TestJSON(t *testing.T) {
+	tk := &Token{
+		Type: "Stmt",
+		Pos:  Position{Offset: 4, Line: 2, Column: 1},
+		Children: []*Token{
+			{Type: "id", Data: "x", Pos: Position{Offset: 4, Line: 2, Column: 1}},
+			{Type: "semi"},
+		},
+	}
+
+	data, err := json.Marshal(tk)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned an error: %v", err)
+	}
+
+	want := `{"type":"Stmt","pos":{"offset":4,"line":2,"column":1},"children":[{"type":"id","data":"x","pos":{"offset":4,"line":2,"column":1}},{"type":"semi"}]}`
+	if string(data) != want {
+		t.Errorf("json.Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	want := encodingTree()
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() returned an error: %v", err)
+	}
+
+	// Decoding resets the fields the JSON omits.
+	got := &Token{
+		Data:     "stale",
+		Pos:      Position{Offset: 1, Line: 1, Column: 2},
+		Children: []*Token{NewToken("stale", "")},
+	}
+
+	err = json.Unmarshal(data, got)
+	if err != nil {
+		t.Fatalf("json.Unmarshal() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json.Unmarshal(json.Marshal()) = %s, want %s", EncodeSExpr(got), EncodeSExpr(want))
+	}
+
+	var pos *Position
+
+	err = pos.UnmarshalJSON([]byte(`{}`))
+	if !errors.Is(err, common.ErrNilReceiver) {
+		t.Errorf("UnmarshalJSON() of a nil position returned %v, want %v", err, common.ErrNilReceiver)
+	}
+}
+
+func TestEncodeSExpr(t *testing.T) {
+	tests := []struct {
+		name string
+		tk   *Token
+		want string
+	}{
+		{
+			name: "nil",
+			tk:   nil,
+			want: "()",
+		},
+		{
+			name: "leaf",
+			tk:   NewToken("id", "x"),
+			want: `(id "x")`,
+		},
+		{
+			name: "tree",
+			tk:   encodingTree(),
+			want: `(Source 1:1:0 (Stmt 1:1:0 (id "x" 1:1:0) (str "say \"hi\"\n\tnow ()" 1:5:4)) ("a b" "é") ("9lives") ("") ("op(" "+" 2:3:20))`,
+		},
+		{
+			name: "nil children",
+			tk:   &Token{Type: "List", Children: []*Token{nil, NewToken("a", ""), nil}},
+			want: `(List (a))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := EncodeSExpr(test.tk); got != test.want {
+				t.Errorf("EncodeSExpr() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSExprRoundTrip(t *testing.T) {
+	want := encodingTree()
+
+	got, err := DecodeSExpr(EncodeSExpr(want))
+	if err != nil {
+		t.Fatalf("DecodeSExpr() returned an error: %v", err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeSExpr(EncodeSExpr()) = %s, want %s", EncodeSExpr(got), EncodeSExpr(want))
+	}
+
+	got, err = DecodeSExpr(" \n( Stmt\t( id \"x\" ) ( semi ) )\n")
+	if err != nil {
+		t.Fatalf("DecodeSExpr() returned an error: %v", err)
+	}
+
+	if str := EncodeSExpr(got); str != `(Stmt (id "x") (semi))` {
+		t.Errorf("DecodeSExpr() with spaces = %s, want (Stmt (id \"x\") (semi))", str)
+	}
+}
+
+func TestDecodeSExprErrors(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"", "expected '(' at offset 0"},
+		{"id", "expected '(' at offset 0"},
+		{"()", "expected a type at offset 1"},
+		{"(id", "expected ')' at offset 3"},
+		{`(id "x)`, "unterminated string at offset 4"},
+		{`(id "\q")`, "invalid string at offset 4"},
+		{"(id 1:2)", "invalid position at offset 4"},
+		{"(id 0:2:3)", "invalid position at offset 4"},
+		{"(id) (id)", "expected the end of the S-expression at offset 5"},
+		{strings.Repeat("(a ", MaxSExprDepth+1), "too deeply nested at offset 30000"},
+	}
+
+	for _, test := range tests {
+		tk, err := DecodeSExpr(test.src)
+		if tk != nil {
+			t.Errorf("DecodeSExpr(%q) = %s, want nil", test.src, EncodeSExpr(tk))
+		}
+
+		var bad *common.ErrBadParam
+		if !errors.As(err, &bad) || bad.Reason != test.want {
+			t.Errorf("DecodeSExpr(%q) returned %v, want %q", test.src, err, test.want)
+		}
+	}
+}
+
+func TestDecodeSExprDepth(t *testing.T) {
+	src := strings.Repeat("(a ", MaxSExprDepth) + strings.Repeat(")", MaxSExprDepth)
+
+	tk, err := DecodeSExpr(src)
+	if err != nil {
+		t.Fatalf("DecodeSExpr() returned an error: %v", err)
+	}
+
+	depth := 0
+
+	for ; tk != nil; depth++ {
+		if len(tk.Children) == 0 {
+			tk = nil
+		} else {
+			tk = tk.Children[0]
+		}
+	}
+
+	if depth != MaxSExprDepth {
+		t.Errorf("DecodeSExpr() returned a tree of depth %d, want %d", depth, MaxSExprDepth)
+	}
+}
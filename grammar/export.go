@@ -0,0 +1,159 @@
+package grammar
+
+import (
+	"strconv"
+	"strings"
+)
+
+// EscapeDOT escapes the given text for a quoted DOT string. Newlines become
+// left-justified line breaks.
+//
+// Parameters:
+//   - text: The text to escape.
+//
+// Returns:
+//   - string: The escaped text, without the quotes.
+func EscapeDOT(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\l`)
+
+	str := replacer.Replace(text)
+	return str
+}
+
+// EscapeMermaid escapes the given text for a quoted Mermaid label. Newlines
+// become line breaks.
+//
+// Parameters:
+//   - text: The text to escape.
+//
+// Returns:
+//   - string: The escaped text, without the quotes.
+func EscapeMermaid(text string) string {
+	replacer := strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "|", "#124;", "\n", "<br/>")
+
+	str := replacer.Replace(text)
+	return str
+}
+
+// recTreeToGraph writes the nodes and the edges of a tree in a graph
+// language.
+//
+// Parameters:
+//   - b: The strings.Builder to write to.
+//   - next: The identifier of the next node. It is incremented for each node.
+//   - node: The root of the tree.
+//   - write_node: The function that writes a node with the given identifier.
+//   - write_edge: The function that writes an edge between two identifiers.
+//
+// Returns:
+//   - int: The identifier of the root.
+func recTreeToGraph[T interface {
+	Walkable[T]
+	TreeNode
+}](b *strings.Builder, next *int, node T, write_node func(b *strings.Builder, id int, label string), write_edge func(b *strings.Builder, from, to int)) int {
+	id := *next
+	*next++
+
+	write_node(b, id, node.String())
+
+	for _, child := range node.GetChildren() {
+		child_id := recTreeToGraph(b, next, child, write_node, write_edge)
+		write_edge(b, id, child_id)
+	}
+
+	return id
+}
+
+// TreeToDOT returns the Graphviz DOT graph of the given tree; one box per
+// node, labeled with its string representation, and one edge from each node
+// to each of its children, in order.
+//
+// Format:
+//
+//	digraph tree {
+//		node [shape=box];
+//		n0 [label="..."];
+//		n1 [label="..."];
+//		n0 -> n1;
+//		...
+//	}
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - string: The DOT graph.
+func TreeToDOT[T interface {
+	Walkable[T]
+	TreeNode
+}](root T) string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("digraph tree {\n")
+	_, _ = builder.WriteString("\tordering=out;\n")
+	_, _ = builder.WriteString("\tnode [shape=box];\n")
+
+	var next int
+
+	recTreeToGraph(&builder, &next, root, func(b *strings.Builder, id int, label string) {
+		_, _ = b.WriteString("\tn")
+		_, _ = b.WriteString(strconv.Itoa(id))
+		_, _ = b.WriteString(" [label=\"")
+		_, _ = b.WriteString(EscapeDOT(label))
+		_, _ = b.WriteString("\"];\n")
+	}, func(b *strings.Builder, from, to int) {
+		_, _ = b.WriteString("\tn")
+		_, _ = b.WriteString(strconv.Itoa(from))
+		_, _ = b.WriteString(" -> n")
+		_, _ = b.WriteString(strconv.Itoa(to))
+		_, _ = b.WriteString(";\n")
+	})
+
+	_, _ = builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// TreeToMermaid returns the Mermaid flowchart of the given tree; one box per
+// node, labeled with its string representation, and one link from each node
+// to each of its children, in order.
+//
+// Format:
+//
+//	flowchart TD
+//		n0["..."]
+//		n1["..."]
+//		n0 --> n1
+//		...
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - string: The Mermaid flowchart.
+func TreeToMermaid[T interface {
+	Walkable[T]
+	TreeNode
+}](root T) string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("flowchart TD\n")
+
+	var next int
+
+	recTreeToGraph(&builder, &next, root, func(b *strings.Builder, id int, label string) {
+		_, _ = b.WriteString("\tn")
+		_, _ = b.WriteString(strconv.Itoa(id))
+		_, _ = b.WriteString("[\"")
+		_, _ = b.WriteString(EscapeMermaid(label))
+		_, _ = b.WriteString("\"]\n")
+	}, func(b *strings.Builder, from, to int) {
+		_, _ = b.WriteString("\tn")
+		_, _ = b.WriteString(strconv.Itoa(from))
+		_, _ = b.WriteString(" --> n")
+		_, _ = b.WriteString(strconv.Itoa(to))
+		_, _ = b.WriteRune('\n')
+	})
+
+	return builder.String()
+}
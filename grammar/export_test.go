@@ -0,0 +1,86 @@
+package grammar
+
+import (
+	"testing"
+)
+
+// exportTree returns a tree whose labels need escaping.
+func exportTree() *Token {
+	return &Token{
+		Type: "Stmt",
+		Children: []*Token{
+			NewToken("str", `a "b" \ c`),
+			{Type: "Pipe", Children: []*Token{NewToken("op", "a|b<c>")}},
+		},
+	}
+}
+
+func TestEscapeDOT(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{`say "hi"`, `say \"hi\"`},
+		{`back\slash`, `back\\slash`},
+		{"two\nlines", `two\llines`},
+	}
+
+	for _, test := range tests {
+		if got := EscapeDOT(test.text); got != test.want {
+			t.Errorf("EscapeDOT(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestEscapeMermaid(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{`say "hi"`, "say #quot;hi#quot;"},
+		{"a|b", "a#124;b"},
+		{"<tag>", "#lt;tag#gt;"},
+		{"two\nlines", "two<br/>lines"},
+	}
+
+	for _, test := range tests {
+		if got := EscapeMermaid(test.text); got != test.want {
+			t.Errorf("EscapeMermaid(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestTreeToDOT(t *testing.T) {
+	want := "digraph tree {\n" +
+		"\tordering=out;\n" +
+		"\tnode [shape=box];\n" +
+		"\tn0 [label=\"Token[Stmt]\"];\n" +
+		"\tn1 [label=\"Token[str (\\\"a \\\\\\\"b\\\\\\\" \\\\\\\\ c\\\")]\"];\n" +
+		"\tn0 -> n1;\n" +
+		"\tn2 [label=\"Token[Pipe]\"];\n" +
+		"\tn3 [label=\"Token[op (\\\"a|b<c>\\\")]\"];\n" +
+		"\tn2 -> n3;\n" +
+		"\tn0 -> n2;\n" +
+		"}\n"
+
+	if got := TreeToDOT(exportTree()); got != want {
+		t.Errorf("TreeToDOT() = %q, want %q", got, want)
+	}
+}
+
+func TestTreeToMermaid(t *testing.T) {
+	want := "flowchart TD\n" +
+		"\tn0[\"Token[Stmt]\"]\n" +
+		"\tn1[\"Token[str (#quot;a \\#quot;b\\#quot; \\\\ c#quot;)]\"]\n" +
+		"\tn0 --> n1\n" +
+		"\tn2[\"Token[Pipe]\"]\n" +
+		"\tn3[\"Token[op (#quot;a#124;b#lt;c#gt;#quot;)]\"]\n" +
+		"\tn2 --> n3\n" +
+		"\tn0 --> n2\n"
+
+	if got := TreeToMermaid(exportTree()); got != want {
+		t.Errorf("TreeToMermaid() = %q, want %q", got, want)
+	}
+}
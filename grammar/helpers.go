@@ -10,17 +10,18 @@ package grammar
 //   - error: An error if the token does not have the given type.
 //
 // Errors:
-//   - *ErrWant: If the token is nil or does not have the given type.
-func CheckToken(tk *Token, want string) error {
+//   - *ErrWant: If the token is nil or does not have the given type. The
+//     types are written with KindString.
+func CheckToken[K Kind](tk *TokenOf[K], want K) error {
 	if tk == nil {
-		err := NewErrWant(true, "token type", want, nil)
+		err := NewErrWant(true, "token type", KindString(want), nil)
 		return err
 	}
 
-	type_ := tk.Type
+	if tk.Type != want {
+		type_ := KindString(tk.Type)
 
-	if type_ != want {
-		err := NewErrWant(true, "token type", want, &type_)
+		err := NewErrWant(true, "token type", KindString(want), &type_)
 		return err
 	}
 
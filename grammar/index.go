@@ -0,0 +1,362 @@
+package grammar
+
+// indexEntry is what an Index knows about a node.
+type indexEntry[K Kind] struct {
+	// parent is the parent of the node, or nil for the root.
+	parent *TokenOf[K]
+
+	// index is the index of the node among the children of its parent.
+	index int
+
+	// depth is the depth of the node; 0 for the root.
+	depth int
+
+	// start is the byte offset of the start of the node in the source.
+	start int
+
+	// end is the byte offset of the end of the node in the source, or -1 if
+	// the node covers no position.
+	end int
+}
+
+// IndexOf is a read-only view over a token tree that links each node to its
+// parent; for navigating upwards and sideways, and for finding nodes by
+// source offset. See NewIndex.
+//
+// The index is a snapshot: nodes added to the tree afterwards are not in it,
+// and changes to the tree require a new index.
+type IndexOf[K Kind] struct {
+	// root is the root of the tree.
+	root *TokenOf[K]
+
+	// entries is the entry of every node of the tree.
+	entries map[*TokenOf[K]]*indexEntry[K]
+}
+
+// Index is the index of a token tree of the string-based API.
+type Index = IndexOf[string]
+
+// add adds the given subtree to the index, and computes its span.
+//
+// Parameters:
+//   - tk: The root of the subtree. (Assumed to not be nil)
+//   - parent: The parent of the subtree, or nil for the root.
+//   - index: The index of the subtree among the children of its parent.
+//   - depth: The depth of the subtree.
+//
+// Returns:
+//   - *indexEntry: The entry of the subtree, or nil if it is already in the
+//     index.
+func (x *IndexOf[K]) add(tk, parent *TokenOf[K], index, depth int) *indexEntry[K] {
+	_, ok := x.entries[tk]
+	if ok {
+		return nil
+	}
+
+	entry := &indexEntry[K]{
+		parent: parent,
+		index:  index,
+		depth:  depth,
+		end:    -1,
+	}
+
+	x.entries[tk] = entry
+
+	if len(tk.Children) == 0 && tk.Pos.IsValid() {
+		entry.start = tk.Pos.Offset
+		entry.end = tk.Pos.Offset + len(tk.Data)
+	}
+
+	for i, child := range tk.Children {
+		if child == nil {
+			continue
+		}
+
+		child_entry := x.add(child, tk, i, depth+1)
+		if child_entry == nil || child_entry.end < 0 {
+			continue
+		}
+
+		if entry.end < 0 {
+			entry.start = child_entry.start
+			entry.end = child_entry.end
+		} else {
+			entry.start = min(entry.start, child_entry.start)
+			entry.end = max(entry.end, child_entry.end)
+		}
+	}
+
+	return entry
+}
+
+// NewIndex indexes the given token tree. A node that appears more than once
+// in the tree is indexed at its first occurrence, in pre-order.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - *IndexOf[K]: The index. Never returns nil.
+func NewIndex[K Kind](root *TokenOf[K]) *IndexOf[K] {
+	x := &IndexOf[K]{
+		root:    root,
+		entries: make(map[*TokenOf[K]]*indexEntry[K]),
+	}
+
+	if root != nil {
+		_ = x.add(root, nil, 0, 0)
+	}
+
+	return x
+}
+
+// Root returns the root of the indexed tree.
+//
+// Returns:
+//   - *Token: The root, or nil if the tree is empty.
+func (x IndexOf[K]) Root() *TokenOf[K] {
+	return x.root
+}
+
+// Contains checks whether the given node is in the indexed tree.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - bool: True if the node is in the tree, false otherwise.
+func (x IndexOf[K]) Contains(tk *TokenOf[K]) bool {
+	_, ok := x.entries[tk]
+	return ok
+}
+
+// Parent returns the parent of the given node.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - *Token: The parent, or nil if the node is the root or is not in the
+//     tree.
+func (x IndexOf[K]) Parent(tk *TokenOf[K]) *TokenOf[K] {
+	entry, ok := x.entries[tk]
+	if !ok {
+		return nil
+	}
+
+	return entry.parent
+}
+
+// ChildIndex returns the index of the given node among the children of its
+// parent.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - int: The index, or -1 if the node is the root or is not in the tree.
+func (x IndexOf[K]) ChildIndex(tk *TokenOf[K]) int {
+	entry, ok := x.entries[tk]
+	if !ok || entry.parent == nil {
+		return -1
+	}
+
+	return entry.index
+}
+
+// NextSibling returns the sibling that follows the given node, skipping nil
+// children.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - *Token: The next sibling, or nil if there is none.
+func (x IndexOf[K]) NextSibling(tk *TokenOf[K]) *TokenOf[K] {
+	entry, ok := x.entries[tk]
+	if !ok || entry.parent == nil {
+		return nil
+	}
+
+	siblings := entry.parent.Children
+
+	for i := entry.index + 1; i < len(siblings); i++ {
+		if siblings[i] != nil {
+			return siblings[i]
+		}
+	}
+
+	return nil
+}
+
+// PrevSibling returns the sibling that precedes the given node, skipping nil
+// children.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - *Token: The previous sibling, or nil if there is none.
+func (x IndexOf[K]) PrevSibling(tk *TokenOf[K]) *TokenOf[K] {
+	entry, ok := x.entries[tk]
+	if !ok || entry.parent == nil {
+		return nil
+	}
+
+	siblings := entry.parent.Children
+
+	for i := entry.index - 1; i >= 0; i-- {
+		if siblings[i] != nil {
+			return siblings[i]
+		}
+	}
+
+	return nil
+}
+
+// Depth returns the depth of the given node; that is, its number of
+// ancestors.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - int: The depth, or -1 if the node is not in the tree.
+func (x IndexOf[K]) Depth(tk *TokenOf[K]) int {
+	entry, ok := x.entries[tk]
+	if !ok {
+		return -1
+	}
+
+	return entry.depth
+}
+
+// Ancestors returns the ancestors of the given node, from its parent up to
+// the root.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - []*TokenOf[K]: The ancestors, or nil if the node is the root or is not in the
+//     tree.
+func (x IndexOf[K]) Ancestors(tk *TokenOf[K]) []*TokenOf[K] {
+	entry, ok := x.entries[tk]
+	if !ok || entry.depth == 0 {
+		return nil
+	}
+
+	ancestors := make([]*TokenOf[K], 0, entry.depth)
+
+	for entry.parent != nil {
+		ancestors = append(ancestors, entry.parent)
+		entry = x.entries[entry.parent]
+	}
+
+	return ancestors
+}
+
+// Path returns the path from the root to the given node. See Node.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - Path: The path.
+//   - bool: True if the node is in the tree, false otherwise.
+func (x IndexOf[K]) Path(tk *TokenOf[K]) (Path, bool) {
+	entry, ok := x.entries[tk]
+	if !ok {
+		return nil, false
+	}
+
+	path := make(Path, entry.depth)
+
+	for entry.parent != nil {
+		path[entry.depth-1] = entry.index
+		entry = x.entries[entry.parent]
+	}
+
+	return path, true
+}
+
+// Node returns the node at the given path from the root. See Path.
+//
+// Parameters:
+//   - path: The path.
+//
+// Returns:
+//   - *Token: The node, or nil if there is none.
+func (x IndexOf[K]) Node(path Path) *TokenOf[K] {
+	tk := x.root
+
+	for _, idx := range path {
+		if tk == nil || idx < 0 || idx >= len(tk.Children) {
+			return nil
+		}
+
+		tk = tk.Children[idx]
+	}
+
+	return tk
+}
+
+// Span returns the bytes of the source that the given node covers; that is,
+// from the start of its first terminal to the end of its last one. Only the
+// terminals with a valid position are taken into account.
+//
+// Parameters:
+//   - tk: The node.
+//
+// Returns:
+//   - int: The byte offset of the start of the node.
+//   - int: The byte offset of the end of the node; exclusive.
+//   - bool: True if the node is in the tree and covers a position, false
+//     otherwise.
+func (x IndexOf[K]) Span(tk *TokenOf[K]) (int, int, bool) {
+	entry, ok := x.entries[tk]
+	if !ok || entry.end < 0 {
+		return 0, 0, false
+	}
+
+	return entry.start, entry.end, true
+}
+
+// At returns the smallest node that covers the given byte offset of the
+// source. See Span.
+//
+// Parameters:
+//   - offset: The byte offset.
+//
+// Returns:
+//   - *Token: The deepest node whose span contains the offset, or nil if no
+//     node covers it.
+func (x IndexOf[K]) At(offset int) *TokenOf[K] {
+	covers := func(tk *TokenOf[K]) bool {
+		entry, ok := x.entries[tk]
+		return ok && entry.end >= 0 && entry.start <= offset && offset < entry.end
+	}
+
+	if x.root == nil || !covers(x.root) {
+		return nil
+	}
+
+	tk := x.root
+
+	for {
+		var next *TokenOf[K]
+
+		for _, child := range tk.Children {
+			if child != nil && x.entries[child].parent == tk && covers(child) {
+				next = child
+				break
+			}
+		}
+
+		if next == nil {
+			return tk
+		}
+
+		tk = next
+	}
+}
@@ -0,0 +1,230 @@
+package grammar
+
+import (
+	"slices"
+	"testing"
+)
+
+// leafAt returns a token of the given type and data at the given offset of
+// the first line.
+func leafAt(type_, data string, offset int) *Token {
+	tk := &Token{
+		Type: type_,
+		Data: data,
+		Pos:  Position{Offset: offset, Line: 1, Column: offset + 1},
+	}
+
+	return tk
+}
+
+// indexTree returns the tree of "x = 12; y", with a nil child and a node
+// without position under the root.
+func indexTree() *Token {
+	return &Token{
+		Type: "Source",
+		Children: []*Token{
+			{
+				Type: "Stmt",
+				Children: []*Token{
+					leafAt("id", "x", 0),
+					leafAt("eq", "=", 2),
+					{Type: "Expr", Children: []*Token{leafAt("num", "12", 4)}},
+				},
+			},
+			nil,
+			leafAt("semi", ";", 6),
+			{Type: "Empty"},
+			leafAt("id", "y", 8),
+		},
+	}
+}
+
+// label returns the data of the given token, or its type if it has no data.
+func label(tk *Token) string {
+	if tk == nil {
+		return "<nil>"
+	} else if tk.Data != "" {
+		return tk.Data
+	}
+
+	return tk.Type
+}
+
+func TestIndexNavigation(t *testing.T) {
+	root := indexTree()
+	x := NewIndex(root)
+
+	stmt := root.Children[0]
+	num := stmt.Children[2].Children[0]
+	semi := root.Children[2]
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"Root", x.Root(), root},
+		{"Parent of num", label(x.Parent(num)), "Expr"},
+		{"Parent of root", x.Parent(root), (*Token)(nil)},
+		{"ChildIndex of semi", x.ChildIndex(semi), 2},
+		{"ChildIndex of root", x.ChildIndex(root), -1},
+		{"NextSibling of stmt", label(x.NextSibling(stmt)), ";"},
+		{"PrevSibling of semi", label(x.PrevSibling(semi)), "Stmt"},
+		{"NextSibling of the last child", label(x.NextSibling(root.Children[4])), "<nil>"},
+		{"PrevSibling of the first child", label(x.PrevSibling(stmt)), "<nil>"},
+		{"NextSibling of root", label(x.NextSibling(root)), "<nil>"},
+		{"Depth of root", x.Depth(root), 0},
+		{"Depth of num", x.Depth(num), 3},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("%s = %v, want %v", test.name, test.got, test.want)
+		}
+	}
+
+	var ancestors []string
+	for _, tk := range x.Ancestors(num) {
+		ancestors = append(ancestors, label(tk))
+	}
+
+	if want := []string{"Expr", "Stmt", "Source"}; !slices.Equal(ancestors, want) {
+		t.Errorf("Ancestors() = %q, want %q", ancestors, want)
+	}
+
+	if got := x.Ancestors(root); got != nil {
+		t.Errorf("Ancestors() of the root = %v, want nil", got)
+	}
+}
+
+func TestIndexPath(t *testing.T) {
+	root := indexTree()
+	x := NewIndex(root)
+
+	var check func(tk *Token)
+
+	check = func(tk *Token) {
+		if !x.Contains(tk) {
+			t.Errorf("Contains(%s) = false, want true", tk)
+		}
+
+		path, ok := x.Path(tk)
+		if !ok {
+			t.Errorf("Path(%s) returned false", tk)
+		} else if got := x.Node(path); got != tk {
+			t.Errorf("Node(Path(%s)) = %v", tk, got)
+		}
+
+		for _, child := range tk.Children {
+			if child != nil {
+				check(child)
+			}
+		}
+	}
+
+	check(root)
+
+	path, _ := x.Path(root.Children[0].Children[2].Children[0])
+	if path.String() != "/0/2/0" {
+		t.Errorf("Path() = %s, want /0/2/0", path)
+	}
+
+	for _, path := range []Path{{1}, {5}, {-1}, {0, 0, 0}} {
+		if got := x.Node(path); got != nil {
+			t.Errorf("Node(%s) = %v, want nil", path, got)
+		}
+	}
+}
+
+func TestIndexSpanAndAt(t *testing.T) {
+	root := indexTree()
+	x := NewIndex(root)
+
+	spans := []struct {
+		tk    *Token
+		start int
+		end   int
+		ok    bool
+	}{
+		{root, 0, 9, true},
+		{root.Children[0], 0, 6, true},
+		{root.Children[0].Children[2], 4, 6, true},
+		{root.Children[2], 6, 7, true},
+		{root.Children[3], 0, 0, false},
+	}
+
+	for _, test := range spans {
+		start, end, ok := x.Span(test.tk)
+		if start != test.start || end != test.end || ok != test.ok {
+			t.Errorf("Span(%s) = %d, %d, %t, want %d, %d, %t", test.tk, start, end, ok, test.start, test.end, test.ok)
+		}
+	}
+
+	at := []struct {
+		offset int
+		want   string
+	}{
+		{0, "x"},
+		{1, "Stmt"},
+		{2, "="},
+		{5, "12"},
+		{6, ";"},
+		{7, "Source"},
+		{8, "y"},
+		{9, "<nil>"},
+		{-1, "<nil>"},
+	}
+
+	for _, test := range at {
+		if got := label(x.At(test.offset)); got != test.want {
+			t.Errorf("At(%d) = %s, want %s", test.offset, got, test.want)
+		}
+	}
+}
+
+func TestIndexOutside(t *testing.T) {
+	x := NewIndex(indexTree())
+	other := leafAt("id", "z", 0)
+
+	if x.Contains(other) || x.Parent(other) != nil || x.ChildIndex(other) != -1 || x.Depth(other) != -1 {
+		t.Errorf("a node outside the tree is indexed")
+	}
+
+	if _, ok := x.Path(other); ok {
+		t.Errorf("Path() of a node outside the tree returned true")
+	}
+
+	if _, _, ok := x.Span(other); ok {
+		t.Errorf("Span() of a node outside the tree returned true")
+	}
+
+	empty := NewIndex[string](nil)
+
+	if empty.Root() != nil || empty.At(0) != nil || empty.Node(Path{}) != nil {
+		t.Errorf("the index of an empty tree has nodes")
+	}
+
+	// A shared node is indexed at its first occurrence.
+	shared := leafAt("id", "s", 3)
+	root := &Token{
+		Type: "Source",
+		Children: []*Token{
+			{Type: "A", Children: []*Token{shared}},
+			{Type: "B", Children: []*Token{shared}},
+		},
+	}
+
+	x = NewIndex(root)
+
+	if got := label(x.Parent(shared)); got != "A" {
+		t.Errorf("Parent() of a shared node = %s, want A", got)
+	}
+
+	if got := label(x.At(3)); got != "s" {
+		t.Errorf("At() = %s, want s", got)
+	}
+
+	if _, _, ok := x.Span(root.Children[1]); ok {
+		t.Errorf("Span() of B covers the shared node")
+	}
+}
@@ -0,0 +1,77 @@
+package grammar
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// Kind is the constraint of the kinds of the tokens; that is, of the types of
+// the tokens and of the symbols of the rules.
+//
+// Strings are the kinds of the string-based API, such as Token and Rule. Any
+// other comparable type may be used instead; typically, an int enum with a
+// String method, whose misspelled kinds do not compile and whose lookups are
+// cheaper than the ones of strings.
+type Kind interface {
+	comparable
+}
+
+// KindString returns the name of the given kind.
+//
+// Parameters:
+//   - k: The kind.
+//
+// Returns:
+//   - string: The kind itself, for strings; the result of its String method,
+//     for kinds that implement fmt.Stringer; or its default format otherwise.
+func KindString[K Kind](k K) string {
+	switch k := any(k).(type) {
+	case string:
+		return k
+	case fmt.Stringer:
+		return k.String()
+	}
+
+	str := fmt.Sprint(k)
+	return str
+}
+
+// ParseKind returns the kind of the given name; the inverse of KindString.
+//
+// Parameters:
+//   - name: The name of the kind.
+//
+// Returns:
+//   - K: The kind.
+//   - error: An error if the name cannot be parsed.
+//
+// Errors:
+//   - common.ErrBadParam: If K has no string underlying type and its pointer
+//     does not implement encoding.TextUnmarshaler.
+//   - any other error: If the text unmarshaler rejects the name.
+func ParseKind[K Kind](name string) (K, error) {
+	var k K
+
+	switch ptr := any(&k).(type) {
+	case *string:
+		*ptr = name
+	case encoding.TextUnmarshaler:
+		err := ptr.UnmarshalText([]byte(name))
+		if err != nil {
+			return k, err
+		}
+	default:
+		v := reflect.ValueOf(&k).Elem()
+		if v.Kind() != reflect.String {
+			err := common.NewErrBadParam("name", "cannot be parsed as a kind of type "+v.Type().String())
+			return k, err
+		}
+
+		v.SetString(name)
+	}
+
+	return k, nil
+}
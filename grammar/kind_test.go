@@ -0,0 +1,105 @@
+package grammar
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// color is a typed kind with a String method and a text unmarshaler.
+type color int
+
+const (
+	red color = iota
+	green
+)
+
+// String implements fmt.Stringer.
+func (c color) String() string {
+	switch c {
+	case red:
+		return "red"
+	case green:
+		return "green"
+	default:
+		return "color?"
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (c *color) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "red":
+		*c = red
+	case "green":
+		*c = green
+	default:
+		return errors.New("unknown color " + string(text))
+	}
+
+	return nil
+}
+
+// tag is a kind whose underlying type is string.
+type tag string
+
+func TestKindString(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{KindString("id"), "id"},
+		{KindString(green), "green"},
+		{KindString(tag("x")), "x"},
+		{KindString(42), "42"},
+	}
+
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("KindString() = %q, want %q", test.got, test.want)
+		}
+	}
+}
+
+func TestParseKind(t *testing.T) {
+	if c, err := ParseKind[color]("green"); err != nil || c != green {
+		t.Errorf("ParseKind[color](green) = %v, %v", c, err)
+	}
+
+	if _, err := ParseKind[color]("blue"); err == nil {
+		t.Errorf("ParseKind[color](blue) returned no error")
+	}
+
+	if k, err := ParseKind[tag]("x"); err != nil || k != "x" {
+		t.Errorf("ParseKind[tag](x) = %q, %v", k, err)
+	}
+
+	if _, err := ParseKind[int]("1"); err == nil {
+		t.Errorf("ParseKind[int] returned no error")
+	}
+}
+
+func TestTypedTokenJSON(t *testing.T) {
+	tk := NewToken(red, "")
+	_ = tk.AppendChildren([]*TokenOf[color]{NewToken(green, "g")})
+
+	data, err := json.Marshal(tk)
+	if err != nil {
+		t.Fatalf("Marshal() returned an error: %v", err)
+	}
+
+	var got TokenOf[color]
+
+	err = json.Unmarshal(data, &got)
+	if err != nil {
+		t.Fatalf("Unmarshal(%s) returned an error: %v", data, err)
+	}
+
+	if got.Type != red || len(got.Children) != 1 || got.Children[0].Type != green || got.Children[0].Data != "g" {
+		t.Errorf("Unmarshal(%s) = %s", data, EncodeSExpr(&got))
+	}
+
+	if str := EncodeSExpr(tk); str != `(red (green "g"))` {
+		t.Errorf("EncodeSExpr() = %s", str)
+	}
+}
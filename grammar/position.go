@@ -0,0 +1,76 @@
+package grammar
+
+import "strconv"
+
+// Position is the position of a token in the input data.
+type Position struct {
+	// Offset is the byte offset of the token, starting from 0.
+	Offset int
+
+	// Line is the line of the token, starting from 1.
+	Line int
+
+	// Column is the column of the token, in runes, starting from 1.
+	Column int
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<line>:<column>"
+//
+// If the position is not valid, the string "?" is returned instead.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return "?"
+	}
+
+	str := strconv.Itoa(p.Line) + ":" + strconv.Itoa(p.Column)
+	return str
+}
+
+// IsValid checks whether the position is known. The zero value of Position
+// is not valid.
+//
+// Returns:
+//   - bool: True if the position is valid, false otherwise.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// Advance returns the position that follows the given character.
+//
+// Parameters:
+//   - char: The character at the current position.
+//   - size: The size, in bytes, of the character.
+//
+// Returns:
+//   - Position: The position of the next character.
+func (p Position) Advance(char rune, size int) Position {
+	p.Offset += size
+
+	if char == '\n' {
+		p.Line++
+		p.Column = 1
+	} else {
+		p.Column++
+	}
+
+	return p
+}
+
+// StartPosition returns the position of the first character of the input
+// data.
+//
+// Returns:
+//   - Position: The position of the first character.
+func StartPosition() Position {
+	p := Position{
+		Offset: 0,
+		Line:   1,
+		Column: 1,
+	}
+
+	return p
+}
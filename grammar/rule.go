@@ -0,0 +1,59 @@
+package grammar
+
+import "strings"
+
+// RuleOf is a rule of the grammar in BNF form; that is, a left-hand side
+// symbol followed by a flat sequence of right-hand side symbols, whose symbols
+// are kinds of type K. See Kind.
+type RuleOf[K Kind] struct {
+	// Lhs is the left-hand side of the rule.
+	Lhs K
+
+	// Rhss is the right-hand side symbols of the rule. An empty right-hand side
+	// denotes the empty string.
+	Rhss []K
+}
+
+// Rule is a rule whose symbols are strings; the rule of the string-based API.
+type Rule = RuleOf[string]
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<lhs> = <rhs> <rhs> ... ."
+//
+// Where the symbols are written with KindString.
+func (r RuleOf[K]) String() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(KindString(r.Lhs))
+	_, _ = builder.WriteString(" =")
+
+	for _, rhs := range r.Rhss {
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(KindString(rhs))
+	}
+
+	_, _ = builder.WriteString(" .")
+
+	str := builder.String()
+	return str
+}
+
+// NewRule creates a new rule with the given left-hand side and right-hand side symbols.
+//
+// Parameters:
+//   - lhs: The left-hand side of the rule.
+//   - rhss: The right-hand side symbols of the rule.
+//
+// Returns:
+//   - *RuleOf[K]: The newly created rule. Never returns nil.
+func NewRule[K Kind](lhs K, rhss ...K) *RuleOf[K] {
+	rule := &RuleOf[K]{
+		Lhs:  lhs,
+		Rhss: rhss,
+	}
+
+	return rule
+}
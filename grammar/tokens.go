@@ -8,24 +8,31 @@ import (
 	gslc "github.com/PlayerR9/SlParser/mygo-lib/slices"
 )
 
-// Token is a token in the grammar.
-type Token struct {
+// TokenOf is a token in the grammar, whose type is a kind of type K. See Kind.
+type TokenOf[K Kind] struct {
 	// Type is the type of the token.
-	Type string
+	Type K
 
 	// Data is the data of the token.
 	Data string
 
+	// Pos is the position of the token in the input data. Non-terminals have
+	// the position of their first token; if any.
+	Pos Position
+
 	// Children is the children of the token.
-	Children []*Token
+	Children []*TokenOf[K]
 }
 
-// String implements TreeNode.
-func (tk Token) String() string {
+// Token is a token whose type is a string; the token of the string-based API.
+type Token = TokenOf[string]
+
+// String implements TreeNode. The type is written with KindString.
+func (tk TokenOf[K]) String() string {
 	var builder strings.Builder
 
 	_, _ = builder.WriteString("Token[")
-	_, _ = builder.WriteString(tk.Type)
+	_, _ = builder.WriteString(KindString(tk.Type))
 
 	if tk.Data != "" {
 		_, _ = builder.WriteString(" (")
@@ -46,9 +53,9 @@ func (tk Token) String() string {
 //   - data: The data associated with the token.
 //
 // Returns:
-//   - *Token: A pointer to the newly created Token. Never returns nil.
-func NewToken(type_, data string) *Token {
-	tk := &Token{
+//   - *TokenOf[K]: A pointer to the newly created Token. Never returns nil.
+func NewToken[K Kind](type_ K, data string) *TokenOf[K] {
+	tk := &TokenOf[K]{
 		Type: type_,
 		Data: data,
 	}
@@ -63,7 +70,7 @@ func NewToken(type_, data string) *Token {
 //
 // Returns:
 //   - error: An error if the receiver is nil.
-func (tk *Token) PrependChildren(children []*Token) error {
+func (tk *TokenOf[K]) PrependChildren(children []*TokenOf[K]) error {
 	if tk == nil {
 		return common.ErrNilReceiver
 	}
@@ -85,7 +92,7 @@ func (tk *Token) PrependChildren(children []*Token) error {
 //
 // Returns:
 //   - error: An error if the receiver is nil.
-func (tk *Token) AppendChildren(children []*Token) error {
+func (tk *TokenOf[K]) AppendChildren(children []*TokenOf[K]) error {
 	if tk == nil {
 		return common.ErrNilReceiver
 	}
@@ -100,16 +107,43 @@ func (tk *Token) AppendChildren(children []*Token) error {
 	return nil
 }
 
+// Copy returns a deep copy of the tree rooted at the token; so that the copy
+// shares no token with the original tree.
+//
+// Returns:
+//   - *TokenOf[K]: A deep copy of the token. Never returns nil.
+func (tk TokenOf[K]) Copy() *TokenOf[K] {
+	tk_copy := &TokenOf[K]{
+		Type: tk.Type,
+		Data: tk.Data,
+		Pos:  tk.Pos,
+	}
+
+	if len(tk.Children) == 0 {
+		return tk_copy
+	}
+
+	tk_copy.Children = make([]*TokenOf[K], 0, len(tk.Children))
+
+	for _, child := range tk.Children {
+		if child != nil {
+			tk_copy.Children = append(tk_copy.Children, child.Copy())
+		}
+	}
+
+	return tk_copy
+}
+
 // GetChildren returns a copy of the children of the token.
 //
 // Returns:
-//   - []*Token: A copy of the children of the token.
-func (tk Token) GetChildren() []*Token {
+//   - []*TokenOf[K]: A copy of the children of the token.
+func (tk TokenOf[K]) GetChildren() []*TokenOf[K] {
 	if len(tk.Children) == 0 {
 		return nil
 	}
 
-	children := make([]*Token, len(tk.Children))
+	children := make([]*TokenOf[K], len(tk.Children))
 	copy(children, tk.Children)
 
 	return children
@@ -10,29 +10,96 @@ type TreeNode interface {
 	fmt.Stringer
 }
 
+// colors is the ANSI colors of the nodes of colorized trees, by depth.
+var colors = []string{
+	"\033[36m", // cyan
+	"\033[32m", // green
+	"\033[33m", // yellow
+	"\033[35m", // magenta
+	"\033[34m", // blue
+}
+
+const (
+	// colorReset is the ANSI code that resets the color.
+	colorReset string = "\033[0m"
+
+	// colorDim is the ANSI code of the indentation of colorized trees.
+	colorDim string = "\033[2m"
+)
+
+// TreeFormat is the format of the string representation of a tree. See
+// FormatTree.
+type TreeFormat struct {
+	// Indent is the string that indents each level of the tree. If empty,
+	// three spaces are used.
+	Indent string
+
+	// Color is true if the nodes are colorized, with ANSI escape codes, by
+	// depth; and the indentation is dimmed.
+	Color bool
+}
+
 // recTreeToString writes a string representation of a tree to the given
 // strings.Builder. The string representation is a recursive stringification
-// of the tree, with each node indented under its parent. The indent string
-// is used to indent each level of the tree.
+// of the tree, with each node indented under its parent.
 //
 // Parameters:
 //   - b: The strings.Builder to write the string representation to.
-//   - indent: The string to use to indent each level of the tree.
+//   - format: The format of the tree.
+//   - depth: The depth of the node.
 //   - node: The root of the tree to stringify.
 func recTreeToString[T interface {
-	GetChildren() []T
-
+	Walkable[T]
 	TreeNode
-}](b *strings.Builder, indent string, node T) {
-	_, _ = b.WriteString(indent)
-	_, _ = b.WriteString(node.String())
+}](b *strings.Builder, format TreeFormat, depth int, node T) {
+	indent := strings.Repeat(format.Indent, depth)
+
+	if format.Color && indent != "" {
+		_, _ = b.WriteString(colorDim)
+		_, _ = b.WriteString(indent)
+		_, _ = b.WriteString(colorReset)
+	} else {
+		_, _ = b.WriteString(indent)
+	}
+
+	if format.Color {
+		_, _ = b.WriteString(colors[depth%len(colors)])
+		_, _ = b.WriteString(node.String())
+		_, _ = b.WriteString(colorReset)
+	} else {
+		_, _ = b.WriteString(node.String())
+	}
+
 	_, _ = b.WriteRune('\n')
 
 	children := node.GetChildren()
 
 	for _, child := range children {
-		recTreeToString(b, indent+"   ", child)
+		recTreeToString(b, format, depth+1, child)
+	}
+}
+
+// FormatTree is like TreeToString, but with the given format.
+//
+// Parameters:
+//   - root: The root of the tree to stringify.
+//   - format: The format of the tree.
+//
+// Returns:
+//   - string: A string representation of the tree.
+func FormatTree[T interface {
+	Walkable[T]
+	TreeNode
+}](root T, format TreeFormat) string {
+	if format.Indent == "" {
+		format.Indent = "   "
 	}
+
+	var builder strings.Builder
+
+	recTreeToString(&builder, format, 0, root)
+
+	return builder.String()
 }
 
 // TreeToString takes a tree and returns a string representation of it. The
@@ -46,13 +113,9 @@ func recTreeToString[T interface {
 // Returns:
 //   - string: A string representation of the tree.
 func TreeToString[T interface {
-	GetChildren() []T
-
+	Walkable[T]
 	TreeNode
 }](root T) string {
-	var builder strings.Builder
-
-	recTreeToString(&builder, "", root)
-
-	return builder.String()
+	str := FormatTree(root, TreeFormat{})
+	return str
 }
@@ -0,0 +1,52 @@
+package grammar
+
+import (
+	"testing"
+)
+
+func TestFormatTree(t *testing.T) {
+	root := &Token{
+		Type: "Stmt",
+		Children: []*Token{
+			{Type: "Expr", Children: []*Token{NewToken("id", "x")}},
+			NewToken("semi", ";"),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		format TreeFormat
+		want   string
+	}{
+		{
+			name:   "default",
+			format: TreeFormat{},
+			want:   "Token[Stmt]\n   Token[Expr]\n      Token[id (\"x\")]\n   Token[semi (\";\")]\n",
+		},
+		{
+			name:   "indent",
+			format: TreeFormat{Indent: "| "},
+			want:   "Token[Stmt]\n| Token[Expr]\n| | Token[id (\"x\")]\n| Token[semi (\";\")]\n",
+		},
+		{
+			name:   "color",
+			format: TreeFormat{Indent: "  ", Color: true},
+			want: "\033[36mToken[Stmt]\033[0m\n" +
+				"\033[2m  \033[0m\033[32mToken[Expr]\033[0m\n" +
+				"\033[2m    \033[0m\033[33mToken[id (\"x\")]\033[0m\n" +
+				"\033[2m  \033[0m\033[32mToken[semi (\";\")]\033[0m\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := FormatTree(root, test.format); got != test.want {
+				t.Errorf("FormatTree() = %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	if got, want := TreeToString(root), FormatTree(root, TreeFormat{}); got != want {
+		t.Errorf("TreeToString() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,331 @@
+package grammar
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+var (
+	// SkipChildren is returned by a WalkFn, when entering a node, to not walk
+	// the children of the node. This error can be checked with the ==
+	// operator.
+	//
+	// Format:
+	// 	"skip children"
+	SkipChildren error
+
+	// Stop is returned by a WalkFn to end the walk at once. This error can be
+	// checked with the == operator.
+	//
+	// Format:
+	// 	"stop walk"
+	Stop error
+)
+
+func init() {
+	SkipChildren = errors.New("skip children")
+	Stop = errors.New("stop walk")
+}
+
+// Walkable is the constraint of the nodes of a tree that can be walked; such
+// as *Token or any user node type that returns its children.
+type Walkable[T any] interface {
+	// GetChildren returns the children of the node, in order.
+	//
+	// Returns:
+	//   - []T: The children of the node, or nil if it has none.
+	GetChildren() []T
+}
+
+// Order is the order in which the nodes of a tree are visited.
+type Order int
+
+const (
+	// PreOrder visits a node before its children.
+	PreOrder Order = iota
+
+	// PostOrder visits a node after its children.
+	PostOrder
+)
+
+// String implements fmt.Stringer.
+func (o Order) String() string {
+	switch o {
+	case PreOrder:
+		return "pre-order"
+	case PostOrder:
+		return "post-order"
+	default:
+		return "Order(" + strconv.Itoa(int(o)) + ")"
+	}
+}
+
+// WalkFn is the function called for every node of a walk.
+//
+// Parameters:
+//   - node: The node.
+//   - order: PreOrder when entering the node, PostOrder when leaving it.
+//
+// Returns:
+//   - error: SkipChildren, when entering, to not walk the children of the
+//     node; Stop to end the walk; or any other error to end the walk with
+//     that error.
+type WalkFn[T any] func(node T, order Order) error
+
+// walk is the recursive part of Walk.
+//
+// Parameters:
+//   - node: The node to walk.
+//   - fn: The function to call. (Assumed to not be nil)
+//
+// Returns:
+//   - error: Stop or the error of the function, if any.
+func walk[T Walkable[T]](node T, fn WalkFn[T]) error {
+	err := fn(node, PreOrder)
+	if err == SkipChildren {
+		err := fn(node, PostOrder)
+		if err == SkipChildren {
+			err = nil
+		}
+
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	for _, child := range node.GetChildren() {
+		err := walk(child, fn)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = fn(node, PostOrder)
+	if err == SkipChildren {
+		err = nil
+	}
+
+	return err
+}
+
+// Walk walks the tree rooted at the given node in depth-first order, calling
+// the function when entering every node and again when leaving it; even if
+// its children were skipped.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - fn: The function called for every node. Must not be nil.
+//
+// Returns:
+//   - error: The error returned by the function, if any other than
+//     SkipChildren and Stop.
+//
+// Errors:
+//   - common.ErrBadParam: If the function is nil.
+//   - any other error: The error returned by the function.
+func Walk[T Walkable[T]](root T, fn WalkFn[T]) error {
+	if fn == nil {
+		err := common.NewErrNilParam("fn")
+		return err
+	}
+
+	err := walk(root, fn)
+	if err == Stop {
+		return nil
+	}
+
+	return err
+}
+
+// Signal tells Inspect how to go on after visiting a node.
+type Signal int
+
+const (
+	// Continue goes on with the next node.
+	Continue Signal = iota
+
+	// Skip does not visit the children of the node. It has no effect in
+	// post-order, as they were already visited.
+	Skip
+
+	// Halt ends the inspection at once.
+	Halt
+)
+
+// Inspect visits the nodes of the tree rooted at the given node, in depth-first
+// order, by calling the function once for every node.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - order: Whether a node is visited before or after its children.
+//   - fn: The function called for every node. If nil, nothing is visited.
+//
+// Returns:
+//   - bool: False if the function halted the inspection, true otherwise.
+func Inspect[T Walkable[T]](root T, order Order, fn func(node T) Signal) bool {
+	if fn == nil {
+		return true
+	}
+
+	err := walk(root, func(node T, o Order) error {
+		if o != order {
+			return nil
+		}
+
+		switch fn(node) {
+		case Skip:
+			return SkipChildren
+		case Halt:
+			return Stop
+		default:
+			return nil
+		}
+	})
+
+	return err == nil
+}
+
+// VisitFn is the function called for the nodes of a given kind by a Visitor.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - error: SkipChildren to not visit the children of the node; Stop to end
+//     the visit; or any other error to end the visit with that error.
+type VisitFn[T any] func(node T) error
+
+// Visitor visits the nodes of a tree, in pre-order, by calling the function
+// registered for the kind of each node. See NewVisitor and NewTokenVisitor.
+//
+// A zero Visitor is not valid.
+type Visitor[T Walkable[T]] struct {
+	// kind_of returns the kind of a node.
+	kind_of func(node T) string
+
+	// table maps the kinds of nodes to their functions.
+	table map[string]VisitFn[T]
+
+	// fallback is the function of the nodes whose kind has none, if any.
+	fallback VisitFn[T]
+}
+
+// NewVisitor creates a new visitor that dispatches the nodes on the given
+// kind.
+//
+// Parameters:
+//   - kind_of: The function that returns the kind of a node. Must not be nil.
+//
+// Returns:
+//   - *Visitor[T]: The new visitor.
+//   - error: An error if the function is nil.
+func NewVisitor[T Walkable[T]](kind_of func(node T) string) (*Visitor[T], error) {
+	if kind_of == nil {
+		err := common.NewErrNilParam("kind_of")
+		return nil, err
+	}
+
+	v := &Visitor[T]{
+		kind_of: kind_of,
+		table:   make(map[string]VisitFn[T]),
+	}
+
+	return v, nil
+}
+
+// NewTokenVisitor creates a new visitor of token trees that dispatches the
+// tokens on their type.
+//
+// Returns:
+//   - *Visitor[*Token]: The new visitor. Never returns nil.
+func NewTokenVisitor() *Visitor[*Token] {
+	v := &Visitor[*Token]{
+		kind_of: func(tk *Token) string {
+			return tk.Type
+		},
+		table: make(map[string]VisitFn[*Token]),
+	}
+
+	return v
+}
+
+// On sets the function of the nodes of the given kind, replacing the previous
+// one, if any.
+//
+// Parameters:
+//   - kind: The kind of the nodes.
+//   - fn: The function. If nil, the nodes of the kind have no function.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (v *Visitor[T]) On(kind string, fn VisitFn[T]) error {
+	if v == nil {
+		return common.ErrNilReceiver
+	}
+
+	if fn == nil {
+		delete(v.table, kind)
+	} else {
+		v.table[kind] = fn
+	}
+
+	return nil
+}
+
+// SetDefault sets the function of the nodes whose kind has none. If none is
+// set, such nodes are skipped, but not their children.
+//
+// Parameters:
+//   - fn: The default function.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (v *Visitor[T]) SetDefault(fn VisitFn[T]) error {
+	if v == nil {
+		return common.ErrNilReceiver
+	}
+
+	v.fallback = fn
+
+	return nil
+}
+
+// Visit visits the tree rooted at the given node.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - error: The error returned by a function, if any other than
+//     SkipChildren and Stop.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - any other error: The error returned by a function.
+func (v *Visitor[T]) Visit(root T) error {
+	if v == nil {
+		return common.ErrNilReceiver
+	}
+
+	err := Walk(root, func(node T, order Order) error {
+		if order != PreOrder {
+			return nil
+		}
+
+		fn, ok := v.table[v.kind_of(node)]
+		if !ok {
+			fn = v.fallback
+		}
+
+		if fn == nil {
+			return nil
+		}
+
+		err := fn(node)
+		return err
+	})
+
+	return err
+}
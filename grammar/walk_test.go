@@ -0,0 +1,196 @@
+package grammar
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// sampleTree returns the tree (A (B x y) (C z)).
+func sampleTree() *Token {
+	b := NewToken("B", "")
+	_ = b.AppendChildren([]*Token{NewToken("x", "1"), NewToken("y", "2")})
+
+	c := NewToken("C", "")
+	_ = c.AppendChildren([]*Token{NewToken("z", "3")})
+
+	a := NewToken("A", "")
+	_ = a.AppendChildren([]*Token{b, c})
+
+	return a
+}
+
+func TestWalk(t *testing.T) {
+	errFailure := errors.New("failure")
+
+	tests := []struct {
+		name string
+		at   map[string]error
+		want []string
+		err  error
+	}{
+		{
+			name: "every node",
+			want: []string{"+A", "+B", "+x", "-x", "+y", "-y", "-B", "+C", "+z", "-z", "-C", "-A"},
+		},
+		{
+			name: "skip children",
+			at:   map[string]error{"B": SkipChildren},
+			want: []string{"+A", "+B", "-B", "+C", "+z", "-z", "-C", "-A"},
+		},
+		{
+			name: "stop",
+			at:   map[string]error{"y": Stop},
+			want: []string{"+A", "+B", "+x", "-x", "+y"},
+		},
+		{
+			name: "error",
+			at:   map[string]error{"C": errFailure},
+			want: []string{"+A", "+B", "+x", "-x", "+y", "-y", "-B", "+C"},
+			err:  errFailure,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+
+			err := Walk(sampleTree(), func(tk *Token, order Order) error {
+				if order == PreOrder {
+					got = append(got, "+"+tk.Type)
+					return test.at[tk.Type]
+				}
+
+				got = append(got, "-"+tk.Type)
+				return nil
+			})
+
+			if err != test.err {
+				t.Errorf("Walk() returned %v, want %v", err, test.err)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("visited %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	err := Walk[*Token](sampleTree(), nil)
+	if err == nil {
+		t.Errorf("Walk() with a nil function returned no error")
+	}
+}
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name  string
+		order Order
+		at    map[string]Signal
+		want  []string
+		ok    bool
+	}{
+		{"pre-order", PreOrder, nil, []string{"A", "B", "x", "y", "C", "z"}, true},
+		{"post-order", PostOrder, nil, []string{"x", "y", "B", "z", "C", "A"}, true},
+		{"skip", PreOrder, map[string]Signal{"B": Skip}, []string{"A", "B", "C", "z"}, true},
+		{"halt", PostOrder, map[string]Signal{"B": Halt}, []string{"x", "y", "B"}, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+
+			ok := Inspect(sampleTree(), test.order, func(tk *Token) Signal {
+				got = append(got, tk.Type)
+				return test.at[tk.Type]
+			})
+
+			if ok != test.ok {
+				t.Errorf("Inspect() = %t, want %t", ok, test.ok)
+			}
+
+			if !slices.Equal(got, test.want) {
+				t.Errorf("visited %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestTokenVisitor(t *testing.T) {
+	v := NewTokenVisitor()
+
+	var got []string
+
+	_ = v.On("B", func(tk *Token) error {
+		got = append(got, "B")
+		return SkipChildren
+	})
+
+	_ = v.On("z", func(tk *Token) error {
+		got = append(got, "z="+tk.Data)
+		return nil
+	})
+
+	_ = v.SetDefault(func(tk *Token) error {
+		got = append(got, "default "+tk.Type)
+		return nil
+	})
+
+	err := v.Visit(sampleTree())
+	if err != nil {
+		t.Fatalf("Visit() returned an error: %v", err)
+	}
+
+	want := []string{"default A", "B", "default C", "z=3"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("visited %q, want %q", got, want)
+	}
+}
+
+// node is a user tree node, to check that the walking functions are not tied
+// to tokens.
+type node struct {
+	name     string
+	children []*node
+}
+
+// GetChildren implements Walkable.
+func (n *node) GetChildren() []*node {
+	return n.children
+}
+
+func TestVisitorUserNodes(t *testing.T) {
+	root := &node{
+		name: "root",
+		children: []*node{
+			{name: "leaf"},
+			{name: "inner", children: []*node{{name: "leaf"}}},
+		},
+	}
+
+	v, err := NewVisitor(func(n *node) string { return n.name })
+	if err != nil {
+		t.Fatalf("NewVisitor() returned an error: %v", err)
+	}
+
+	var leaves int
+
+	_ = v.On("leaf", func(n *node) error {
+		leaves++
+		return nil
+	})
+
+	err = v.Visit(root)
+	if err != nil {
+		t.Fatalf("Visit() returned an error: %v", err)
+	}
+
+	if leaves != 2 {
+		t.Errorf("visited %d leaves, want 2", leaves)
+	}
+
+	_, err = NewVisitor[*node](nil)
+	if err == nil {
+		t.Errorf("NewVisitor(nil) returned no error")
+	}
+}
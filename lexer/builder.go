@@ -8,19 +8,32 @@ import (
 	"github.com/PlayerR9/SlParser/mygo-lib/common"
 )
 
-// Builder is a builder for lexers.
-type Builder struct {
+// BuilderOf is a builder for lexers of tokens whose types are of kind K.
+type BuilderOf[K slgr.Kind] struct {
 	// lex_one_fn is the function used to lex one token from the input data.
-	lex_one_fn LexOneFn
+	lex_one_fn LexOneFnOf[K]
+
+	// max_input_size is the maximum number of bytes of input data, or 0 if
+	// there is no limit.
+	max_input_size int
+
+	// max_tokens is the maximum number of tokens to lex, or 0 if there is no
+	// limit.
+	max_tokens int
 }
 
+// Builder is the builder of the string-based API.
+type Builder = BuilderOf[string]
+
 // Reset implements common.Resetter.
-func (b *Builder) Reset() error {
+func (b *BuilderOf[K]) Reset() error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
 
 	b.lex_one_fn = nil
+	b.max_input_size = 0
+	b.max_tokens = 0
 
 	return nil
 }
@@ -36,7 +49,7 @@ func (b *Builder) Reset() error {
 // Errors:
 //   - common.ErrNilReceiver: If the receiver is nil.
 //   - common.ErrBadParam: If the parameter is nil.
-func (b *Builder) SetLexOneFn(fn LexOneFn) error {
+func (b *BuilderOf[K]) SetLexOneFn(fn LexOneFnOf[K]) error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
@@ -51,15 +64,73 @@ func (b *Builder) SetLexOneFn(fn LexOneFn) error {
 	return nil
 }
 
-// Build creates a new lexer using the values set on the builder.
+// SetMaxInputSize sets the maximum number of bytes of input data the lexer
+// accepts, whether written or read (see Lexer.SetReader). Beyond it, the
+// lexer fails with a *common.ErrLimitExceeded error.
+//
+// Parameters:
+//   - size: The maximum number of bytes, or 0 for no limit. Must not be
+//     negative.
 //
 // Returns:
-//   - *Lexer: The newly created lexer. Never returns nil.
-func (b Builder) Build() *Lexer {
-	var fn LexOneFn
+//   - error: An error if the receiver is nil or if the parameter is negative.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If the parameter is negative.
+func (b *BuilderOf[K]) SetMaxInputSize(size int) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if size < 0 {
+		err := common.NewErrBadParam("size", "must not be negative")
+		return err
+	}
+
+	b.max_input_size = size
+
+	return nil
+}
+
+// SetMaxTokens sets the maximum number of tokens the lexer lexes. Beyond it,
+// the lexer fails with a *common.ErrLimitExceeded error.
+//
+// Parameters:
+//   - n: The maximum number of tokens, or 0 for no limit. Must not be
+//     negative.
+//
+// Returns:
+//   - error: An error if the receiver is nil or if the parameter is negative.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If the parameter is negative.
+func (b *BuilderOf[K]) SetMaxTokens(n int) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if n < 0 {
+		err := common.NewErrBadParam("n", "must not be negative")
+		return err
+	}
+
+	b.max_tokens = n
+
+	return nil
+}
+
+// Compile creates a new definition using the values set on the builder. See
+// Definition.
+//
+// Returns:
+//   - *DefinitionOf[K]: The newly created definition. Never returns nil.
+func (b BuilderOf[K]) Compile() *DefinitionOf[K] {
+	var fn LexOneFnOf[K]
 
 	if b.lex_one_fn == nil {
-		fn = func(_ io.RuneScanner) (*slgr.Token, error) {
+		fn = func(_ io.RuneScanner) (*slgr.TokenOf[K], error) {
 			err := errors.New("no lexing function provided")
 			return nil, err
 		}
@@ -67,9 +138,20 @@ func (b Builder) Build() *Lexer {
 		fn = b.lex_one_fn
 	}
 
-	lexer := &Lexer{
-		lex_one_fn: fn,
+	def := &DefinitionOf[K]{
+		lex_one_fn:     fn,
+		max_input_size: b.max_input_size,
+		max_tokens:     b.max_tokens,
 	}
 
+	return def
+}
+
+// Build creates a new lexer using the values set on the builder.
+//
+// Returns:
+//   - *LexerOf[K]: The newly created lexer. Never returns nil.
+func (b BuilderOf[K]) Build() *LexerOf[K] {
+	lexer := b.Compile().NewLexer()
 	return lexer
 }
@@ -0,0 +1,76 @@
+package lexer
+
+import (
+	"context"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// DefinitionOf is the compiled configuration of a lexer. Unlike a Lexer, it
+// holds no state of a lexing process and it cannot be modified; so a single
+// definition can be shared across goroutines, each lexing with its own lexer
+// spawned by NewLexer.
+//
+// The lexing function must then be safe for concurrent use; which it is as
+// long as it only works on the given scanner.
+type DefinitionOf[K slgr.Kind] struct {
+	// lex_one_fn is the function used to lex one token from the input data.
+	lex_one_fn LexOneFnOf[K]
+
+	// max_input_size is the maximum number of bytes of input data, or 0 if
+	// there is no limit.
+	max_input_size int
+
+	// max_tokens is the maximum number of tokens to lex, or 0 if there is no
+	// limit.
+	max_tokens int
+}
+
+// Definition is the definition of the string-based API.
+type Definition = DefinitionOf[string]
+
+// NewLexer creates a new lexer, with no input data, from the definition. The
+// lexer is not safe for concurrent use, but it is independent from the other
+// lexers of the definition.
+//
+// Returns:
+//   - *LexerOf[K]: The new lexer. Never returns nil.
+func (d DefinitionOf[K]) NewLexer() *LexerOf[K] {
+	lexer := &LexerOf[K]{
+		lex_one_fn:     d.lex_one_fn,
+		max_input_size: d.max_input_size,
+		max_tokens:     d.max_tokens,
+	}
+
+	return lexer
+}
+
+// Lex lexes the input data with a new lexer of the definition. It is safe to
+// call concurrently. See Lex.
+//
+// Parameters:
+//   - data: The input data to be lexed.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The list of lexed tokens. If an error occurs while lexing, the
+//     returned list may be empty.
+//   - error: An error if the lexing process fails.
+func (d DefinitionOf[K]) Lex(data []byte) ([]*slgr.TokenOf[K], error) {
+	tokens, err := LexContext(context.Background(), d.NewLexer(), data)
+	return tokens, err
+}
+
+// LexContext is like Lex, but it stops as soon as the context is done.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - data: The input data to be lexed.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The list of lexed tokens. If an error occurs while lexing, the
+//     returned list may be empty.
+//   - error: An error if the lexing process fails or if the context is done.
+func (d DefinitionOf[K]) LexContext(ctx context.Context, data []byte) ([]*slgr.TokenOf[K], error) {
+	tokens, err := LexContext(ctx, d.NewLexer(), data)
+	return tokens, err
+}
@@ -0,0 +1,59 @@
+package lexer
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// TestDefinitionConcurrentSessions runs many lexing sessions at the same time
+// from a definition shared across goroutines. Run it with -race.
+func TestDefinitionConcurrentSessions(t *testing.T) {
+	var builder Builder
+
+	_ = builder.SetLexOneFn(lexWords)
+
+	def := builder.Compile()
+
+	const sessions = 64
+
+	var wg sync.WaitGroup
+
+	errs := make([]string, sessions)
+
+	for i := range sessions {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			data := []byte(strings.Repeat("word, ", i+1))
+
+			tokens, err := def.Lex(data)
+			if err != nil {
+				errs[i] = "Lex() returned an error: " + err.Error()
+				return
+			} else if len(tokens) != 2*(i+1) {
+				errs[i] = "Lex() returned " + describe(tokens)
+				return
+			}
+
+			lexer := def.NewLexer()
+
+			tokens, _, _, err = Relex(lexer, tokens, append([]byte("new "), data...), slgr.Edit{Offset: 0, Inserted: "new "})
+			if err != nil || len(tokens) != 2*(i+1)+1 {
+				errs[i] = "Relex() returned " + describe(tokens)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != "" {
+			t.Errorf("session #%d: %s", i, err)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package lexer
+
+import (
+	"io"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// SetExpected sets the token types the parser accepts next. The parser calls
+// it before pulling each token (see parser.ExpectingSource), so that the
+// lexing function can restrict or prioritise its matches with IsExpected; for
+// instance, to lex ">>" as two ">" tokens inside generics.
+//
+// Parameters:
+//   - expected: The accepted token types. If nil, every type is accepted.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (l *LexerOf[K]) SetExpected(expected []K) error {
+	if l == nil {
+		return common.ErrNilReceiver
+	}
+
+	if expected == nil {
+		l.expected = nil
+		return nil
+	}
+
+	l.expected = make(map[K]struct{}, len(expected))
+
+	for _, type_ := range expected {
+		l.expected[type_] = struct{}{}
+	}
+
+	return nil
+}
+
+// IsExpected checks whether the parser accepts the given token type next.
+//
+// Parameters:
+//   - type_: The token type.
+//
+// Returns:
+//   - bool: True if the type is accepted or if the parser gave no expected
+//     types, false otherwise.
+func (l LexerOf[K]) IsExpected(type_ K) bool {
+	if l.expected == nil {
+		return true
+	}
+
+	_, ok := l.expected[type_]
+	return ok
+}
+
+// IsExpected checks whether the parser accepts the given token type next,
+// from within a lexing function.
+//
+// Parameters:
+//   - scanner: The scanner given to the lexing function.
+//   - type_: The token type.
+//
+// Returns:
+//   - bool: True if the type is accepted, or if the scanner is not a lexer
+//     that was given expected types; false otherwise.
+func IsExpected[K slgr.Kind](scanner io.RuneScanner, type_ K) bool {
+	l, ok := scanner.(*LexerOf[K])
+	if !ok || l == nil {
+		return true
+	}
+
+	ok = l.IsExpected(type_)
+	return ok
+}
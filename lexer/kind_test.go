@@ -0,0 +1,109 @@
+package lexer
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// calcKind is the typed kind of the tokens of lexCalc.
+type calcKind int
+
+const (
+	calcNum calcKind = iota
+	calcPlus
+)
+
+// String implements fmt.Stringer.
+func (k calcKind) String() string {
+	switch k {
+	case calcNum:
+		return "num"
+	case calcPlus:
+		return "plus"
+	default:
+		return "calcKind?"
+	}
+}
+
+// lexCalc lexes single-digit numbers and plus signs, skipping spaces. A plus
+// sign is lexed as a number "0" when the parser does not expect it.
+func lexCalc(scanner io.RuneScanner) (*slgr.TokenOf[calcKind], error) {
+	c, _, err := scanner.ReadRune()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case c == ' ':
+		return nil, nil
+	case c == '+' && IsExpected(scanner, calcPlus):
+		return slgr.NewToken(calcPlus, "+"), nil
+	case c == '+':
+		return slgr.NewToken(calcNum, "0"), nil
+	case c >= '0' && c <= '9':
+		return slgr.NewToken(calcNum, string(c)), nil
+	default:
+		return nil, errors.New("unexpected character " + string(c))
+	}
+}
+
+// kinds returns the kinds of the given tokens.
+func kinds(tokens []*slgr.TokenOf[calcKind]) []calcKind {
+	var types []calcKind
+
+	for _, tk := range tokens {
+		types = append(types, tk.Type)
+	}
+
+	return types
+}
+
+func TestTypedKindLex(t *testing.T) {
+	var builder BuilderOf[calcKind]
+
+	_ = builder.SetLexOneFn(lexCalc)
+
+	def := builder.Compile()
+
+	tokens, err := def.Lex([]byte("1 + 2"))
+	if err != nil {
+		t.Fatalf("Lex() returned an error: %v", err)
+	}
+
+	if got, want := kinds(tokens), []calcKind{calcNum, calcPlus, calcNum}; !slices.Equal(got, want) {
+		t.Errorf("Lex() = %v, want %v", got, want)
+	}
+
+	lexer := def.NewLexer()
+
+	_ = lexer.SetExpected([]calcKind{calcNum})
+
+	if lexer.IsExpected(calcPlus) || !lexer.IsExpected(calcNum) {
+		t.Errorf("IsExpected() does not follow SetExpected()")
+	}
+
+	tokens, err = Lex(lexer, []byte("+"))
+	if err != nil || len(tokens) != 1 || tokens[0].Type != calcNum {
+		t.Errorf("Lex() with only numbers expected = %v, %v", tokens, err)
+	}
+
+	_ = lexer.SetExpected(nil)
+
+	old, err := Lex(lexer, []byte("1+2"))
+	if err != nil {
+		t.Fatalf("Lex() returned an error: %v", err)
+	}
+
+	tokens, _, _, err = Relex(lexer, old, []byte("1+2+3"), slgr.Edit{Offset: 3, Inserted: "+3"})
+	if err != nil {
+		t.Fatalf("Relex() returned an error: %v", err)
+	}
+
+	if got, want := kinds(tokens), []calcKind{calcNum, calcPlus, calcNum, calcPlus, calcNum}; !slices.Equal(got, want) {
+		t.Errorf("Relex() = %v, want %v", got, want)
+	}
+}
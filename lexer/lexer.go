@@ -1,6 +1,8 @@
 package lexer
 
 import (
+	"bufio"
+	"context"
 	"io"
 	"unicode/utf8"
 
@@ -9,33 +11,68 @@ import (
 	gch "github.com/PlayerR9/SlParser/mygo-lib/runes"
 )
 
-// LexOneFn is the function used to lex one token from the input data.
+// LexOneFnOf is the function used to lex one token from the input data.
 //
 // Parameters:
 //   - scanner: The input data to be lexed.
 //
 // Returns:
-//   - *slgr.Token: The lexed token, or nil if the lexing process fails.
+//   - *slgr.TokenOf[K]: The lexed token, or nil if the lexing process fails.
 //   - error: An error if the lexing process fails.
-type LexOneFn func(scanner io.RuneScanner) (*slgr.Token, error)
+type LexOneFnOf[K slgr.Kind] func(scanner io.RuneScanner) (*slgr.TokenOf[K], error)
 
-// Lexer is a lexer that can be used to lex input data into a list of tokens.
-type Lexer struct {
+// LexOneFn is the lexing function of the string-based API.
+type LexOneFn = LexOneFnOf[string]
+
+// LexerOf is a lexer that can be used to lex input data into a list of tokens
+// whose types are of kind K.
+type LexerOf[K slgr.Kind] struct {
 	// chars is a list of characters that have not been lexed yet.
 	chars []rune
 
 	// tokens is a list of lexed tokens.
-	tokens []*slgr.Token
+	tokens []*slgr.TokenOf[K]
 
 	// last_read is the last rune that was read from the input data.
 	last_read *rune
 
+	// pos is the position of the next rune to be read.
+	pos slgr.Position
+
+	// last_pos is the position of the last rune that was read.
+	last_pos slgr.Position
+
 	// lex_one_fn is the function used to lex one token from the input data.
-	lex_one_fn LexOneFn
+	lex_one_fn LexOneFnOf[K]
+
+	// reader is the reader the input data is read from once the written data
+	// is exhausted, if any.
+	reader *bufio.Reader
+
+	// expected is the set of token types the parser accepts next, or nil if
+	// every type is accepted.
+	expected map[K]struct{}
+
+	// max_input_size is the maximum number of bytes of input data, or 0 if
+	// there is no limit.
+	max_input_size int
+
+	// max_tokens is the maximum number of tokens to lex, or 0 if there is no
+	// limit.
+	max_tokens int
+
+	// written is the number of bytes written so far.
+	written int
+
+	// lexed is the number of tokens lexed so far.
+	lexed int
 }
 
+// Lexer is the lexer of the string-based API.
+type Lexer = LexerOf[string]
+
 // Write implements io.Writer.
-func (l *Lexer) Write(data []byte) (int, error) {
+func (l *LexerOf[K]) Write(data []byte) (int, error) {
 	if l == nil {
 		return 0, common.ErrNilReceiver
 	}
@@ -44,38 +81,68 @@ func (l *Lexer) Write(data []byte) (int, error) {
 		return 0, nil
 	}
 
+	if l.max_input_size > 0 && l.written+len(data) > l.max_input_size {
+		err := common.NewErrLimitExceeded("input size", l.max_input_size)
+		return 0, err
+	}
+
 	chars, err := gch.BytesToUtf8(data)
 	if err != nil {
 		return 0, err
 	}
 
 	l.chars = append(l.chars, chars...)
+	l.written += len(data)
 
 	return len(data), nil
 }
 
 // ReadRune implements io.RuneScanner.
-func (l *Lexer) ReadRune() (rune, int, error) {
+func (l *LexerOf[K]) ReadRune() (rune, int, error) {
 	if l == nil {
 		return 0, 0, common.ErrNilReceiver
 	}
 
-	if len(l.chars) == 0 {
+	var c rune
+
+	if len(l.chars) > 0 {
+		c = l.chars[0]
+		l.chars = l.chars[1:]
+	} else if l.reader != nil {
+		char, size, err := l.reader.ReadRune()
+		if err == io.EOF {
+			l.reader = nil
+			return 0, 0, io.EOF
+		} else if err != nil {
+			return 0, 0, err
+		} else if char == utf8.RuneError && size == 1 {
+			return 0, 0, gch.ErrInvalidUtf8
+		} else if l.max_input_size > 0 && l.Pos().Offset+size > l.max_input_size {
+			err := common.NewErrLimitExceeded("input size", l.max_input_size)
+			return 0, 0, err
+		}
+
+		c = char
+	} else {
 		return 0, 0, io.EOF
 	}
 
-	c := l.chars[0]
-	l.chars = l.chars[1:]
-
 	l.last_read = &c
 
 	size := utf8.RuneLen(c)
 
+	if !l.pos.IsValid() {
+		l.pos = slgr.StartPosition()
+	}
+
+	l.last_pos = l.pos
+	l.pos = l.pos.Advance(c, size)
+
 	return c, size, nil
 }
 
 // UnreadRune implements io.RuneScanner.
-func (l *Lexer) UnreadRune() error {
+func (l *LexerOf[K]) UnreadRune() error {
 	if l == nil {
 		return common.ErrNilReceiver
 	}
@@ -87,11 +154,13 @@ func (l *Lexer) UnreadRune() error {
 	l.chars = append([]rune{*l.last_read}, l.chars...)
 	l.last_read = nil
 
+	l.pos = l.last_pos
+
 	return nil
 }
 
 // Reset implements common.Resetter.
-func (l *Lexer) Reset() error {
+func (l *LexerOf[K]) Reset() error {
 	if l == nil {
 		return common.ErrNilReceiver
 	}
@@ -107,54 +176,164 @@ func (l *Lexer) Reset() error {
 	}
 
 	l.last_read = nil
+	l.pos = slgr.StartPosition()
+	l.last_pos = slgr.StartPosition()
+	l.reader = nil
+	l.expected = nil
+	l.written = 0
+	l.lexed = 0
+
+	return nil
+}
+
+// SetReader sets the reader the input data is read from, on demand, once the
+// data written to the lexer is exhausted. This allows to lex streams without
+// holding them in memory.
+//
+// Parameters:
+//   - r: The reader. If nil, only the written data is lexed.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (l *LexerOf[K]) SetReader(r io.Reader) error {
+	if l == nil {
+		return common.ErrNilReceiver
+	}
+
+	if r == nil {
+		l.reader = nil
+	} else {
+		l.reader = bufio.NewReader(r)
+	}
 
 	return nil
 }
 
+// Pos returns the position of the next rune to be read.
+//
+// Returns:
+//   - slgr.Position: The position of the next rune.
+func (l LexerOf[K]) Pos() slgr.Position {
+	if !l.pos.IsValid() {
+		return slgr.StartPosition()
+	}
+
+	return l.pos
+}
+
 // GetTokens returns a copy of the tokens that have been lexed.
 //
 // The function returns a copy of the tokens that have been lexed so far. If
 // no tokens have been lexed, the function returns nil.
 //
 // Returns:
-//   - []*slgr.Token: A copy of the tokens that have been lexed, or nil if no
+//   - []*slgr.TokenOf[K]: A copy of the tokens that have been lexed, or nil if no
 //     tokens have been lexed.
-func (l Lexer) GetTokens() []*slgr.Token {
+func (l LexerOf[K]) GetTokens() []*slgr.TokenOf[K] {
 	if len(l.tokens) == 0 {
 		return nil
 	}
 
-	tokens := make([]*slgr.Token, len(l.tokens))
+	tokens := make([]*slgr.TokenOf[K], len(l.tokens))
 	copy(tokens, l.tokens)
 
 	return tokens
 }
 
+// Next lexes the next token of the input data using the lexing function.
+//
+// Tokens whose position was not set by the lexing function are given the
+// position at which the lexing function started reading.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The next token, or nil if there is none.
+//   - error: An error if the lexing process fails or if the receiver is nil.
+//
+// Errors:
+//   - io.EOF: If the input data is exhausted.
+//   - *common.ErrLimitExceeded: If the maximum number of tokens or the maximum
+//     input size is exceeded.
+//   - any other error: If the lexing function fails.
+func (l *LexerOf[K]) Next() (*slgr.TokenOf[K], error) {
+	if l == nil {
+		return nil, common.ErrNilReceiver
+	}
+
+	for {
+		pos := l.Pos()
+
+		tk, err := l.lex_one_fn(l)
+		if err != nil {
+			return nil, err
+		}
+
+		if tk == nil {
+			continue
+		}
+
+		if !tk.Pos.IsValid() {
+			tk.Pos = pos
+		}
+
+		if l.max_tokens > 0 && l.lexed >= l.max_tokens {
+			err := common.NewErrLimitExceeded("token", l.max_tokens)
+			return nil, err
+		}
+
+		l.lexed++
+
+		return tk, nil
+	}
+}
+
 // Lex lexes input data into a list of tokens using the lexing function.
 //
 // The function reads runes from the input data and applies the lexing
 // function to convert them into tokens. The process continues until
-// the end of the input is reached or an error occurs.
+// the end of the input is reached or an error occurs. See Next.
 //
 // Returns:
 //   - error: An error if the lexing process fails or if the receiver
 //     is nil.
-func (l *Lexer) Lex() error {
+func (l *LexerOf[K]) Lex() error {
+	err := l.LexContext(context.Background())
+	return err
+}
+
+// LexContext is like Lex, but it stops as soon as the context is done.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//
+// Returns:
+//   - error: An error if the lexing process fails, if the context is done or
+//     if the receiver is nil.
+//
+// Errors:
+//   - ctx.Err(): If the context is done.
+//   - *common.ErrLimitExceeded: If a limit of the lexer is exceeded.
+//   - any other error: If the lexing process fails.
+func (l *LexerOf[K]) LexContext(ctx context.Context) error {
 	if l == nil {
 		return common.ErrNilReceiver
 	}
 
 	for {
-		tk, err := l.lex_one_fn(l)
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			return err
+		default:
+		}
+
+		tk, err := l.Next()
 		if err == io.EOF {
 			break
 		} else if err != nil {
 			return err
 		}
 
-		if tk != nil {
-			l.tokens = append(l.tokens, tk)
-		}
+		l.tokens = append(l.tokens, tk)
 	}
 
 	return nil
@@ -167,10 +346,27 @@ func (l *Lexer) Lex() error {
 //   - data: The input data to be lexed.
 //
 // Returns:
-//   - []*slgr.Token: The list of lexed tokens. If an error occurs while lexing, the
+//   - []*slgr.TokenOf[K]: The list of lexed tokens. If an error occurs while lexing, the
 //     returned list may be empty.
 //   - error: An error if the lexing process fails.
-func Lex(lexer *Lexer, data []byte) ([]*slgr.Token, error) {
+func Lex[K slgr.Kind](lexer *LexerOf[K], data []byte) ([]*slgr.TokenOf[K], error) {
+	tokens, err := LexContext(context.Background(), lexer, data)
+	return tokens, err
+}
+
+// LexContext is like Lex, but it stops as soon as the context is done. See
+// Lexer.LexContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - lexer: The lexer to be used to lex the input data.
+//   - data: The input data to be lexed.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The list of lexed tokens. If an error occurs while lexing, the
+//     returned list may be empty.
+//   - error: An error if the lexing process fails or if the context is done.
+func LexContext[K slgr.Kind](ctx context.Context, lexer *LexerOf[K], data []byte) ([]*slgr.TokenOf[K], error) {
 	if lexer == nil {
 		return nil, common.ErrNilReceiver
 	}
@@ -189,7 +385,7 @@ func Lex(lexer *Lexer, data []byte) ([]*slgr.Token, error) {
 		}
 	}
 
-	err := lexer.Lex()
+	err := lexer.LexContext(ctx)
 
 	tokens := lexer.GetTokens()
 	return tokens, err
@@ -0,0 +1,160 @@
+package lexer
+
+import (
+	"io"
+	"sort"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// startAt makes the lexer read the written data as if it started at the
+// given position of a larger input, so that the positions of the lexed
+// tokens refer to that input.
+//
+// Parameters:
+//   - pos: The position of the first rune to be read.
+func (l *LexerOf[K]) startAt(pos slgr.Position) {
+	l.pos = pos
+	l.last_pos = pos
+}
+
+// shifted returns a copy of the given token of the previous input data, moved
+// to where it is in the edited input data.
+//
+// Parameters:
+//   - tk: The token to move. (Assumed to not be nil)
+//   - anchor: The previous position of the first moved token.
+//   - to: The new position of the first moved token.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The moved copy of the token. Never returns nil.
+func shifted[K slgr.Kind](tk *slgr.TokenOf[K], anchor, to slgr.Position) *slgr.TokenOf[K] {
+	moved := slgr.NewToken(tk.Type, tk.Data)
+	moved.Pos = tk.Pos
+
+	if moved.Pos.Line == anchor.Line {
+		moved.Pos.Column += to.Column - anchor.Column
+	}
+
+	moved.Pos.Line += to.Line - anchor.Line
+	moved.Pos.Offset += to.Offset - anchor.Offset
+
+	return moved
+}
+
+// Relex lexes the edited input data again without lexing all of it: it
+// restarts from the last token of the previous input data that starts before
+// the edit and stops as soon as a lexed token, past the edit, is the same as a
+// previous token at the same place. From there on, the rest of the input data
+// is unchanged and the previous tokens are reused with their positions
+// shifted. Shifting copies every token after the edit, which takes time linear
+// in their number, but the lexing function does not run on them.
+//
+// The lexing function is assumed to look at most one character past the end
+// of a token and to not depend on anything but the input data; as the
+// resynchronisation relies on the lexing function starting from the same
+// state at the same place.
+//
+// Parameters:
+//   - lexer: The lexer to be used to lex the input data.
+//   - old_tokens: The tokens of the previous input data, as returned by Lex.
+//   - data: The edited input data.
+//   - edit: The edit of the previous input data that resulted in data. See
+//     slgr.ApplyEdits for combining several edits.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The tokens of the edited input data.
+//   - int: The number of tokens at the start that are shared with old_tokens.
+//   - int: The number of tokens at the end that are shifted copies of the last
+//     tokens of old_tokens. The tokens in between are the only ones that
+//     changed.
+//   - error: An error if the lexing process fails.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the lexer is nil.
+//   - common.ErrBadParam: If the edit does not fit the edited input data.
+//   - any other error: If the lexing process fails.
+func Relex[K slgr.Kind](lexer *LexerOf[K], old_tokens []*slgr.TokenOf[K], data []byte, edit slgr.Edit) ([]*slgr.TokenOf[K], int, int, error) {
+	if lexer == nil {
+		return nil, 0, 0, common.ErrNilReceiver
+	}
+
+	end := edit.Offset + len(edit.Inserted)
+
+	if edit.Offset < 0 || edit.Deleted < 0 || end > len(data) {
+		err := common.NewErrBadParam("edit", "does not fit the input data")
+		return nil, 0, 0, err
+	}
+
+	defer lexer.Reset()
+
+	// The first previous token that starts at or after the edit; the one
+	// before it may be extended by the edit and it is lexed again.
+	k := sort.Search(len(old_tokens), func(i int) bool {
+		return old_tokens[i].Pos.Offset >= edit.Offset
+	})
+
+	prefix := max(0, k-1)
+
+	start := slgr.StartPosition()
+	if prefix > 0 {
+		start = old_tokens[prefix].Pos
+	}
+
+	_ = lexer.Reset()
+
+	_, err := lexer.Write(data[start.Offset:])
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	lexer.startAt(start)
+
+	delta := len(edit.Inserted) - edit.Deleted
+
+	// The first previous token that starts after the deleted bytes.
+	j := sort.Search(len(old_tokens), func(i int) bool {
+		return old_tokens[i].Pos.Offset >= edit.Offset+edit.Deleted
+	})
+
+	tokens := make([]*slgr.TokenOf[K], prefix, len(old_tokens)+1)
+	copy(tokens, old_tokens[:prefix])
+
+	for {
+		tk, err := lexer.Next()
+		if err == io.EOF {
+			return tokens, prefix, 0, nil
+		} else if err != nil {
+			return nil, 0, 0, err
+		}
+
+		if tk.Pos.Offset < end {
+			tokens = append(tokens, tk)
+			continue
+		}
+
+		for j < len(old_tokens) && old_tokens[j].Pos.Offset+delta < tk.Pos.Offset {
+			j++
+		}
+
+		if j == len(old_tokens) {
+			tokens = append(tokens, tk)
+			continue
+		}
+
+		old := old_tokens[j]
+
+		if old.Pos.Offset+delta != tk.Pos.Offset || old.Type != tk.Type || old.Data != tk.Data {
+			tokens = append(tokens, tk)
+			continue
+		}
+
+		// Resynchronised: the rest of the input data is the same.
+		for _, old_tk := range old_tokens[j:] {
+			tokens = append(tokens, shifted(old_tk, old.Pos, tk.Pos))
+		}
+
+		return tokens, prefix, len(old_tokens) - j, nil
+	}
+}
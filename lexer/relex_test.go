@@ -0,0 +1,174 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"unicode"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// lexWords lexes words of letters and single-character punctuation, skipping
+// spaces and newlines.
+func lexWords(scanner io.RuneScanner) (*slgr.Token, error) {
+	c, _, err := scanner.ReadRune()
+	if err != nil {
+		return nil, err
+	}
+
+	if c == ' ' || c == '\n' {
+		return nil, nil
+	} else if !unicode.IsLetter(c) {
+		return slgr.NewToken("punct", string(c)), nil
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteRune(c)
+
+	for {
+		c, _, err := scanner.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !unicode.IsLetter(c) {
+			_ = scanner.UnreadRune()
+			break
+		}
+
+		_, _ = builder.WriteRune(c)
+	}
+
+	return slgr.NewToken("word", builder.String()), nil
+}
+
+// newWordLexer returns a lexer of words.
+func newWordLexer() *Lexer {
+	var builder Builder
+
+	_ = builder.SetLexOneFn(lexWords)
+
+	return builder.Build()
+}
+
+// describe returns the data and position of every given token.
+func describe(tokens []*slgr.Token) string {
+	parts := make([]string, 0, len(tokens))
+
+	for _, tk := range tokens {
+		parts = append(parts, fmt.Sprintf("%s@%d:%d:%d", tk.Data, tk.Pos.Line, tk.Pos.Column, tk.Pos.Offset))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestRelex(t *testing.T) {
+	old_data := []byte("alpha beta;\ngamma delta\nepsilon")
+
+	tests := []struct {
+		name   string
+		edit   slgr.Edit
+		prefix int
+		suffix int
+	}{
+		{
+			name:   "word extended",
+			edit:   slgr.Edit{Offset: 10, Inserted: "x"},
+			prefix: 1,
+			suffix: 4,
+		},
+		{
+			name:   "word split",
+			edit:   slgr.Edit{Offset: 8, Inserted: " "},
+			prefix: 1,
+			suffix: 4,
+		},
+		{
+			name:   "line inserted",
+			edit:   slgr.Edit{Offset: 12, Inserted: "new line\n"},
+			prefix: 2,
+			suffix: 3,
+		},
+		{
+			name:   "tokens deleted",
+			edit:   slgr.Edit{Offset: 6, Deleted: 11},
+			prefix: 0,
+			suffix: 2,
+		},
+		{
+			name:   "edit at the start",
+			edit:   slgr.Edit{Offset: 0, Deleted: 5, Inserted: "omega"},
+			prefix: 0,
+			suffix: 5,
+		},
+		{
+			name:   "edit at the end",
+			edit:   slgr.Edit{Offset: 31, Inserted: "!"},
+			prefix: 5,
+			suffix: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			old_tokens, err := Lex(newWordLexer(), old_data)
+			if err != nil {
+				t.Fatalf("Lex() returned an error: %v", err)
+			}
+
+			data, err := test.edit.Apply(old_data)
+			if err != nil {
+				t.Fatalf("Apply() returned an error: %v", err)
+			}
+
+			tokens, prefix, suffix, err := Relex(newWordLexer(), old_tokens, data, test.edit)
+			if err != nil {
+				t.Fatalf("Relex() returned an error: %v", err)
+			}
+
+			want, err := Lex(newWordLexer(), data)
+			if err != nil {
+				t.Fatalf("Lex() returned an error: %v", err)
+			}
+
+			if got, want := describe(tokens), describe(want); got != want {
+				t.Errorf("tokens = %s, want %s", got, want)
+			}
+
+			if prefix != test.prefix || suffix != test.suffix {
+				t.Errorf("prefix, suffix = %d, %d, want %d, %d", prefix, suffix, test.prefix, test.suffix)
+			}
+
+			for i := range prefix {
+				if tokens[i] != old_tokens[i] {
+					t.Errorf("token #%d is not shared with the previous tokens", i)
+				}
+			}
+
+			for i := len(old_tokens) - suffix; i < len(old_tokens); i++ {
+				if tokens[i-len(old_tokens)+len(tokens)] == old_tokens[i] {
+					t.Errorf("previous token #%d is reused without being copied", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRelexBadEdit(t *testing.T) {
+	data := []byte("alpha")
+
+	_, _, _, err := Relex(newWordLexer(), nil, data, slgr.Edit{Offset: 3, Inserted: "xyz"})
+	if err == nil {
+		t.Errorf("Relex() with an edit past the end of the data returned no error")
+	}
+
+	_, _, _, err = Relex[string](nil, nil, data, slgr.Edit{})
+	if err == nil {
+		t.Errorf("Relex(nil) returned no error")
+	}
+}
@@ -1,6 +1,9 @@
 package common
 
-import "errors"
+import (
+	"errors"
+	"strconv"
+)
 
 var (
 	// ErrNilReceiver occurs when a method is called on a receiver that was not
@@ -87,3 +90,38 @@ func NewErrNilParam(param_name string) error {
 	}
 	return e
 }
+
+// ErrLimitExceeded occurs when a resource limit is exceeded.
+type ErrLimitExceeded struct {
+	// Limit is the name of the limit that was exceeded.
+	Limit string
+
+	// Max is the maximum value allowed by the limit.
+	Max int
+}
+
+// Error implements error.
+func (e ErrLimitExceeded) Error() string {
+	return e.Limit + " limit of " + strconv.Itoa(e.Max) + " exceeded"
+}
+
+// NewErrLimitExceeded returns an error with the given limit name and maximum.
+//
+// Parameters:
+//   - limit: The name of the limit that was exceeded.
+//   - max: The maximum value allowed by the limit.
+//
+// Returns:
+//   - error: An instance of ErrLimitExceeded. Never returns nil.
+//
+// Format:
+//
+//	"<limit> limit of <max> exceeded"
+func NewErrLimitExceeded(limit string, max int) error {
+	e := &ErrLimitExceeded{
+		Limit: limit,
+		Max:   max,
+	}
+
+	return e
+}
@@ -1,7 +1,7 @@
 package parser
 
 import (
-	"github.com/PlayerR9/SlParser/parser/internal"
+	slgr "github.com/PlayerR9/SlParser/grammar"
 )
 
 type Action interface {
@@ -15,27 +15,31 @@ func NewShiftAction() Action {
 	return act
 }
 
-type ReduceAction struct {
-	rule *internal.Rule
+type ReduceActionOf[K slgr.Kind] struct {
+	rule *slgr.RuleOf[K]
 }
 
-func NewReduceAction(lhs string, rhss ...string) Action {
-	rule := internal.NewRule(lhs, rhss)
+type ReduceAction = ReduceActionOf[string]
 
-	act := &ReduceAction{
+func NewReduceAction[K slgr.Kind](lhs K, rhss ...K) Action {
+	rule := slgr.NewRule(lhs, rhss...)
+
+	act := &ReduceActionOf[K]{
 		rule: rule,
 	}
 	return act
 }
 
-type AcceptAction struct {
-	rule *internal.Rule
+type AcceptActionOf[K slgr.Kind] struct {
+	rule *slgr.RuleOf[K]
 }
 
-func NewAcceptAction(lhs string, rhss ...string) Action {
-	rule := internal.NewRule(lhs, rhss)
+type AcceptAction = AcceptActionOf[string]
+
+func NewAcceptAction[K slgr.Kind](lhs K, rhss ...K) Action {
+	rule := slgr.NewRule(lhs, rhss...)
 
-	act := &AcceptAction{
+	act := &AcceptActionOf[K]{
 		rule: rule,
 	}
 	return act
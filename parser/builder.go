@@ -5,11 +5,9 @@ import (
 
 	slgr "github.com/PlayerR9/SlParser/grammar"
 	"github.com/PlayerR9/SlParser/mygo-lib/common"
-	assert "github.com/PlayerR9/go-verify"
-	lls "github.com/PlayerR9/mygo-data/stack"
 )
 
-// ParseOneFn is the function used to parse one token from the input data.
+// ParseOneFnOf is the function used to parse one token from the input data.
 //
 // Parameters:
 //   - parser: The input data to be parsed.
@@ -17,21 +15,71 @@ import (
 // Returns:
 //   - Action: The parsed action, or nil if the parsing process fails.
 //   - error: An error if the parsing process fails.
-type ParseOneFn func(parser *Parser) (Action, error)
+type ParseOneFnOf[K slgr.Kind] func(parser *ParserOf[K]) (Action, error)
 
-// Builder is a builder for parsers.
-type Builder struct {
+// ParseOneFn is the parsing function of the string-based API.
+type ParseOneFn = ParseOneFnOf[string]
+
+// BuilderOf is a builder for parsers of tokens whose types are of kind K.
+type BuilderOf[K slgr.Kind] struct {
 	// parse_one_fn is the function used to parse the input tokens.
-	parse_one_fn ParseOneFn
+	parse_one_fn ParseOneFnOf[K]
+
+	// table is the parsing table used to parse the input tokens, if any.
+	table *TableOf[K]
+
+	// eof is the kind of the end-of-input token.
+	eof K
+
+	// error_ is the kind of the error pseudo-terminal.
+	error_ K
+
+	// has_kinds is true if eof and error_ are set.
+	has_kinds bool
+
+	// recovery is true if the parser recovers from errors in panic mode.
+	recovery bool
+
+	// repair is true if the parser repairs the input on errors.
+	repair bool
+
+	// glr is true if the parser runs in GLR mode.
+	glr bool
+
+	// max_stack_depth is the maximum depth of the parser's stack, or 0 if
+	// there is no limit.
+	max_stack_depth int
+
+	// max_forest_size is the maximum size of the graph-structured stack and
+	// of the packed parse forest in GLR mode, or 0 if there is no limit.
+	max_forest_size int
+
+	// new_tracer returns the tracer of each new parser, if any.
+	new_tracer func() TracerOf[K]
 }
 
+// Builder is the builder of the string-based API.
+type Builder = BuilderOf[string]
+
 // Reset implements common.Resetter.
-func (b *Builder) Reset() error {
+func (b *BuilderOf[K]) Reset() error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
 
+	var zero K
+
 	b.parse_one_fn = nil
+	b.table = nil
+	b.eof = zero
+	b.error_ = zero
+	b.has_kinds = false
+	b.recovery = false
+	b.repair = false
+	b.glr = false
+	b.max_stack_depth = 0
+	b.max_forest_size = 0
+	b.new_tracer = nil
 
 	return nil
 }
@@ -47,7 +95,7 @@ func (b *Builder) Reset() error {
 // Errors:
 //   - common.ErrNilReceiver: If the receiver is nil.
 //   - common.ErrBadParam: If the parameter is nil.
-func (b *Builder) SetParseOneFn(fn ParseOneFn) error {
+func (b *BuilderOf[K]) SetParseOneFn(fn ParseOneFnOf[K]) error {
 	if b == nil {
 		return common.ErrNilReceiver
 	}
@@ -62,15 +110,265 @@ func (b *Builder) SetParseOneFn(fn ParseOneFn) error {
 	return nil
 }
 
-// Build creates a new parser using the values set on the builder.
+// SetTable sets the parsing table used by the parser.
+//
+// When a table is set, the parser is table-driven and the parsing function,
+// if any, is ignored; and so are the kinds set by SetKinds, as the table has
+// its own.
+//
+// Parameters:
+//   - table: The new parsing table. Must not be nil.
+//
+// Returns:
+//   - error: An error if the receiver is nil or if the parameter is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If the parameter is nil.
+func (b *BuilderOf[K]) SetTable(table *TableOf[K]) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if table == nil {
+		err := common.NewErrNilParam("table")
+		return err
+	}
+
+	b.table = table
+
+	return nil
+}
+
+// SetKinds sets the kinds of the end-of-input token, which the parser appends
+// to the input, and of the error pseudo-terminal; for parsers driven by a
+// parsing function. If the tokens are of the string-based API, they default
+// to EtEOF and EtError; otherwise, they must be set.
+//
+// Parameters:
+//   - eof: The kind of the end-of-input token.
+//   - error_: The kind of the error pseudo-terminal.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetKinds(eof, error_ K) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	b.eof = eof
+	b.error_ = error_
+	b.has_kinds = true
+
+	return nil
+}
+
+// SetRecovery sets whether the parser recovers from errors.
+//
+// A recovering parser does not stop at the first error. Instead, it pops
+// states until one can shift the EtError pseudo-terminal, shifts an EtError
+// token holding the discarded tokens, and discards the input until a token
+// that is acceptable in the new state; like yacc does. Parse then returns the
+// partial forest along with every error that was recovered from. This only
+// works for table-driven parsers whose grammar uses EtError in its rules.
+//
+// Parameters:
+//   - recovery: Whether the parser recovers from errors.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetRecovery(recovery bool) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	b.recovery = recovery
+
+	return nil
+}
+
+// SetRepair sets whether the parser repairs the input on errors.
+//
+// On an error, a repairing parser searches the cheapest sequence of token
+// insertions, deletions and substitutions, up to MaxRepairCost edits, after
+// which it can shift RepairShifts more tokens; in the spirit of Burke-Fisher
+// and CPCT+. It then applies the repair, records it in the error (see
+// ParseError.Repairs) and continues. If no repair is found, the parser falls
+// back to panic mode if recovery is enabled (see SetRecovery), or stops
+// otherwise. Parse then returns the forest along with every error that was
+// repaired. This only works for table-driven parsers.
+//
+// Parameters:
+//   - repair: Whether the parser repairs the input on errors.
 //
 // Returns:
-//   - *Parser: The newly created parser. Never returns nil.
-func (b Builder) Build() *Parser {
-	var fn ParseOneFn
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetRepair(repair bool) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	b.repair = repair
+
+	return nil
+}
+
+// SetGLR sets whether the parser runs in GLR mode.
+//
+// In GLR mode, a table-driven parser forks its stack on every conflict of the
+// table instead of resolving it, and yields every parse tree of the input as
+// a shared packed parse forest. See ParseForest and Parser.GetPackedForest.
+// Recovery and repair are not available in GLR mode.
+//
+// Parameters:
+//   - glr: Whether the parser runs in GLR mode.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetGLR(glr bool) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	b.glr = glr
+
+	return nil
+}
+
+// SetMaxStackDepth sets the maximum depth of the parser's stack. Beyond it,
+// the parser fails with a *common.ErrLimitExceeded error. In GLR mode, it is
+// the maximum number of edges of the paths of the graph-structured stack.
+//
+// Parameters:
+//   - depth: The maximum depth, or 0 for no limit. Must not be negative.
+//
+// Returns:
+//   - error: An error if the receiver is nil or if the parameter is negative.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If the parameter is negative.
+func (b *BuilderOf[K]) SetMaxStackDepth(depth int) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if depth < 0 {
+		err := common.NewErrBadParam("depth", "must not be negative")
+		return err
+	}
+
+	b.max_stack_depth = depth
+
+	return nil
+}
+
+// SetMaxForestSize sets the maximum size of the graph-structured stack and of
+// the packed parse forest of a GLR parser; that is, the number of their nodes
+// plus the number of their edges, where every derivation of a forest node
+// counts as an edge. Beyond it, the parser fails with a
+// *common.ErrLimitExceeded error. The limit only applies in GLR mode, where
+// highly ambiguous inputs make both structures grow quickly.
+//
+// Parameters:
+//   - size: The maximum size, or 0 for no limit. Must not be negative.
+//
+// Returns:
+//   - error: An error if the receiver is nil or if the parameter is negative.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If the parameter is negative.
+func (b *BuilderOf[K]) SetMaxForestSize(size int) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if size < 0 {
+		err := common.NewErrBadParam("size", "must not be negative")
+		return err
+	}
+
+	b.max_forest_size = size
+
+	return nil
+}
+
+// SetTracer sets the tracer that receives every step of the parsing
+// processes of the parser, whether it is driven by a table or by a parsing
+// function; see NewTraceTracer. GLR parsers are not traced.
+//
+// The tracer is shared by every parser of the compiled definition; so, if
+// they run concurrently, it must be safe for concurrent use and it receives
+// their events interleaved. Use SetTracerFunc to give each parser its own.
+//
+// Parameters:
+//   - tracer: The tracer. If nil, the parser is not traced.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetTracer(tracer TracerOf[K]) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	if tracer == nil {
+		b.new_tracer = nil
+	} else {
+		b.new_tracer = func() TracerOf[K] { return tracer }
+	}
+
+	return nil
+}
+
+// SetTracerFunc is like SetTracer, but the given function is called by every
+// new parser of the compiled definition to create its own tracer; so that
+// parsers that run concurrently do not share a tracer.
+//
+// Parameters:
+//   - fn: The function that creates the tracer of a new parser. If nil, or if
+//     it returns nil, the parser is not traced.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+func (b *BuilderOf[K]) SetTracerFunc(fn func() TracerOf[K]) error {
+	if b == nil {
+		return common.ErrNilReceiver
+	}
+
+	b.new_tracer = fn
+
+	return nil
+}
+
+// Compile creates a new definition using the values set on the builder. See
+// Definition.
+//
+// Returns:
+//   - *DefinitionOf[K]: The newly created definition. Never returns nil.
+func (b BuilderOf[K]) Compile() *DefinitionOf[K] {
+	var fn ParseOneFnOf[K]
 
 	if b.parse_one_fn == nil {
-		fn = func(_ *Parser) (Action, error) {
+		fn = func(_ *ParserOf[K]) (Action, error) {
 			err := errors.New("no parsing function provided")
 			return nil, err
 		}
@@ -78,13 +376,38 @@ func (b Builder) Build() *Parser {
 		fn = b.parse_one_fn
 	}
 
-	stack, err := lls.RefusableOf(new(lls.ArrayStack[*slgr.Token]))
-	assert.Err(err, "lls.RefusableOf(new(lls.ArrayStack[*slgr.Token]))")
+	eof, error_, has_kinds := b.eof, b.error_, b.has_kinds
 
-	parser := &Parser{
+	if b.table != nil {
+		eof, error_, has_kinds = b.table.eof, b.table.error_, true
+	} else if !has_kinds {
+		eof, has_kinds = any(EtEOF).(K)
+		error_, _ = any(EtError).(K)
+	}
+
+	def := &DefinitionOf[K]{
 		parse_one_fn: fn,
-		stack:        stack,
+		table:        b.table,
+		eof:          eof,
+		error_:       error_,
+		has_kinds:    has_kinds,
+		recovery:     b.recovery,
+		repair:       b.repair,
+		glr:          b.glr,
+
+		max_stack_depth: b.max_stack_depth,
+		max_forest_size: b.max_forest_size,
+		new_tracer:      b.new_tracer,
 	}
 
+	return def
+}
+
+// Build creates a new parser using the values set on the builder.
+//
+// Returns:
+//   - *ParserOf[K]: The newly created parser. Never returns nil.
+func (b BuilderOf[K]) Build() *ParserOf[K] {
+	parser := b.Compile().NewParser()
 	return parser
 }
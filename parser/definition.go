@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"context"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	assert "github.com/PlayerR9/go-verify"
+	lls "github.com/PlayerR9/mygo-data/stack"
+)
+
+// DefinitionOf is the compiled configuration of a parser. Unlike a Parser, it
+// holds no state of a parsing process and it cannot be modified; so a single
+// definition, and its table, can be shared across goroutines, each parsing
+// with its own parser spawned by NewParser.
+//
+// The parsing function must then be safe for concurrent use; which it is as
+// long as it only works on the given parser. So must be the tracer set by
+// Builder.SetTracer, if any, which receives the events of every parser of the
+// definition; unlike the tracers created by the function of
+// Builder.SetTracerFunc, one per parser.
+type DefinitionOf[K slgr.Kind] struct {
+	// parse_one_fn is the function used to parse the input tokens.
+	parse_one_fn ParseOneFnOf[K]
+
+	// table is the parsing table used to parse the input tokens, if any.
+	table *TableOf[K]
+
+	// eof is the kind of the end-of-input token.
+	eof K
+
+	// error_ is the kind of the error pseudo-terminal.
+	error_ K
+
+	// has_kinds is true if eof and error_ are known.
+	has_kinds bool
+
+	// recovery is true if the parser recovers from errors in panic mode.
+	recovery bool
+
+	// repair is true if the parser repairs the input on errors.
+	repair bool
+
+	// glr is true if the parser runs in GLR mode.
+	glr bool
+
+	// max_stack_depth is the maximum depth of the parser's stack, or 0 if
+	// there is no limit.
+	max_stack_depth int
+
+	// max_forest_size is the maximum size of the graph-structured stack and
+	// of the packed parse forest in GLR mode, or 0 if there is no limit.
+	max_forest_size int
+
+	// new_tracer returns the tracer of each new parser, if any.
+	new_tracer func() TracerOf[K]
+}
+
+// Definition is the definition of the string-based API.
+type Definition = DefinitionOf[string]
+
+// NewParser creates a new parser, with no input stream, from the definition.
+// The parser is not safe for concurrent use, but it is independent from the
+// other parsers of the definition.
+//
+// Returns:
+//   - *ParserOf[K]: The new parser. Never returns nil.
+func (d DefinitionOf[K]) NewParser() *ParserOf[K] {
+	stack, err := lls.RefusableOf(new(lls.ArrayStack[*slgr.TokenOf[K]]))
+	assert.Err(err, "lls.RefusableOf(new(lls.ArrayStack[*slgr.TokenOf[K]]))")
+
+	parser := &ParserOf[K]{
+		parse_one_fn: d.parse_one_fn,
+		table:        d.table,
+		eof:          d.eof,
+		error_:       d.error_,
+		has_kinds:    d.has_kinds,
+		stack:        stack,
+		recovery:     d.recovery,
+		repair:       d.repair,
+		glr:          d.glr,
+
+		max_stack_depth: d.max_stack_depth,
+		max_forest_size: d.max_forest_size,
+	}
+
+	if d.new_tracer != nil {
+		parser.tracer = d.new_tracer()
+	}
+
+	return parser
+}
+
+// Parse parses the tokens with a new parser of the definition. It is safe to
+// call concurrently. See Parse.
+//
+// Parameters:
+//   - tokens: The list of tokens to be used as the input stream.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails.
+func (d DefinitionOf[K]) Parse(tokens []*slgr.TokenOf[K]) ([]*slgr.TokenOf[K], error) {
+	forest, err := ParseFromContext(context.Background(), d.NewParser(), NewSliceSource(tokens))
+	return forest, err
+}
+
+// ParseContext is like Parse, but it stops as soon as the context is done.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - tokens: The list of tokens to be used as the input stream.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails or if the context is done.
+func (d DefinitionOf[K]) ParseContext(ctx context.Context, tokens []*slgr.TokenOf[K]) ([]*slgr.TokenOf[K], error) {
+	forest, err := ParseFromContext(ctx, d.NewParser(), NewSliceSource(tokens))
+	return forest, err
+}
+
+// ParseFrom parses the tokens pulled from the given source with a new parser
+// of the definition. It is safe to call concurrently, as long as each call
+// has its own source. See ParseFrom.
+//
+// Parameters:
+//   - source: The source of tokens.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails, or if the source fails.
+func (d DefinitionOf[K]) ParseFrom(source TokenSourceOf[K]) ([]*slgr.TokenOf[K], error) {
+	forest, err := ParseFromContext(context.Background(), d.NewParser(), source)
+	return forest, err
+}
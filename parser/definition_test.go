@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// sumInput returns the tokens of a sum of n numbers, whose last one is
+// parenthesized if n is odd.
+func sumInput(n int) []*slgr.Token {
+	data := []string{"1"}
+
+	for i := 2; i <= n; i++ {
+		data = append(data, "+", strconv.Itoa(i))
+	}
+
+	if n%2 == 1 {
+		data = append(data[:len(data)-1], "(", data[len(data)-1], ")")
+	}
+
+	return numTokens(data...)
+}
+
+// TestDefinitionConcurrentSessions runs many parsing sessions at the same time
+// from definitions shared across goroutines. Run it with -race.
+func TestDefinitionConcurrentSessions(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetRepair(true)
+
+	def := builder.Compile()
+
+	_ = builder.SetRepair(false)
+	_ = builder.SetGLR(true)
+
+	glr_def := builder.Compile()
+
+	const sessions = 64
+
+	want := make([]string, sessions)
+
+	for i := range sessions {
+		want[i] = shape(mustParse(t, table, sumInput(i+1)))
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make([]string, sessions)
+
+	for i := range sessions {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			n := i + 1
+
+			forest, err := def.Parse(sumInput(n))
+			if err != nil || len(forest) != 1 || shape(forest[0]) != want[i] {
+				errs[i] = "Parse() = " + strconv.Quote(strings.Join(shapes(forest), " ")) + ", " + errString(err)
+				return
+			}
+
+			src := &expectingSource{
+				countingSource: countingSource{tokens: sumInput(n)},
+			}
+
+			forest, err = def.ParseFrom(src)
+			if err != nil || len(forest) != 1 || shape(forest[0]) != want[i] {
+				errs[i] = "ParseFrom() failed: " + errString(err)
+				return
+			}
+
+			// An invalid input is repaired.
+			_, err = def.Parse(append(sumInput(n), numTokens("+")...))
+			if len(ParseErrors(err)) != 1 {
+				errs[i] = "Parse() of an invalid input returned " + errString(err)
+				return
+			}
+
+			value, err := ParseValue(def.NewParser(), sumInput(n), sumActions(t))
+			if err != nil || value != n*(n+1)/2 {
+				errs[i] = "ParseValue() = " + strconv.Itoa(value) + ", " + errString(err)
+				return
+			}
+
+			packed, err := ParseForest(glr_def.NewParser(), sumInput(n))
+			if err != nil {
+				errs[i] = "ParseForest() failed: " + errString(err)
+				return
+			}
+
+			tree, err := packed.Tree()
+			if err != nil || shape(tree) != want[i] {
+				errs[i] = "Tree() failed: " + errString(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != "" {
+			t.Errorf("session #%d: %s", i, err)
+		}
+	}
+}
+
+// TestDefinitionConcurrentTracers runs many traced parsing sessions at the
+// same time from shared definitions; with a tracer per parser, and with a
+// single shared tracer. Run it with -race.
+func TestDefinitionConcurrentTracers(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	const sessions = 32
+
+	var mu sync.Mutex
+
+	var bufs []*bytes.Buffer
+
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetTracerFunc(func() Tracer {
+		buf := new(bytes.Buffer)
+
+		mu.Lock()
+		bufs = append(bufs, buf)
+		mu.Unlock()
+
+		tracer, err := NewTraceTracer(buf)
+		if err != nil {
+			panic(err)
+		}
+
+		return tracer
+	})
+
+	own_def := builder.Compile()
+
+	var shared bytes.Buffer
+
+	tracer, err := NewTraceTracer(&shared)
+	if err != nil {
+		t.Fatalf("NewTraceTracer() returned an error: %v", err)
+	}
+
+	_ = builder.SetTracer(tracer)
+
+	shared_def := builder.Compile()
+
+	var wg sync.WaitGroup
+
+	errs := make([]string, sessions)
+
+	for i := range sessions {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for _, def := range []*Definition{own_def, shared_def} {
+				_, err := def.Parse(sumInput(i + 1))
+				if err != nil {
+					errs[i] = "Parse() returned " + errString(err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != "" {
+			t.Errorf("session #%d: %s", i, err)
+		}
+	}
+
+	if len(bufs) != sessions {
+		t.Fatalf("%d tracers were created, want %d", len(bufs), sessions)
+	}
+
+	for i, buf := range bufs {
+		lines := traceLines(buf)
+
+		if !strings.HasPrefix(lines[0], "step") || !strings.HasSuffix(lines[len(lines)-1], "accept") {
+			t.Errorf("tracer #%d wrote %q, want a whole trace table", i, buf.String())
+		}
+	}
+
+	if got := strings.Count(shared.String(), "accept\n"); got != sessions {
+		t.Errorf("the shared tracer traced %d accepts, want %d", got, sessions)
+	}
+}
+
+// errString returns the message of the given error, or "<nil>".
+func errString(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+
+	return err.Error()
+}
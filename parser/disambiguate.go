@@ -0,0 +1,776 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// Associativity is the associativity of a group of rules.
+type Associativity int
+
+const (
+	// LeftAssoc forbids a rule of the group as the last child of a rule of the
+	// group; so "a + b + c" is read as "(a + b) + c".
+	LeftAssoc Associativity = iota
+
+	// RightAssoc forbids a rule of the group as the first child of a rule of
+	// the group; so "a ^ b ^ c" is read as "a ^ (b ^ c)".
+	RightAssoc
+
+	// NonAssoc forbids a rule of the group as either the first or the last
+	// child of a rule of the group; so "a == b == c" is rejected.
+	NonAssoc
+)
+
+// String implements fmt.Stringer.
+func (a Associativity) String() string {
+	switch a {
+	case LeftAssoc:
+		return "left"
+	case RightAssoc:
+		return "right"
+	case NonAssoc:
+		return "non-assoc"
+	default:
+		return "Associativity(" + strconv.Itoa(int(a)) + ")"
+	}
+}
+
+// ruleSet is a set of rules.
+type ruleSet[K slgr.Kind] map[*slgr.RuleOf[K]]struct{}
+
+// has checks whether the set contains the given rule.
+//
+// Parameters:
+//   - rule: The rule to check.
+//
+// Returns:
+//   - bool: True if the set contains the rule, false otherwise.
+func (s ruleSet[K]) has(rule *slgr.RuleOf[K]) bool {
+	_, ok := s[rule]
+	return ok
+}
+
+// DisambiguatorOf is a set of declarative filters that remove unwanted parse
+// trees from a packed parse forest. Rules are identified by pointer; hence,
+// the filters must be given the same rules the table was built from.
+//
+// The zero value is a disambiguator without filters.
+type DisambiguatorOf[K slgr.Kind] struct {
+	// lower maps a rule to the rules that cannot be its direct children.
+	lower map[*slgr.RuleOf[K]]ruleSet[K]
+
+	// not_first maps a rule to the rules that cannot be its first child.
+	not_first map[*slgr.RuleOf[K]]ruleSet[K]
+
+	// not_last maps a rule to the rules that cannot be its last child.
+	not_last map[*slgr.RuleOf[K]]ruleSet[K]
+
+	// prefer is the set of preferred rules.
+	prefer ruleSet[K]
+
+	// avoid is the set of avoided rules.
+	avoid ruleSet[K]
+
+	// reject is the set of reject rules.
+	reject ruleSet[K]
+}
+
+// Disambiguator is the disambiguator of the string-based API.
+type Disambiguator = DisambiguatorOf[string]
+
+// checkRules checks that none of the given rules is nil.
+//
+// Parameters:
+//   - name: The name of the parameter.
+//   - rules: The rules to check.
+//
+// Returns:
+//   - error: An error if a rule is nil.
+func checkRules[K slgr.Kind](name string, rules []*slgr.RuleOf[K]) error {
+	for i, rule := range rules {
+		if rule == nil {
+			err := common.NewErrNilParam(name + "[" + strconv.Itoa(i) + "]")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addTo adds the given rules to the set of the given rule.
+//
+// Parameters:
+//   - m: The map of sets. (Assumed to not be nil)
+//   - rule: The rule whose set is extended.
+//   - rules: The rules to add.
+func addTo[K slgr.Kind](m map[*slgr.RuleOf[K]]ruleSet[K], rule *slgr.RuleOf[K], rules ...*slgr.RuleOf[K]) {
+	set, ok := m[rule]
+	if !ok {
+		set = make(ruleSet[K], len(rules))
+		m[rule] = set
+	}
+
+	for _, r := range rules {
+		set[r] = struct{}{}
+	}
+}
+
+// AddPriority declares that the higher rule binds tighter than the lower one;
+// that is, a tree derived by the lower rule cannot be a direct child of a tree
+// derived by the higher rule. For instance, given "E = E times E ." higher
+// than "E = E plus E .", "a + b * c" can only be read as "a + (b * c)".
+//
+// Parameters:
+//   - higher: The rule with the higher priority.
+//   - lower: The rules with a lower priority.
+//
+// Returns:
+//   - error: An error if the receiver or a rule is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If a rule is nil.
+func (d *DisambiguatorOf[K]) AddPriority(higher *slgr.RuleOf[K], lower ...*slgr.RuleOf[K]) error {
+	if d == nil {
+		return common.ErrNilReceiver
+	}
+
+	if higher == nil {
+		err := common.NewErrNilParam("higher")
+		return err
+	}
+
+	err := checkRules("lower", lower)
+	if err != nil {
+		return err
+	}
+
+	if d.lower == nil {
+		d.lower = make(map[*slgr.RuleOf[K]]ruleSet[K])
+	}
+
+	addTo(d.lower, higher, lower...)
+
+	return nil
+}
+
+// AddAssociativity declares the associativity of a group of rules with the
+// same priority.
+//
+// Parameters:
+//   - assoc: The associativity of the group.
+//   - rules: The rules of the group.
+//
+// Returns:
+//   - error: An error if the receiver or a rule is nil, or if the
+//     associativity is not valid.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If a rule is nil or the associativity is not valid.
+func (d *DisambiguatorOf[K]) AddAssociativity(assoc Associativity, rules ...*slgr.RuleOf[K]) error {
+	if d == nil {
+		return common.ErrNilReceiver
+	}
+
+	err := checkRules("rules", rules)
+	if err != nil {
+		return err
+	}
+
+	var first, last bool
+
+	switch assoc {
+	case LeftAssoc:
+		last = true
+	case RightAssoc:
+		first = true
+	case NonAssoc:
+		first, last = true, true
+	default:
+		err := common.NewErrBadParam("assoc", "is not valid")
+		return err
+	}
+
+	if d.not_first == nil {
+		d.not_first = make(map[*slgr.RuleOf[K]]ruleSet[K])
+	}
+
+	if d.not_last == nil {
+		d.not_last = make(map[*slgr.RuleOf[K]]ruleSet[K])
+	}
+
+	for _, rule := range rules {
+		if first {
+			addTo(d.not_first, rule, rules...)
+		}
+
+		if last {
+			addTo(d.not_last, rule, rules...)
+		}
+	}
+
+	return nil
+}
+
+// addAll adds the given rules to the given set.
+//
+// Parameters:
+//   - set: The set to extend.
+//   - name: The name of the parameter.
+//   - rules: The rules to add.
+//
+// Returns:
+//   - ruleSet[K]: The extended set.
+//   - error: An error if a rule is nil.
+func addAll[K slgr.Kind](set ruleSet[K], name string, rules []*slgr.RuleOf[K]) (ruleSet[K], error) {
+	err := checkRules(name, rules)
+	if err != nil {
+		return set, err
+	}
+
+	if set == nil {
+		set = make(ruleSet[K], len(rules))
+	}
+
+	for _, rule := range rules {
+		set[rule] = struct{}{}
+	}
+
+	return set, nil
+}
+
+// AddPrefer declares rules as preferred: whenever a node is ambiguous and at
+// least one of its derivations uses a preferred rule, the other derivations
+// are removed.
+//
+// Parameters:
+//   - rules: The preferred rules.
+//
+// Returns:
+//   - error: An error if the receiver or a rule is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If a rule is nil.
+func (d *DisambiguatorOf[K]) AddPrefer(rules ...*slgr.RuleOf[K]) error {
+	if d == nil {
+		return common.ErrNilReceiver
+	}
+
+	set, err := addAll(d.prefer, "rules", rules)
+	if err != nil {
+		return err
+	}
+
+	d.prefer = set
+
+	return nil
+}
+
+// AddAvoid declares rules as avoided: whenever a node is ambiguous, the
+// derivations that use an avoided rule are removed unless every derivation
+// does.
+//
+// Parameters:
+//   - rules: The avoided rules.
+//
+// Returns:
+//   - error: An error if the receiver or a rule is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If a rule is nil.
+func (d *DisambiguatorOf[K]) AddAvoid(rules ...*slgr.RuleOf[K]) error {
+	if d == nil {
+		return common.ErrNilReceiver
+	}
+
+	set, err := addAll(d.avoid, "rules", rules)
+	if err != nil {
+		return err
+	}
+
+	d.avoid = set
+
+	return nil
+}
+
+// AddReject declares reject rules: a node that can be derived by a reject rule
+// is removed altogether, with every other derivation of the same symbol over
+// the same span. For instance, "Identifier = kw_if ." as a reject rule
+// prevents keywords from being read as identifiers.
+//
+// Parameters:
+//   - rules: The reject rules.
+//
+// Returns:
+//   - error: An error if the receiver or a rule is nil.
+//
+// Errors:
+//   - common.ErrNilReceiver: If the receiver is nil.
+//   - common.ErrBadParam: If a rule is nil.
+func (d *DisambiguatorOf[K]) AddReject(rules ...*slgr.RuleOf[K]) error {
+	if d == nil {
+		return common.ErrNilReceiver
+	}
+
+	set, err := addAll(d.reject, "rules", rules)
+	if err != nil {
+		return err
+	}
+
+	d.reject = set
+
+	return nil
+}
+
+// filterer holds the state of a filtering pass.
+type filterer[K slgr.Kind] struct {
+	// d is the disambiguator.
+	d *DisambiguatorOf[K]
+
+	// filtered maps a node to its filtered copy; nil if it was removed.
+	filtered map[*ForestNodeOf[K]]*ForestNodeOf[K]
+
+	// restricted maps a node and a set of forbidden rules to the restricted
+	// copy of the node; nil if it was removed.
+	restricted map[string]*ForestNodeOf[K]
+
+	// ids maps a node to a unique identifier.
+	ids map[*ForestNodeOf[K]]int
+}
+
+// forbidden returns the rules that cannot derive the given child of a family.
+//
+// Parameters:
+//   - rule: The rule of the family.
+//   - idx: The index of the child.
+//   - size: The number of children of the family.
+//
+// Returns:
+//   - ruleSet[K]: The forbidden rules, or nil if there are none.
+func (f *filterer[K]) forbidden(rule *slgr.RuleOf[K], idx, size int) ruleSet[K] {
+	var set ruleSet[K]
+
+	merge := func(other ruleSet[K]) {
+		if len(other) == 0 {
+			return
+		}
+
+		if set == nil {
+			set = make(ruleSet[K], len(other))
+		}
+
+		for r := range other {
+			set[r] = struct{}{}
+		}
+	}
+
+	merge(f.d.lower[rule])
+
+	if idx == 0 {
+		merge(f.d.not_first[rule])
+	}
+
+	if idx == size-1 {
+		merge(f.d.not_last[rule])
+	}
+
+	return set
+}
+
+// id returns the unique identifier of the given node.
+//
+// Parameters:
+//   - n: The node.
+//
+// Returns:
+//   - int: The identifier.
+func (f *filterer[K]) id(n *ForestNodeOf[K]) int {
+	id, ok := f.ids[n]
+	if !ok {
+		id = len(f.ids)
+		f.ids[n] = id
+	}
+
+	return id
+}
+
+// restrict returns a copy of the given (filtered) node without the
+// derivations that use one of the given rules.
+//
+// Parameters:
+//   - n: The node. (Assumed to not be nil)
+//   - set: The forbidden rules. (Assumed to not be empty)
+//
+// Returns:
+//   - *ForestNodeOf[K]: The restricted node, or nil if no derivation is left.
+func (f *filterer[K]) restrict(n *ForestNodeOf[K], set ruleSet[K]) *ForestNodeOf[K] {
+	if n.Token != nil {
+		return n
+	}
+
+	var removed []string
+
+	for i, fam := range n.Families {
+		if set.has(fam.Rule) {
+			removed = append(removed, strconv.Itoa(i))
+		}
+	}
+
+	if len(removed) == 0 {
+		return n
+	}
+
+	key := strconv.Itoa(f.id(n)) + "|" + strings.Join(removed, ",")
+
+	res, ok := f.restricted[key]
+	if ok {
+		return res
+	}
+
+	var families []*FamilyOf[K]
+
+	for _, fam := range n.Families {
+		if !set.has(fam.Rule) {
+			families = append(families, fam)
+		}
+	}
+
+	if len(families) > 0 {
+		res = &ForestNodeOf[K]{
+			Symbol:   n.Symbol,
+			Start:    n.Start,
+			End:      n.End,
+			Families: families,
+		}
+	}
+
+	f.restricted[key] = res
+
+	return res
+}
+
+// filter returns the filtered copy of the given node.
+//
+// Parameters:
+//   - n: The node. (Assumed to not be nil)
+//
+// Returns:
+//   - *ForestNodeOf[K]: The filtered node, or nil if no derivation is left.
+func (f *filterer[K]) filter(n *ForestNodeOf[K]) *ForestNodeOf[K] {
+	if n.Token != nil {
+		return n
+	}
+
+	res, ok := f.filtered[n]
+	if ok {
+		return res
+	}
+
+	// Guards against cycles: a node that is being filtered is removed from
+	// the derivations that loop back to it.
+	f.filtered[n] = nil
+
+	for _, fam := range n.Families {
+		if f.d.reject.has(fam.Rule) {
+			return nil
+		}
+	}
+
+	var families []*FamilyOf[K]
+
+	for _, fam := range n.Families {
+		children := make([]*ForestNodeOf[K], 0, len(fam.Children))
+
+		for i, child := range fam.Children {
+			c := f.filter(child)
+
+			if c != nil {
+				set := f.forbidden(fam.Rule, i, len(fam.Children))
+				if len(set) > 0 {
+					c = f.restrict(c, set)
+				}
+			}
+
+			if c == nil {
+				children = nil
+				break
+			}
+
+			children = append(children, c)
+		}
+
+		if children == nil && len(fam.Children) > 0 {
+			continue
+		}
+
+		families = append(families, &FamilyOf[K]{
+			Rule:     fam.Rule,
+			Children: children,
+		})
+	}
+
+	families = f.preferences(families)
+
+	if len(families) > 0 {
+		res = &ForestNodeOf[K]{
+			Symbol:   n.Symbol,
+			Start:    n.Start,
+			End:      n.End,
+			Families: families,
+		}
+	}
+
+	f.filtered[n] = res
+
+	return res
+}
+
+// preferences applies the prefer and avoid filters to the derivations of a
+// node.
+//
+// Parameters:
+//   - families: The derivations of the node.
+//
+// Returns:
+//   - []*FamilyOf[K]: The remaining derivations.
+func (f *filterer[K]) preferences(families []*FamilyOf[K]) []*FamilyOf[K] {
+	if len(families) < 2 {
+		return families
+	}
+
+	var preferred []*FamilyOf[K]
+
+	for _, fam := range families {
+		if f.d.prefer.has(fam.Rule) {
+			preferred = append(preferred, fam)
+		}
+	}
+
+	if len(preferred) > 0 {
+		families = preferred
+	}
+
+	var kept []*FamilyOf[K]
+
+	for _, fam := range families {
+		if !f.d.avoid.has(fam.Rule) {
+			kept = append(kept, fam)
+		}
+	}
+
+	if len(kept) > 0 {
+		families = kept
+	}
+
+	return families
+}
+
+// Apply applies the filters to the given forest. The forest is not modified;
+// filtered nodes are copies.
+//
+// Parameters:
+//   - forest: The forest to filter.
+//
+// Returns:
+//   - *ForestOf[K]: The filtered forest.
+//   - error: An error if every parse tree is removed by the filters.
+//
+// Errors:
+//   - common.ErrBadParam: If the forest is nil.
+//   - error: If no parse tree survives the filters.
+func (d DisambiguatorOf[K]) Apply(forest *ForestOf[K]) (*ForestOf[K], error) {
+	if forest == nil {
+		err := common.NewErrNilParam("forest")
+		return nil, err
+	}
+
+	f := &filterer[K]{
+		d:          &d,
+		filtered:   make(map[*ForestNodeOf[K]]*ForestNodeOf[K]),
+		restricted: make(map[string]*ForestNodeOf[K]),
+		ids:        make(map[*ForestNodeOf[K]]int),
+	}
+
+	root := f.filter(forest.root)
+	if root == nil {
+		return nil, errors.New("no parse tree survives the filters")
+	}
+
+	res := &ForestOf[K]{
+		root: root,
+	}
+
+	return res, nil
+}
+
+// AmbiguityOf is an ambiguous span of the input that the filters could not
+// resolve.
+type AmbiguityOf[K slgr.Kind] struct {
+	// Node is the ambiguous node of the forest.
+	Node *ForestNodeOf[K]
+
+	// Interpretations is one parse tree per derivation of the node.
+	Interpretations []*slgr.TokenOf[K]
+}
+
+// Ambiguity is the ambiguity of the string-based API.
+type Ambiguity = AmbiguityOf[string]
+
+// firstTree returns the parse tree of the given node that always takes the
+// first derivation.
+//
+// Parameters:
+//   - n: The node. (Assumed to not be nil)
+//   - visiting: The nodes that are being expanded.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The parse tree.
+func firstTree[K slgr.Kind](n *ForestNodeOf[K], visiting map[*ForestNodeOf[K]]bool) *slgr.TokenOf[K] {
+	if n.Token != nil {
+		return n.Token
+	}
+
+	tk := slgr.NewToken(n.Symbol, "")
+
+	if visiting[n] || len(n.Families) == 0 {
+		return tk
+	}
+
+	visiting[n] = true
+	defer delete(visiting, n)
+
+	children := make([]*slgr.TokenOf[K], 0, len(n.Families[0].Children))
+
+	for _, child := range n.Families[0].Children {
+		children = append(children, firstTree(child, visiting))
+	}
+
+	err := tk.AppendChildren(children)
+	assert.Err(err, "tk.AppendChildren(children)")
+
+	for _, child := range tk.Children {
+		if child.Pos.IsValid() {
+			tk.Pos = child.Pos
+			break
+		}
+	}
+
+	return tk
+}
+
+// interpretations returns one parse tree per derivation of the given node.
+//
+// Parameters:
+//   - n: The node. (Assumed to not be nil)
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parse trees.
+func interpretations[K slgr.Kind](n *ForestNodeOf[K]) []*slgr.TokenOf[K] {
+	trees := make([]*slgr.TokenOf[K], 0, len(n.Families))
+
+	for _, fam := range n.Families {
+		single := &ForestNodeOf[K]{
+			Symbol:   n.Symbol,
+			Start:    n.Start,
+			End:      n.End,
+			Families: []*FamilyOf[K]{fam},
+		}
+
+		trees = append(trees, firstTree(single, make(map[*ForestNodeOf[K]]bool)))
+	}
+
+	return trees
+}
+
+// AmbiguityErrorOf occurs when a forest still has more than one parse tree after
+// disambiguation.
+type AmbiguityErrorOf[K slgr.Kind] struct {
+	// Ambiguities is the ambiguous spans, in depth-first order.
+	Ambiguities []*AmbiguityOf[K]
+}
+
+// AmbiguityError is the ambiguity error of the string-based API.
+type AmbiguityError = AmbiguityErrorOf[string]
+
+// Error implements error.
+//
+// Format:
+//
+//	"<n> ambiguous span(s):
+//	<symbol> [<start>, <end>) has <m> interpretations:
+//	#1:
+//	<tree>
+//	..."
+func (e AmbiguityErrorOf[K]) Error() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(strconv.Itoa(len(e.Ambiguities)))
+	_, _ = builder.WriteString(" ambiguous span(s):")
+
+	for _, a := range e.Ambiguities {
+		_, _ = builder.WriteRune('\n')
+		_, _ = builder.WriteString(a.Node.String())
+		_, _ = builder.WriteString(" has ")
+		_, _ = builder.WriteString(strconv.Itoa(len(a.Interpretations)))
+		_, _ = builder.WriteString(" interpretations:")
+
+		for i, tree := range a.Interpretations {
+			_, _ = builder.WriteString("\n#")
+			_, _ = builder.WriteString(strconv.Itoa(i + 1))
+			_, _ = builder.WriteString(":\n")
+			_, _ = builder.WriteString(strings.TrimSuffix(slgr.TreeToString(tree), "\n"))
+		}
+	}
+
+	str := builder.String()
+	return str
+}
+
+// Resolve applies the filters to the given forest and returns its single parse
+// tree.
+//
+// Parameters:
+//   - forest: The forest to resolve.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The parse tree, or nil if the forest cannot be resolved.
+//   - error: An error if the forest cannot be resolved.
+//
+// Errors:
+//   - common.ErrBadParam: If the forest is nil.
+//   - *AmbiguityErrorOf[K]: If more than one parse tree survives the filters.
+//   - error: If no parse tree survives the filters.
+func (d DisambiguatorOf[K]) Resolve(forest *ForestOf[K]) (*slgr.TokenOf[K], error) {
+	filtered, err := d.Apply(forest)
+	if err != nil {
+		return nil, err
+	}
+
+	ambiguous := filtered.Ambiguities()
+	if len(ambiguous) == 0 {
+		tree := firstTree(filtered.root, make(map[*ForestNodeOf[K]]bool))
+		return tree, nil
+	}
+
+	e := &AmbiguityErrorOf[K]{
+		Ambiguities: make([]*AmbiguityOf[K], 0, len(ambiguous)),
+	}
+
+	for _, n := range ambiguous {
+		e.Ambiguities = append(e.Ambiguities, &AmbiguityOf[K]{
+			Node:            n,
+			Interpretations: interpretations(n),
+		})
+	}
+
+	return nil, e
+}
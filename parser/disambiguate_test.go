@@ -0,0 +1,206 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// mustForest parses the given input with a GLR parser driven by the table of
+// the given rules, or fails the test.
+func mustForest(t *testing.T, rules []*slgr.Rule, types ...string) *Forest {
+	t.Helper()
+
+	forest, err := ParseForest(newGLRParser(mustTable(t, rules...)), newTokens(types...))
+	if err != nil {
+		t.Fatalf("ParseForest() returned an error: %v", err)
+	}
+
+	return forest
+}
+
+func TestDisambiguatorOperators(t *testing.T) {
+	plus := slgr.NewRule("E", "E", "plus", "E")
+	times := slgr.NewRule("E", "E", "times", "E")
+	rules := []*slgr.Rule{plus, times, slgr.NewRule("E", "num")}
+
+	tests := []struct {
+		name  string
+		setup func(d *Disambiguator) error
+		input []string
+		want  string
+	}{
+		{
+			name:  "priority",
+			setup: func(d *Disambiguator) error { return d.AddPriority(times, plus) },
+			input: []string{"num", "plus", "num", "times", "num"},
+			want:  "(E (E num) plus (E (E num) times (E num)))",
+		},
+		{
+			name:  "left associativity",
+			setup: func(d *Disambiguator) error { return d.AddAssociativity(LeftAssoc, plus) },
+			input: []string{"num", "plus", "num", "plus", "num"},
+			want:  "(E (E (E num) plus (E num)) plus (E num))",
+		},
+		{
+			name:  "right associativity",
+			setup: func(d *Disambiguator) error { return d.AddAssociativity(RightAssoc, plus) },
+			input: []string{"num", "plus", "num", "plus", "num"},
+			want:  "(E (E num) plus (E (E num) plus (E num)))",
+		},
+		{
+			name: "associativity within a group",
+			setup: func(d *Disambiguator) error {
+				return d.AddAssociativity(LeftAssoc, plus, times)
+			},
+			input: []string{"num", "times", "num", "plus", "num"},
+			want:  "(E (E (E num) times (E num)) plus (E num))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var d Disambiguator
+
+			err := test.setup(&d)
+			if err != nil {
+				t.Fatalf("setup returned an error: %v", err)
+			}
+
+			tree, err := d.Resolve(mustForest(t, rules, test.input...))
+			if err != nil {
+				t.Fatalf("Resolve() returned an error: %v", err)
+			}
+
+			if got := shape(tree); got != test.want {
+				t.Errorf("Resolve() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDisambiguatorNonAssoc(t *testing.T) {
+	eq := slgr.NewRule("E", "E", "eq", "E")
+	rules := []*slgr.Rule{eq, slgr.NewRule("E", "num")}
+
+	var d Disambiguator
+
+	_ = d.AddAssociativity(NonAssoc, eq)
+
+	_, err := d.Apply(mustForest(t, rules, "num", "eq", "num", "eq", "num"))
+	if err == nil {
+		t.Errorf("Apply() returned no error, want no surviving tree")
+	}
+
+	tree, err := d.Resolve(mustForest(t, rules, "num", "eq", "num"))
+	if err != nil {
+		t.Fatalf("Resolve() returned an error: %v", err)
+	}
+
+	if got, want := shape(tree), "(E (E num) eq (E num))"; got != want {
+		t.Errorf("Resolve() = %s, want %s", got, want)
+	}
+}
+
+func TestDisambiguatorDerivations(t *testing.T) {
+	id_word := slgr.NewRule("Id", "word")
+	id_kw := slgr.NewRule("Id", "kw")
+	s_id := slgr.NewRule("S", "Id")
+	s_kw := slgr.NewRule("S", "Kw")
+
+	rules := []*slgr.Rule{
+		s_id,
+		s_kw,
+		id_word,
+		id_kw,
+		slgr.NewRule("Kw", "kw"),
+	}
+
+	tests := []struct {
+		name  string
+		setup func(d *Disambiguator) error
+		want  string
+	}{
+		{
+			name:  "prefer",
+			setup: func(d *Disambiguator) error { return d.AddPrefer(s_kw) },
+			want:  "(S (Kw kw))",
+		},
+		{
+			name:  "avoid",
+			setup: func(d *Disambiguator) error { return d.AddAvoid(s_kw) },
+			want:  "(S (Id kw))",
+		},
+		{
+			name:  "reject",
+			setup: func(d *Disambiguator) error { return d.AddReject(id_kw) },
+			want:  "(S (Kw kw))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var d Disambiguator
+
+			err := test.setup(&d)
+			if err != nil {
+				t.Fatalf("setup returned an error: %v", err)
+			}
+
+			tree, err := d.Resolve(mustForest(t, rules, "kw"))
+			if err != nil {
+				t.Fatalf("Resolve() returned an error: %v", err)
+			}
+
+			if got := shape(tree); got != test.want {
+				t.Errorf("Resolve() = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestResolveAmbiguous(t *testing.T) {
+	var d Disambiguator
+
+	_, err := d.Resolve(mustForest(t, sumRules, "num", "plus", "num", "plus", "num"))
+
+	var ae *AmbiguityError
+	if !errors.As(err, &ae) {
+		t.Fatalf("Resolve() returned %v, want an *AmbiguityError", err)
+	}
+
+	if len(ae.Ambiguities) != 1 {
+		t.Fatalf("Ambiguities has %d spans, want 1", len(ae.Ambiguities))
+	}
+
+	a := ae.Ambiguities[0]
+
+	if a.Node.Symbol != "E" || a.Node.Start != 0 || a.Node.End != 5 {
+		t.Errorf("Node = %s, want E over [0, 5)", a.Node)
+	}
+
+	if len(a.Interpretations) != 2 {
+		t.Errorf("Interpretations has %d trees, want 2", len(a.Interpretations))
+	}
+}
+
+func TestDisambiguatorNilRules(t *testing.T) {
+	var d Disambiguator
+
+	if err := d.AddPriority(nil); err == nil {
+		t.Errorf("AddPriority(nil) returned no error")
+	}
+
+	if err := d.AddPrefer(nil); err == nil {
+		t.Errorf("AddPrefer(nil) returned no error")
+	}
+
+	if err := d.AddAssociativity(Associativity(-1), slgr.NewRule("E", "num")); err == nil {
+		t.Errorf("AddAssociativity() with an invalid associativity returned no error")
+	}
+
+	if _, err := d.Apply(nil); err == nil {
+		t.Errorf("Apply(nil) returned no error")
+	}
+}
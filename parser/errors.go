@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// ParseErrorOf occurs when the parser encounters a token it cannot accept.
+type ParseErrorOf[K slgr.Kind] struct {
+	// Token is the offending token, or nil if the input ended unexpectedly.
+	Token *slgr.TokenOf[K]
+
+	// State is the state of the parser when the error occurred, or -1 if the
+	// parser is not table-driven.
+	State int
+
+	// Expected is the list of terminals, sorted by name, that would have been
+	// acceptable instead of the offending token.
+	Expected []K
+
+	// Repairs is the edits of the input the parser made to continue after the
+	// error, if any. See Builder.SetRepair.
+	Repairs []*RepairOf[K]
+}
+
+// ParseError is the parse error of the string-based API.
+type ParseError = ParseErrorOf[string]
+
+// Error implements error.
+//
+// Format:
+//
+//	"<pos>: unexpected <type>, expected one of: <expected>; fix: <repairs>"
+//
+// Where:
+//   - <pos>: The position of the offending token. Omitted if not known.
+//   - <type>: The type of the offending token, or "end of input" if there is
+//     no such token.
+//   - <expected>: The comma-separated list of acceptable terminals. If only one
+//     terminal is acceptable, the message reads "expected <expected>" instead
+//     and, if none is, the whole clause is omitted.
+//   - <repairs>: The comma-separated list of repairs. Omitted if there are
+//     none.
+func (e ParseErrorOf[K]) Error() string {
+	var builder strings.Builder
+
+	if e.Token != nil && e.Token.Pos.IsValid() {
+		_, _ = builder.WriteString(e.Token.Pos.String())
+		_, _ = builder.WriteString(": ")
+	}
+
+	_, _ = builder.WriteString("unexpected ")
+
+	if e.Token == nil {
+		_, _ = builder.WriteString("end of input")
+	} else {
+		_, _ = builder.WriteString(slgr.KindString(e.Token.Type))
+	}
+
+	switch len(e.Expected) {
+	case 0:
+		// Nothing to add.
+	case 1:
+		_, _ = builder.WriteString(", expected ")
+		_, _ = builder.WriteString(slgr.KindString(e.Expected[0]))
+	default:
+		expected := make([]string, 0, len(e.Expected))
+
+		for _, type_ := range e.Expected {
+			expected = append(expected, slgr.KindString(type_))
+		}
+
+		_, _ = builder.WriteString(", expected one of: ")
+		_, _ = builder.WriteString(strings.Join(expected, ", "))
+	}
+
+	if len(e.Repairs) > 0 {
+		repairs := make([]string, 0, len(e.Repairs))
+
+		for _, r := range e.Repairs {
+			repairs = append(repairs, r.String())
+		}
+
+		_, _ = builder.WriteString("; fix: ")
+		_, _ = builder.WriteString(strings.Join(repairs, ", "))
+	}
+
+	str := builder.String()
+	return str
+}
+
+// NewParseError creates a new ParseError.
+//
+// Parameters:
+//   - tk: The offending token, or nil if the input ended unexpectedly.
+//   - state: The state of the parser, or -1 if the parser is not table-driven.
+//   - expected: The terminals that would have been acceptable.
+//
+// Returns:
+//   - error: An instance of ParseErrorOf. Never returns nil.
+func NewParseError[K slgr.Kind](tk *slgr.TokenOf[K], state int, expected []K) error {
+	expected = slices.Clone(expected)
+
+	slices.SortStableFunc(expected, func(a, b K) int {
+		return strings.Compare(slgr.KindString(a), slgr.KindString(b))
+	})
+
+	e := &ParseErrorOf[K]{
+		Token:    tk,
+		State:    state,
+		Expected: slices.Compact(expected),
+	}
+
+	return e
+}
@@ -0,0 +1,293 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// symbolName returns the name of the given symbol as shown in the exported
+// automata.
+//
+// Parameters:
+//   - symbol: The id of the symbol.
+//
+// Returns:
+//   - string: The name of the symbol.
+func (t TableOf[K]) symbolName(symbol int) string {
+	if symbol == etEnd {
+		return "$end"
+	}
+
+	str := slgr.KindString(t.symbols[symbol])
+	return str
+}
+
+// stateLabel returns the label of the given state of the automaton: its
+// number, its items with their lookaheads and its conflicts, one per line.
+//
+// Format:
+//
+//	state <state>
+//	<lhs> = <rhs> • <rhs> . {<lookahead>, ...}
+//	conflict on <lookahead>: <kind>
+//
+// Parameters:
+//   - state: The state.
+//   - conflicts: The conflicts of the state.
+//
+// Returns:
+//   - string: The label.
+func (t TableOf[K]) stateLabel(state int, conflicts []*ConflictOf[K]) string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("state ")
+	_, _ = builder.WriteString(strconv.Itoa(state))
+
+	for _, item := range t.automaton.States[state].Items {
+		rule := t.automaton.Rules[item.Rule]
+
+		var lhs string
+
+		if item.Rule == 0 {
+			lhs = t.symbolName(t.automaton.Rules[1].Lhs()) + "'"
+		} else {
+			lhs = t.symbolName(rule.Lhs())
+		}
+
+		_, _ = builder.WriteRune('\n')
+		_, _ = builder.WriteString(lhs)
+		_, _ = builder.WriteString(" =")
+
+		for i, rhs := range rule.Rhss() {
+			if i == item.Dot {
+				_, _ = builder.WriteString(" •")
+			}
+
+			_, _ = builder.WriteRune(' ')
+			_, _ = builder.WriteString(t.symbolName(rhs))
+		}
+
+		if item.Dot == len(rule.Rhss()) {
+			_, _ = builder.WriteString(" •")
+		}
+
+		_, _ = builder.WriteString(" . {")
+
+		for i, la := range item.Lookaheads {
+			if i > 0 {
+				_, _ = builder.WriteString(", ")
+			}
+
+			_, _ = builder.WriteString(t.symbolName(la))
+		}
+
+		_, _ = builder.WriteRune('}')
+	}
+
+	for _, c := range conflicts {
+		la := c.lookahead()
+		if c.End {
+			la = t.symbolName(etEnd)
+		}
+
+		_, _ = builder.WriteString("\nconflict on ")
+		_, _ = builder.WriteString(la)
+		_, _ = builder.WriteString(": ")
+		_, _ = builder.WriteString(c.kind())
+	}
+
+	str := builder.String()
+	return str
+}
+
+// isAccepting checks whether the given state can accept the input.
+//
+// Parameters:
+//   - state: The state.
+//
+// Returns:
+//   - bool: True if the state has an accept action, false otherwise.
+func (t TableOf[K]) isAccepting(state int) bool {
+	for _, entries := range t.actions[state] {
+		for _, e := range entries {
+			if e.kind == acceptEntry {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// graphState is a state of the exported automaton.
+type graphState struct {
+	// id is the number of the state.
+	id int
+
+	// label is the label of the state.
+	label string
+
+	// conflict is true if the state has a conflict.
+	conflict bool
+
+	// accept is true if the state can accept the input.
+	accept bool
+
+	// symbols is the ids of the symbols of the transitions of the state,
+	// sorted.
+	symbols []int
+
+	// targets maps the ids of the symbols of the transitions to the states
+	// reached.
+	targets map[int]int
+}
+
+// graphStates returns the states of the automaton, in order, for exporting.
+//
+// Returns:
+//   - []graphState: The states.
+func (t TableOf[K]) graphStates() []graphState {
+	by_state := make(map[int][]*ConflictOf[K])
+
+	for _, c := range t.conflicts {
+		by_state[c.State] = append(by_state[c.State], c)
+	}
+
+	states := make([]graphState, 0, len(t.automaton.States))
+
+	for i, state := range t.automaton.States {
+		symbols := make([]int, 0, len(state.Transitions))
+
+		for symbol := range state.Transitions {
+			symbols = append(symbols, symbol)
+		}
+
+		slices.Sort(symbols)
+
+		states = append(states, graphState{
+			id:       i,
+			label:    t.stateLabel(i, by_state[i]),
+			conflict: len(by_state[i]) > 0,
+			accept:   t.isAccepting(i),
+			symbols:  symbols,
+			targets:  state.Transitions,
+		})
+	}
+
+	return states
+}
+
+// DOT returns the Graphviz DOT graph of the LALR(1) automaton of the table:
+// one box per state, with its items and their lookaheads, and one edge per
+// transition. Transitions on non-terminals are dashed, accepting states have
+// a double border, and states with conflicts are red and list them.
+//
+// Returns:
+//   - string: The DOT graph.
+func (t TableOf[K]) DOT() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("digraph automaton {\n")
+	_, _ = builder.WriteString("\trankdir=LR;\n")
+	_, _ = builder.WriteString("\tnode [shape=box, fontname=monospace];\n")
+
+	states := t.graphStates()
+
+	for _, s := range states {
+		_, _ = builder.WriteString("\ts")
+		_, _ = builder.WriteString(strconv.Itoa(s.id))
+		_, _ = builder.WriteString(" [label=\"")
+		_, _ = builder.WriteString(slgr.EscapeDOT(s.label + "\n"))
+		_, _ = builder.WriteRune('"')
+
+		if s.accept {
+			_, _ = builder.WriteString(", peripheries=2")
+		}
+
+		if s.conflict {
+			_, _ = builder.WriteString(", color=red, fontcolor=red, penwidth=2")
+		}
+
+		_, _ = builder.WriteString("];\n")
+	}
+
+	for _, s := range states {
+		for _, symbol := range s.symbols {
+			_, _ = builder.WriteString("\ts")
+			_, _ = builder.WriteString(strconv.Itoa(s.id))
+			_, _ = builder.WriteString(" -> s")
+			_, _ = builder.WriteString(strconv.Itoa(s.targets[symbol]))
+			_, _ = builder.WriteString(" [label=\"")
+			_, _ = builder.WriteString(slgr.EscapeDOT(t.symbolName(symbol)))
+			_, _ = builder.WriteRune('"')
+
+			if !t.automaton.IsTerminal(symbol) {
+				_, _ = builder.WriteString(", style=dashed")
+			}
+
+			_, _ = builder.WriteString("];\n")
+		}
+	}
+
+	_, _ = builder.WriteString("}\n")
+
+	return builder.String()
+}
+
+// Mermaid returns the Mermaid flowchart of the LALR(1) automaton of the
+// table. See DOT.
+//
+// Returns:
+//   - string: The Mermaid flowchart.
+func (t TableOf[K]) Mermaid() string {
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("flowchart LR\n")
+	_, _ = builder.WriteString("\tclassDef conflict stroke:#d00,stroke-width:3px,color:#d00\n")
+	_, _ = builder.WriteString("\tclassDef accept stroke-width:4px,stroke-dasharray:0\n")
+
+	states := t.graphStates()
+
+	for _, s := range states {
+		_, _ = builder.WriteString("\ts")
+		_, _ = builder.WriteString(strconv.Itoa(s.id))
+		_, _ = builder.WriteString("[\"")
+		_, _ = builder.WriteString(slgr.EscapeMermaid(s.label))
+		_, _ = builder.WriteString("\"]\n")
+	}
+
+	for _, s := range states {
+		for _, symbol := range s.symbols {
+			_, _ = builder.WriteString("\ts")
+			_, _ = builder.WriteString(strconv.Itoa(s.id))
+
+			if t.automaton.IsTerminal(symbol) {
+				_, _ = builder.WriteString(" -->|\"")
+			} else {
+				_, _ = builder.WriteString(" -.->|\"")
+			}
+
+			_, _ = builder.WriteString(slgr.EscapeMermaid(t.symbolName(symbol)))
+			_, _ = builder.WriteString("\"| s")
+			_, _ = builder.WriteString(strconv.Itoa(s.targets[symbol]))
+			_, _ = builder.WriteRune('\n')
+		}
+	}
+
+	for _, s := range states {
+		if s.conflict {
+			_, _ = builder.WriteString("\tclass s")
+			_, _ = builder.WriteString(strconv.Itoa(s.id))
+			_, _ = builder.WriteString(" conflict\n")
+		} else if s.accept {
+			_, _ = builder.WriteString("\tclass s")
+			_, _ = builder.WriteString(strconv.Itoa(s.id))
+			_, _ = builder.WriteString(" accept\n")
+		}
+	}
+
+	return builder.String()
+}
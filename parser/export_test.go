@@ -0,0 +1,89 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+func TestTableDOT(t *testing.T) {
+	table := mustTable(t, slgr.NewRule("S", "a", "b"))
+
+	want := "digraph automaton {\n" +
+		"\trankdir=LR;\n" +
+		"\tnode [shape=box, fontname=monospace];\n" +
+		"\ts0 [label=\"state 0\\lS' = • S . {EtEOF}\\lS = • a b . {EtEOF}\\l\"];\n" +
+		"\ts1 [label=\"state 1\\lS' = S • . {EtEOF}\\l\", peripheries=2];\n" +
+		"\ts2 [label=\"state 2\\lS = a • b . {EtEOF}\\l\"];\n" +
+		"\ts3 [label=\"state 3\\lS = a b • . {EtEOF}\\l\"];\n" +
+		"\ts0 -> s1 [label=\"S\", style=dashed];\n" +
+		"\ts0 -> s2 [label=\"a\"];\n" +
+		"\ts2 -> s3 [label=\"b\"];\n" +
+		"}\n"
+
+	if got := table.DOT(); got != want {
+		t.Errorf("DOT() = %q, want %q", got, want)
+	}
+}
+
+func TestTableMermaid(t *testing.T) {
+	table := mustTable(t, slgr.NewRule("S", "a", "b"))
+
+	want := "flowchart LR\n" +
+		"\tclassDef conflict stroke:#d00,stroke-width:3px,color:#d00\n" +
+		"\tclassDef accept stroke-width:4px,stroke-dasharray:0\n" +
+		"\ts0[\"state 0<br/>S' = • S . {EtEOF}<br/>S = • a b . {EtEOF}\"]\n" +
+		"\ts1[\"state 1<br/>S' = S • . {EtEOF}\"]\n" +
+		"\ts2[\"state 2<br/>S = a • b . {EtEOF}\"]\n" +
+		"\ts3[\"state 3<br/>S = a b • . {EtEOF}\"]\n" +
+		"\ts0 -.->|\"S\"| s1\n" +
+		"\ts0 -->|\"a\"| s2\n" +
+		"\ts2 -->|\"b\"| s3\n" +
+		"\tclass s1 accept\n"
+
+	if got := table.Mermaid(); got != want {
+		t.Errorf("Mermaid() = %q, want %q", got, want)
+	}
+}
+
+func TestTableExportConflicts(t *testing.T) {
+	table := mustTable(t, sumRules...)
+
+	conflicts := table.Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("Conflicts() = %v, want one conflict", conflicts)
+	}
+
+	state := "s" + strconv.Itoa(conflicts[0].State)
+
+	dot := table.DOT()
+
+	for _, want := range []string{
+		"conflict on plus: shift/reduce\\l\", color=red, fontcolor=red, penwidth=2];",
+		"\t" + state + " [label=\"state ",
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT() = %q, want it to contain %q", dot, want)
+		}
+	}
+
+	mermaid := table.Mermaid()
+
+	for _, want := range []string{
+		"<br/>conflict on plus: shift/reduce\"]\n",
+		"\tclass " + state + " conflict\n",
+	} {
+		if !strings.Contains(mermaid, want) {
+			t.Errorf("Mermaid() = %q, want it to contain %q", mermaid, want)
+		}
+	}
+
+	// Grammars that consume EtEOF see the end of the input after it.
+	table = mustTable(t, slgr.NewRule("S", "a", EtEOF))
+
+	if dot := table.DOT(); !strings.Contains(dot, "S = a EtEOF • . {$end}") {
+		t.Errorf("DOT() = %q, want the $end lookahead", dot)
+	}
+}
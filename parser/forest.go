@@ -0,0 +1,344 @@
+package parser
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// ErrAmbiguous occurs when a single tree is requested from a forest that has
+// more than one. This error can be checked with the == operator.
+//
+// Format:
+//
+//	"parse forest is ambiguous"
+var ErrAmbiguous error
+
+func init() {
+	ErrAmbiguous = errors.New("parse forest is ambiguous")
+}
+
+// FamilyOf is one of the derivations of a forest node; that is, a rule and the
+// forest nodes of its right-hand side symbols.
+type FamilyOf[K slgr.Kind] struct {
+	// Rule is the rule of the derivation.
+	Rule *slgr.RuleOf[K]
+
+	// Children is the forest nodes of the right-hand side of the rule.
+	Children []*ForestNodeOf[K]
+}
+
+// Family is the derivation of the string-based API.
+type Family = FamilyOf[string]
+
+// ForestNodeOf is a node of a shared packed parse forest. Every node stands for
+// all the ways a symbol derives a span of the input; each way is a Family.
+type ForestNodeOf[K slgr.Kind] struct {
+	// Symbol is the symbol of the node.
+	Symbol K
+
+	// Start is the index of the first input token of the span.
+	Start int
+
+	// End is the index past the last input token of the span.
+	End int
+
+	// Token is the input token, for terminal nodes.
+	Token *slgr.TokenOf[K]
+
+	// Families is the derivations of the node. It is empty for terminal nodes
+	// and has more than one element for ambiguous nodes.
+	Families []*FamilyOf[K]
+}
+
+// ForestNode is the forest node of the string-based API.
+type ForestNode = ForestNodeOf[string]
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<symbol> [<start>, <end>)"
+func (n ForestNodeOf[K]) String() string {
+	str := slgr.KindString(n.Symbol) + " [" + strconv.Itoa(n.Start) + ", " + strconv.Itoa(n.End) + ")"
+	return str
+}
+
+// IsAmbiguous checks whether the node has more than one derivation.
+//
+// Returns:
+//   - bool: True if the node is ambiguous, false otherwise.
+func (n ForestNodeOf[K]) IsAmbiguous() bool {
+	return len(n.Families) > 1
+}
+
+// addFamily adds a derivation to the node, unless it already has it.
+//
+// Parameters:
+//   - rule: The rule of the derivation.
+//   - children: The forest nodes of the right-hand side.
+//
+// Returns:
+//   - bool: True if the derivation was added, false if the node already has
+//     it.
+func (n *ForestNodeOf[K]) addFamily(rule *slgr.RuleOf[K], children []*ForestNodeOf[K]) bool {
+	for _, f := range n.Families {
+		if f.Rule == rule && slices.Equal(f.Children, children) {
+			return false
+		}
+	}
+
+	n.Families = append(n.Families, &FamilyOf[K]{
+		Rule:     rule,
+		Children: children,
+	})
+
+	return true
+}
+
+// ForestOf is a shared packed parse forest; that is, a compact representation of
+// every parse tree of an input.
+type ForestOf[K slgr.Kind] struct {
+	// root is the root node of the forest.
+	root *ForestNodeOf[K]
+}
+
+// Forest is the packed parse forest of the string-based API.
+type Forest = ForestOf[string]
+
+// Root returns the root node of the forest.
+//
+// Returns:
+//   - *ForestNodeOf[K]: The root node. Never returns nil.
+func (f ForestOf[K]) Root() *ForestNodeOf[K] {
+	return f.root
+}
+
+// Nodes returns every node reachable from the root, in depth-first order.
+//
+// Returns:
+//   - []*ForestNodeOf[K]: The nodes of the forest.
+func (f ForestOf[K]) Nodes() []*ForestNodeOf[K] {
+	seen := make(map[*ForestNodeOf[K]]struct{})
+
+	var nodes []*ForestNodeOf[K]
+
+	stack := []*ForestNodeOf[K]{f.root}
+
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		_, ok := seen[n]
+		if ok {
+			continue
+		}
+
+		seen[n] = struct{}{}
+		nodes = append(nodes, n)
+
+		for i := len(n.Families) - 1; i >= 0; i-- {
+			children := n.Families[i].Children
+
+			for j := len(children) - 1; j >= 0; j-- {
+				stack = append(stack, children[j])
+			}
+		}
+	}
+
+	return nodes
+}
+
+// Ambiguities returns the ambiguous nodes reachable from the root, in
+// depth-first order.
+//
+// Returns:
+//   - []*ForestNodeOf[K]: The ambiguous nodes, or nil if the forest has a single
+//     tree.
+func (f ForestOf[K]) Ambiguities() []*ForestNodeOf[K] {
+	var ambiguous []*ForestNodeOf[K]
+
+	for _, n := range f.Nodes() {
+		if n.IsAmbiguous() {
+			ambiguous = append(ambiguous, n)
+		}
+	}
+
+	return ambiguous
+}
+
+// IsAmbiguous checks whether the forest holds more than one tree.
+//
+// Returns:
+//   - bool: True if the forest is ambiguous, false otherwise.
+func (f ForestOf[K]) IsAmbiguous() bool {
+	for _, n := range f.Nodes() {
+		if n.IsAmbiguous() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// trees returns every tree the given node stands for. Derivations that loop
+// back to a node that is being expanded are skipped, so that cyclic grammars
+// yield a finite number of trees.
+//
+// Parameters:
+//   - n: The node.
+//   - visiting: The nodes that are being expanded.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The trees.
+func trees[K slgr.Kind](n *ForestNodeOf[K], visiting map[*ForestNodeOf[K]]bool) []*slgr.TokenOf[K] {
+	if n.Token != nil {
+		return []*slgr.TokenOf[K]{n.Token}
+	}
+
+	if visiting[n] {
+		return nil
+	}
+
+	visiting[n] = true
+	defer delete(visiting, n)
+
+	var result []*slgr.TokenOf[K]
+
+	for _, f := range n.Families {
+		combos := [][]*slgr.TokenOf[K]{nil}
+
+		for _, child := range f.Children {
+			subs := trees(child, visiting)
+
+			var next [][]*slgr.TokenOf[K]
+
+			for _, combo := range combos {
+				for _, sub := range subs {
+					c := make([]*slgr.TokenOf[K], len(combo), len(combo)+1)
+					copy(c, combo)
+
+					next = append(next, append(c, sub))
+				}
+			}
+
+			combos = next
+		}
+
+		for _, combo := range combos {
+			tk := slgr.NewToken(n.Symbol, "")
+
+			err := tk.AppendChildren(combo)
+			if err != nil {
+				continue
+			}
+
+			for _, child := range combo {
+				if child.Pos.IsValid() {
+					tk.Pos = child.Pos
+					break
+				}
+			}
+
+			result = append(result, tk)
+		}
+	}
+
+	return result
+}
+
+// TreesOf returns every parse tree the given forest node stands for. The trees
+// share no token with each other nor with the forest.
+//
+// Beware that the number of trees can be exponential in the size of the input.
+//
+// Parameters:
+//   - n: The forest node.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The trees, or nil if the node is nil.
+func TreesOf[K slgr.Kind](n *ForestNodeOf[K]) []*slgr.TokenOf[K] {
+	if n == nil {
+		return nil
+	}
+
+	result := trees(n, make(map[*ForestNodeOf[K]]bool))
+
+	for i, tree := range result {
+		result[i] = tree.Copy()
+	}
+
+	return result
+}
+
+// Trees returns every parse tree of the forest. The trees share no token with
+// each other nor with the forest.
+//
+// Beware that the number of trees can be exponential in the size of the input.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The trees.
+func (f ForestOf[K]) Trees() []*slgr.TokenOf[K] {
+	result := TreesOf(f.root)
+	return result
+}
+
+// Tree returns the single parse tree of the forest.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The parse tree.
+//   - error: An error if the forest is ambiguous.
+//
+// Errors:
+//   - ErrAmbiguous: If the forest is ambiguous.
+func (f ForestOf[K]) Tree() (*slgr.TokenOf[K], error) {
+	if f.IsAmbiguous() {
+		return nil, ErrAmbiguous
+	}
+
+	result := f.Trees()
+	if len(result) != 1 {
+		return nil, ErrAmbiguous
+	}
+
+	return result[0], nil
+}
+
+// String implements fmt.Stringer.
+//
+// Every node is written once, on its own line, with its derivations below it.
+//
+// Format:
+//
+//	"<node>\n   <rule>: <child> <child> ...\n..."
+func (f ForestOf[K]) String() string {
+	var builder strings.Builder
+
+	for _, n := range f.Nodes() {
+		if n.Token != nil {
+			continue
+		}
+
+		_, _ = builder.WriteString(n.String())
+		_, _ = builder.WriteRune('\n')
+
+		for _, fam := range n.Families {
+			_, _ = builder.WriteString("   ")
+			_, _ = builder.WriteString(fam.Rule.String())
+			_, _ = builder.WriteRune(':')
+
+			for _, child := range fam.Children {
+				_, _ = builder.WriteRune(' ')
+				_, _ = builder.WriteString(child.String())
+			}
+
+			_, _ = builder.WriteRune('\n')
+		}
+	}
+
+	str := builder.String()
+	return str
+}
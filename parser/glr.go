@@ -0,0 +1,589 @@
+package parser
+
+import (
+	"context"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// gssNode is a node of the graph-structured stack.
+type gssNode[K slgr.Kind] struct {
+	// state is the state of the node.
+	state int
+
+	// level is the index of the input token the node was created before.
+	level int
+
+	// depth is the number of edges of the longest path from the node to the
+	// bottom of the stack.
+	depth int
+
+	// edges is the edges to the nodes below this one.
+	edges []*gssEdge[K]
+}
+
+// gssEdge is an edge of the graph-structured stack.
+type gssEdge[K slgr.Kind] struct {
+	// to is the node below.
+	to *gssNode[K]
+
+	// label is the forest node of the symbol between the two nodes.
+	label *ForestNodeOf[K]
+}
+
+// gssPath is a path of the graph-structured stack.
+type gssPath[K slgr.Kind] struct {
+	// end is the last node of the path.
+	end *gssNode[K]
+
+	// labels is the labels of the edges of the path, from the bottom to the
+	// top.
+	labels []*ForestNodeOf[K]
+}
+
+// reduction is a pending reduction of the GLR parser.
+type reduction[K slgr.Kind] struct {
+	// node is the node to reduce from.
+	node *gssNode[K]
+
+	// rule is the index of the rule to reduce.
+	rule int
+
+	// edge is the edge every path must go through, or nil if any path will do.
+	edge *gssEdge[K]
+}
+
+// forestKey identifies a forest node.
+type forestKey struct {
+	// symbol is the id of the symbol of the node.
+	symbol int
+
+	// start is the start of the span of the node.
+	start int
+
+	// end is the end of the span of the node.
+	end int
+}
+
+// glrParser holds the state of a GLR parsing process.
+type glrParser[K slgr.Kind] struct {
+	// parser is the parser that runs the process.
+	parser *ParserOf[K]
+
+	// table is the parsing table.
+	table *TableOf[K]
+
+	// size is the number of nodes and edges of the graph-structured stack and
+	// of the packed parse forest.
+	size int
+
+	// nodes maps the key of a forest node to the node.
+	nodes map[forestKey]*ForestNodeOf[K]
+
+	// frontier maps a state to the node of the current level with that state.
+	frontier map[int]*gssNode[K]
+
+	// order is the nodes of the current level, in order of creation.
+	order []*gssNode[K]
+
+	// queue is the list of pending reductions.
+	queue []reduction[K]
+}
+
+// grow accounts for new nodes or edges of the graph-structured stack or of the
+// packed parse forest.
+//
+// Parameters:
+//   - n: The number of new nodes and edges.
+//
+// Returns:
+//   - error: An error if the maximum size is exceeded.
+//
+// Errors:
+//   - *common.ErrLimitExceeded: If the maximum size is exceeded.
+func (g *glrParser[K]) grow(n int) error {
+	g.size += n
+
+	limit := g.parser.max_forest_size
+
+	if limit > 0 && g.size > limit {
+		err := common.NewErrLimitExceeded("forest size", limit)
+		return err
+	}
+
+	return nil
+}
+
+// newNode creates a node of the graph-structured stack.
+//
+// Parameters:
+//   - state: The state of the node.
+//   - level: The level of the node.
+//
+// Returns:
+//   - *gssNode[K]: The new node. Never returns nil.
+//   - error: An error if the maximum size is exceeded.
+func (g *glrParser[K]) newNode(state, level int) (*gssNode[K], error) {
+	err := g.grow(1)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &gssNode[K]{
+		state: state,
+		level: level,
+	}
+
+	return v, nil
+}
+
+// link adds an edge from a node to a node below it.
+//
+// Parameters:
+//   - v: The node above. (Assumed to not be nil)
+//   - to: The node below. (Assumed to not be nil)
+//   - label: The forest node of the symbol between the two nodes.
+//
+// Returns:
+//   - *gssEdge[K]: The new edge, or nil on error.
+//   - error: An error if the parsing process must stop.
+//
+// Errors:
+//   - ctx.Err(): If the context of the parsing process is done.
+//   - *common.ErrLimitExceeded: If the path through the edge is longer than
+//     the maximum stack depth, or if the maximum size is exceeded.
+func (g *glrParser[K]) link(v, to *gssNode[K], label *ForestNodeOf[K]) (*gssEdge[K], error) {
+	v.depth = max(v.depth, to.depth+1)
+
+	err := g.parser.check(v.depth)
+	if err != nil {
+		return nil, err
+	}
+
+	err = g.grow(1)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &gssEdge[K]{to: to, label: label}
+	v.edges = append(v.edges, e)
+
+	return e, nil
+}
+
+// forestNode returns the forest node of the given symbol and span, creating
+// it if needed.
+//
+// Parameters:
+//   - symbol: The id of the symbol of the node.
+//   - start: The start of the span.
+//   - end: The end of the span.
+//
+// Returns:
+//   - *ForestNodeOf[K]: The forest node, or nil on error.
+//   - error: An error if the maximum size is exceeded.
+func (g *glrParser[K]) forestNode(symbol, start, end int) (*ForestNodeOf[K], error) {
+	key := forestKey{symbol: symbol, start: start, end: end}
+
+	n, ok := g.nodes[key]
+	if ok {
+		return n, nil
+	}
+
+	err := g.grow(1)
+	if err != nil {
+		return nil, err
+	}
+
+	n = &ForestNodeOf[K]{
+		Symbol: g.table.symbols[symbol],
+		Start:  start,
+		End:    end,
+	}
+
+	g.nodes[key] = n
+
+	return n, nil
+}
+
+// paths returns every path of the given length that starts at the given node.
+//
+// Parameters:
+//   - v: The node to start from.
+//   - length: The number of edges of the paths.
+//   - required: The edge every path must go through, or nil if any path will
+//     do.
+//
+// Returns:
+//   - []gssPath[K]: The paths.
+func paths[K slgr.Kind](v *gssNode[K], length int, required *gssEdge[K]) []gssPath[K] {
+	if length == 0 {
+		if required != nil {
+			return nil
+		}
+
+		return []gssPath[K]{{end: v}}
+	}
+
+	var result []gssPath[K]
+
+	for _, e := range v.edges {
+		next := required
+		if e == required {
+			next = nil
+		}
+
+		for _, p := range paths(e.to, length-1, next) {
+			labels := make([]*ForestNodeOf[K], len(p.labels), len(p.labels)+1)
+			copy(labels, p.labels)
+
+			result = append(result, gssPath[K]{
+				end:    p.end,
+				labels: append(labels, e.label),
+			})
+		}
+	}
+
+	return result
+}
+
+// enqueueReductions queues the reductions of the given node on the given
+// lookahead.
+//
+// Parameters:
+//   - v: The node.
+//   - la: The id of the lookahead.
+//   - edge: The edge every path must go through, or nil if any path will do.
+func (g *glrParser[K]) enqueueReductions(v *gssNode[K], la int, edge *gssEdge[K]) {
+	for _, e := range g.table.entries(v.state, la) {
+		if e.kind != reduceEntry {
+			continue
+		}
+
+		if edge != nil && len(g.table.rule(e.rule).Rhss()) == 0 {
+			continue
+		}
+
+		g.queue = append(g.queue, reduction[K]{node: v, rule: e.rule, edge: edge})
+	}
+}
+
+// reduce performs every pending reduction of the current level.
+//
+// Parameters:
+//   - level: The current level.
+//   - la: The id of the lookahead.
+//
+// Returns:
+//   - error: An error if the parsing process must stop. See link.
+func (g *glrParser[K]) reduce(level, la int) error {
+	for len(g.queue) > 0 {
+		r := g.queue[0]
+		g.queue = g.queue[1:]
+
+		rule := g.table.rule(r.rule)
+		lhs := rule.Lhs()
+
+		for _, p := range paths(r.node, len(rule.Rhss()), r.edge) {
+			n, err := g.forestNode(lhs, p.end.level, level)
+			if err != nil {
+				return err
+			}
+
+			if n.addFamily(g.table.rules[r.rule-1], p.labels) {
+				err := g.grow(1)
+				if err != nil {
+					return err
+				}
+			}
+
+			next, ok := g.table.goTo(p.end.state, lhs)
+			assert.Cond(ok, "g.table.goTo(state, lhs)")
+
+			w, ok := g.frontier[next]
+			if !ok {
+				w, err = g.newNode(next, level)
+				if err != nil {
+					return err
+				}
+
+				g.frontier[next] = w
+				g.order = append(g.order, w)
+
+				_, err = g.link(w, p.end, n)
+				if err != nil {
+					return err
+				}
+
+				g.enqueueReductions(w, la, nil)
+
+				continue
+			}
+
+			exists := false
+
+			for _, e := range w.edges {
+				if e.to == p.end && e.label == n {
+					exists = true
+					break
+				}
+			}
+
+			if exists {
+				continue
+			}
+
+			e, err := g.link(w, p.end, n)
+			if err != nil {
+				return err
+			}
+
+			// The new edge may create new paths for any node of this level.
+			for _, x := range g.order {
+				g.enqueueReductions(x, la, e)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseGLR parses the input stream of tokens with a GLR parser that runs on
+// the parsing table.
+//
+// Whenever the table has more than one action, the stack forks; stacks that
+// reach the same state at the same position are merged in a graph-structured
+// stack, and identical derivations are shared in a packed parse forest. The
+// tokens are pulled from the source one level at a time, so that the limits
+// stop the process before the whole input is read.
+//
+// Returns:
+//   - *ForestOf[K]: The packed parse forest.
+//   - error: An error if the input cannot be parsed.
+//
+// Errors:
+//   - *ParseErrorOf[K]: If no stack can accept the input. The state is -1 when
+//     more than one stack was alive.
+//   - ctx.Err(): If the context of the parsing process is done.
+//   - *common.ErrLimitExceeded: If a path of the graph-structured stack is
+//     longer than the maximum stack depth, or if the graph-structured stack
+//     and the packed parse forest exceed the maximum forest size.
+//   - any other error: If the source fails.
+func (p *ParserOf[K]) parseGLR() (*ForestOf[K], error) {
+	assert.Cond(p != nil, "p != nil")
+	assert.Cond(p.table != nil, "p.table != nil")
+
+	g := &glrParser[K]{
+		parser: p,
+		table:  p.table,
+		nodes:  make(map[forestKey]*ForestNodeOf[K]),
+	}
+
+	base, err := g.newNode(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	g.frontier = map[int]*gssNode[K]{0: base}
+	g.order = []*gssNode[K]{base}
+
+	for level := 0; ; level++ {
+		err := p.fill(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens := p.tokens
+
+		depth := 0
+
+		for _, v := range g.order {
+			depth = max(depth, v.depth)
+		}
+
+		err = p.check(depth)
+		if err != nil {
+			return nil, err
+		}
+
+		la := etEnd
+		if level < len(tokens) {
+			la = g.table.id(tokens[level].Type)
+		}
+
+		for _, v := range g.order {
+			g.enqueueReductions(v, la, nil)
+		}
+
+		err = g.reduce(level, la)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range g.order {
+			for _, e := range g.table.entries(v.state, la) {
+				if e.kind != acceptEntry {
+					continue
+				}
+
+				for _, edge := range v.edges {
+					if edge.to == base {
+						p.tokens = tokens[level:]
+
+						f := &ForestOf[K]{
+							root: edge.label,
+						}
+
+						return f, nil
+					}
+				}
+			}
+		}
+
+		var next []*gssNode[K]
+
+		frontier := make(map[int]*gssNode[K])
+
+		if level < len(tokens) && la != noSymbol {
+			leaf, err := g.forestNode(la, level, level+1)
+			if err != nil {
+				return nil, err
+			}
+
+			leaf.Token = tokens[level]
+
+			for _, v := range g.order {
+				for _, e := range g.table.entries(v.state, la) {
+					if e.kind != shiftEntry {
+						continue
+					}
+
+					w, ok := frontier[e.state]
+					if !ok {
+						w, err = g.newNode(e.state, level+1)
+						if err != nil {
+							return nil, err
+						}
+
+						frontier[e.state] = w
+						next = append(next, w)
+					}
+
+					_, err = g.link(w, v, leaf)
+					if err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+
+		if len(next) == 0 {
+			var la_tk *slgr.TokenOf[K]
+
+			if level < len(tokens) {
+				la_tk = tokens[level]
+			}
+
+			var expected []K
+
+			for _, v := range g.order {
+				expected = append(expected, g.table.Expected(v.state)...)
+			}
+
+			state := -1
+			if len(g.order) == 1 {
+				state = g.order[0].state
+			}
+
+			p.tokens = tokens[level:]
+
+			err := NewParseError(la_tk, state, expected)
+			return nil, err
+		}
+
+		g.frontier = frontier
+		g.order = next
+	}
+}
+
+// GetPackedForest returns the packed parse forest of the last parsing process
+// of a GLR parser. The forest is kept across Reset; so that it is available
+// after ParseFrom and ParseFromContext.
+//
+// Returns:
+//   - *ForestOf[K]: The packed parse forest, or nil if there is none.
+func (p ParserOf[K]) GetPackedForest() *ForestOf[K] {
+	return p.packed
+}
+
+// ParseForest parses the input stream of tokens using the provided GLR parser
+// and returns every possible parse tree as a shared packed parse forest.
+//
+// Parameters:
+//   - parser: The parser to be used to parse the input stream. It must be
+//     table-driven; see Builder.SetTable.
+//   - tokens: The list of tokens to be used as the input stream.
+//
+// Returns:
+//   - *ForestOf[K]: The packed parse forest, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails.
+//
+// Errors:
+//   - common.ErrBadParam: If the parser is nil or is not table-driven.
+//   - *ParseErrorOf[K]: If the input stream cannot be parsed.
+//   - *common.ErrLimitExceeded: If the maximum stack depth or the maximum
+//     forest size is exceeded.
+func ParseForest[K slgr.Kind](parser *ParserOf[K], tokens []*slgr.TokenOf[K]) (*ForestOf[K], error) {
+	forest, err := ParseForestContext(context.Background(), parser, tokens)
+	return forest, err
+}
+
+// ParseForestContext is like ParseForest, but it stops as soon as the context
+// is done. See Parser.ParseContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - parser: The parser to be used to parse the input stream. It must be
+//     table-driven; see Builder.SetTable.
+//   - tokens: The list of tokens to be used as the input stream.
+//
+// Returns:
+//   - *ForestOf[K]: The packed parse forest, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails or if the context is done.
+//
+// Errors:
+//   - common.ErrBadParam: If the parser is nil or is not table-driven.
+//   - ctx.Err(): If the context is done.
+//   - *ParseErrorOf[K]: If the input stream cannot be parsed.
+//   - *common.ErrLimitExceeded: If the maximum stack depth or the maximum
+//     forest size is exceeded.
+func ParseForestContext[K slgr.Kind](ctx context.Context, parser *ParserOf[K], tokens []*slgr.TokenOf[K]) (*ForestOf[K], error) {
+	if parser == nil {
+		err := common.NewErrNilParam("parser")
+		return nil, err
+	} else if parser.table == nil {
+		err := common.NewErrBadParam("parser", "must be table-driven")
+		return nil, err
+	}
+
+	defer parser.Reset()
+
+	parser.ctx = ctx
+	defer func() { parser.ctx = nil }()
+
+	err := parser.SetTokenSource(NewSliceSource(tokens))
+	assert.Err(err, "parser.SetTokenSource(source)")
+
+	parser.packed = nil
+
+	forest, err := parser.parseGLR()
+	if err != nil {
+		return nil, err
+	}
+
+	parser.packed = forest
+
+	return forest, nil
+}
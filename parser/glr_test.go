@@ -0,0 +1,203 @@
+package parser
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// sumRules is an ambiguous grammar of sums.
+var sumRules = []*slgr.Rule{
+	slgr.NewRule("E", "E", "plus", "E"),
+	slgr.NewRule("E", "num"),
+}
+
+// newGLRParser returns a GLR parser driven by the given table.
+func newGLRParser(table *Table) *Parser {
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetGLR(true)
+
+	return builder.Build()
+}
+
+// leaves returns the leaves of the given tree, from left to right.
+func leaves(tk *slgr.Token) []*slgr.Token {
+	if len(tk.Children) == 0 {
+		return []*slgr.Token{tk}
+	}
+
+	var result []*slgr.Token
+
+	for _, child := range tk.Children {
+		result = append(result, leaves(child)...)
+	}
+
+	return result
+}
+
+func TestParseForest(t *testing.T) {
+	table := mustTable(t, sumRules...)
+
+	forest, err := ParseForest(newGLRParser(table), newTokens("num", "plus", "num", "plus", "num"))
+	if err != nil {
+		t.Fatalf("ParseForest() returned an error: %v", err)
+	}
+
+	if !forest.IsAmbiguous() {
+		t.Errorf("IsAmbiguous() = false, want true")
+	}
+
+	if got := len(forest.Ambiguities()); got != 1 {
+		t.Errorf("Ambiguities() has %d nodes, want 1", got)
+	}
+
+	trees := forest.Trees()
+
+	got := shapes(trees)
+	slices.Sort(got)
+
+	want := []string{
+		"(E (E (E num) plus (E num)) plus (E num))",
+		"(E (E num) plus (E (E num) plus (E num)))",
+	}
+
+	if !slices.Equal(got, want) {
+		t.Fatalf("Trees() = %q, want %q", got, want)
+	}
+
+	first := leaves(trees[0])
+
+	for i, leaf := range leaves(trees[1]) {
+		if leaf == first[i] {
+			t.Errorf("the trees share their leaf #%d", i)
+		}
+	}
+
+	_, err = forest.Tree()
+	if !errors.Is(err, ErrAmbiguous) {
+		t.Errorf("Tree() returned %v, want ErrAmbiguous", err)
+	}
+}
+
+func TestParseForestUnambiguous(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	forest, err := ParseForest(newGLRParser(table), newTokens("num", "plus", "num"))
+	if err != nil {
+		t.Fatalf("ParseForest() returned an error: %v", err)
+	}
+
+	tree, err := forest.Tree()
+	if err != nil {
+		t.Fatalf("Tree() returned an error: %v", err)
+	}
+
+	want := "(E (E (T num)) plus (T num))"
+
+	if got := shape(tree); got != want {
+		t.Errorf("Tree() = %s, want %s", got, want)
+	}
+}
+
+func TestParseForestErrors(t *testing.T) {
+	table := mustTable(t, sumRules...)
+
+	_, err := ParseForest(newGLRParser(table), newTokens("num", "plus"))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("ParseForest() returned %v, want a *ParseError", err)
+	}
+
+	_, err = ParseForest(nil, newTokens("num"))
+	if err == nil {
+		t.Errorf("ParseForest(nil) returned no error")
+	}
+
+	var builder Builder
+
+	_ = builder.SetParseOneFn(func(p *Parser) (Action, error) { return nil, nil })
+
+	_, err = ParseForest(builder.Build(), newTokens("num"))
+	if err == nil {
+		t.Errorf("ParseForest() of a parser without a table returned no error")
+	}
+}
+
+func TestParseGLR(t *testing.T) {
+	table := mustTable(t, sumRules...)
+	parser := newGLRParser(table)
+
+	forest, err := ParseFrom(parser, NewSliceSource(newTokens("num", "plus", "num", "plus", "num")))
+	if err != nil {
+		t.Fatalf("ParseFrom() returned an error: %v", err)
+	} else if len(forest) != 1 {
+		t.Fatalf("ParseFrom() returned %q, want one tree", shapes(forest))
+	}
+
+	packed := parser.GetPackedForest()
+	if packed == nil {
+		t.Fatalf("GetPackedForest() = nil after ParseFrom")
+	}
+
+	if !slices.Contains(shapes(packed.Trees()), shape(forest[0])) {
+		t.Errorf("tree %s is not a tree of the packed parse forest", shape(forest[0]))
+	}
+}
+
+func TestParseGLRLimits(t *testing.T) {
+	var le *common.ErrLimitExceeded
+
+	// Every parenthesis deepens every stack by two; the source is not read
+	// past the limit.
+	var builder Builder
+
+	_ = builder.SetTable(mustTable(t, exprRules...))
+	_ = builder.SetGLR(true)
+	_ = builder.SetMaxStackDepth(4)
+
+	src := &countingSource{tokens: numTokens(slices.Repeat([]string{"("}, 1000)...)}
+
+	_, err := ParseFrom(builder.Build(), src)
+	if !errors.As(err, &le) || le.Limit != "stack depth" {
+		t.Errorf("ParseFrom() returned %v, want a *common.ErrLimitExceeded on the stack depth", err)
+	} else if src.pulled > 10 {
+		t.Errorf("ParseFrom() pulled %d tokens, want the source to not be read past the limit", src.pulled)
+	}
+
+	_, err = ParseForest(builder.Build(), newTokens("op", "num", "cl"))
+	if err != nil {
+		t.Errorf("ParseForest() returned an error below the depth limit: %v", err)
+	}
+
+	// The forest of n ambiguous sums has O(n^3) derivations.
+	_ = builder.Reset()
+
+	_ = builder.SetTable(mustTable(t, sumRules...))
+	_ = builder.SetGLR(true)
+	_ = builder.SetMaxForestSize(200)
+
+	sum := []string{"num"}
+	for range 20 {
+		sum = append(sum, "plus", "num")
+	}
+
+	_, err = ParseForest(builder.Build(), newTokens(sum...))
+	if !errors.As(err, &le) || le.Limit != "forest size" {
+		t.Errorf("ParseForest() returned %v, want a *common.ErrLimitExceeded on the forest size", err)
+	}
+
+	_, err = ParseForest(builder.Build(), newTokens(sum[:5]...))
+	if err != nil {
+		t.Errorf("ParseForest() returned an error below the size limit: %v", err)
+	}
+
+	if builder.SetMaxForestSize(-1) == nil {
+		t.Errorf("SetMaxForestSize(-1) returned no error")
+	}
+}
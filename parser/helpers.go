@@ -3,4 +3,9 @@ package parser
 const (
 	// EtEOF is the token type for the end of file.
 	EtEOF string = "EtEOF"
+
+	// EtError is the token type of the error pseudo-terminal. When used in a
+	// rule (e.g., "Stmt = EtError semicolon ."), it matches any erroneous input
+	// the parser recovers from. See Builder.SetRecovery.
+	EtError string = "EtError"
 )
@@ -0,0 +1,503 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// reuseItem is an element of the input of an incremental parser: either a
+// new token or a subtree of the previous parse tree.
+type reuseItem[K slgr.Kind] struct {
+	// node is the token or the root of the subtree.
+	node *slgr.TokenOf[K]
+
+	// state is the state the previous parser was in before the subtree, or -1
+	// for new tokens.
+	state int
+
+	// suffix is true if the subtree comes after the changed tokens, so that
+	// its leaves must be replaced by the new tokens.
+	suffix bool
+}
+
+// isLeaf checks whether the given token is a terminal.
+//
+// Parameters:
+//   - tk: The token. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the token is a terminal, false if it is a non-terminal.
+func (t TableOf[K]) isLeaf(tk *slgr.TokenOf[K]) bool {
+	id := t.id(tk.Type)
+
+	ok := len(tk.Children) == 0 && (id == noSymbol || t.automaton.IsTerminal(id))
+	return ok
+}
+
+// firstLeaf returns the first terminal of the given tree.
+//
+// Parameters:
+//   - tk: The tree. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The first terminal, or nil if the tree has none.
+func (t TableOf[K]) firstLeaf(tk *slgr.TokenOf[K]) *slgr.TokenOf[K] {
+	if t.isLeaf(tk) {
+		return tk
+	}
+
+	for _, child := range tk.Children {
+		leaf := t.firstLeaf(child)
+		if leaf != nil {
+			return leaf
+		}
+	}
+
+	return nil
+}
+
+// lastLeaf returns the last terminal of the given tree.
+//
+// Parameters:
+//   - tk: The tree. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The last terminal, or nil if the tree has none.
+func (t TableOf[K]) lastLeaf(tk *slgr.TokenOf[K]) *slgr.TokenOf[K] {
+	if t.isLeaf(tk) {
+		return tk
+	}
+
+	for i := len(tk.Children) - 1; i >= 0; i-- {
+		leaf := t.lastLeaf(tk.Children[i])
+		if leaf != nil {
+			return leaf
+		}
+	}
+
+	return nil
+}
+
+// reuser splits the previous parse tree into the input of an incremental
+// parser.
+type reuser[K slgr.Kind] struct {
+	// table is the parsing table.
+	table *TableOf[K]
+
+	// last_prefix is the last unchanged token before the edit, or nil.
+	last_prefix *slgr.TokenOf[K]
+
+	// first_suffix is the first unchanged token after the edit, or nil.
+	first_suffix *slgr.TokenOf[K]
+
+	// middle is the new tokens that replace the changed ones.
+	middle []*slgr.TokenOf[K]
+
+	// phase is 0 before the edit, 1 within the edit and 2 after the edit.
+	phase int
+
+	// items is the input of the incremental parser, in order.
+	items []reuseItem[K]
+}
+
+// enter emits the new tokens and moves to the changed region.
+func (r *reuser[K]) enter() {
+	r.phase = 1
+
+	for _, tk := range r.middle {
+		r.items = append(r.items, reuseItem[K]{node: tk, state: -1})
+	}
+}
+
+// before checks whether the given tree certainly ends before the given token.
+//
+// Parameters:
+//   - tk: The tree. (Assumed to not be nil)
+//   - limit: The token. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the tree ends before the token, false if it does not or
+//     if it is not known.
+func (r reuser[K]) before(tk, limit *slgr.TokenOf[K]) bool {
+	last := r.table.lastLeaf(tk)
+
+	ok := last != nil && last.Pos.IsValid() && limit.Pos.IsValid() && last.Pos.Offset < limit.Pos.Offset
+	return ok
+}
+
+// visit splits the given subtree of the previous parse tree.
+//
+// Parameters:
+//   - tk: The subtree. (Assumed to not be nil)
+//   - state: The state the previous parser was in before the subtree.
+func (r *reuser[K]) visit(tk *slgr.TokenOf[K], state int) {
+	is_leaf := r.table.isLeaf(tk)
+
+	switch r.phase {
+	case 0:
+		if is_leaf {
+			r.items = append(r.items, reuseItem[K]{node: tk, state: state})
+
+			if tk == r.last_prefix {
+				r.enter()
+			}
+
+			return
+		} else if r.before(tk, r.last_prefix) {
+			// The lookahead of the subtree is unchanged too.
+			r.items = append(r.items, reuseItem[K]{node: tk, state: state})
+			return
+		}
+	case 1:
+		if r.first_suffix == nil {
+			return
+		} else if r.table.firstLeaf(tk) == r.first_suffix {
+			r.phase = 2
+			r.items = append(r.items, reuseItem[K]{node: tk, state: state, suffix: true})
+
+			return
+		} else if is_leaf || r.before(tk, r.first_suffix) {
+			// Changed.
+			return
+		}
+	case 2:
+		r.items = append(r.items, reuseItem[K]{node: tk, state: state, suffix: true})
+		return
+	}
+
+	for _, child := range tk.Children {
+		r.visit(child, state)
+
+		state = r.table.transition(state, child.Type)
+	}
+}
+
+// transition returns the state reached from the given state after the given
+// symbol.
+//
+// Parameters:
+//   - state: The state, or -1 if it is not known.
+//   - symbol: The symbol.
+//
+// Returns:
+//   - int: The state reached, or -1 if there is none.
+func (t TableOf[K]) transition(state int, symbol K) int {
+	if state < 0 {
+		return -1
+	}
+
+	next, ok := t.automaton.States[state].Transitions[t.id(symbol)]
+	if !ok {
+		return -1
+	}
+
+	return next
+}
+
+// suffixer replaces the leaves of the reused subtrees that come after the edit
+// by the new tokens, in order.
+type suffixer[K slgr.Kind] struct {
+	// table is the parsing table.
+	table *TableOf[K]
+
+	// tokens is the new tokens that have not been used yet.
+	tokens []*slgr.TokenOf[K]
+}
+
+// leaf returns the new token of the given leaf.
+//
+// Parameters:
+//   - tk: The leaf of the previous parse tree. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The new token.
+func (s *suffixer[K]) leaf(tk *slgr.TokenOf[K]) *slgr.TokenOf[K] {
+	if tk.Type == s.table.eof || len(s.tokens) == 0 {
+		return tk
+	}
+
+	next := s.tokens[0]
+	s.tokens = s.tokens[1:]
+
+	return next
+}
+
+// clone copies the given subtree of the previous parse tree with the new
+// tokens as leaves.
+//
+// Parameters:
+//   - tk: The subtree. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The copy.
+func (s *suffixer[K]) clone(tk *slgr.TokenOf[K]) *slgr.TokenOf[K] {
+	if s.table.isLeaf(tk) {
+		return s.leaf(tk)
+	}
+
+	tk_copy := slgr.NewToken(tk.Type, tk.Data)
+
+	if len(tk.Children) > 0 {
+		tk_copy.Children = make([]*slgr.TokenOf[K], 0, len(tk.Children))
+	}
+
+	for _, child := range tk.Children {
+		tk_copy.Children = append(tk_copy.Children, s.clone(child))
+	}
+
+	for _, child := range tk_copy.Children {
+		if child.Pos.IsValid() {
+			tk_copy.Pos = child.Pos
+			break
+		}
+	}
+
+	return tk_copy
+}
+
+// reparse parses the given input, made of new tokens and subtrees of the
+// previous parse tree, with the parsing table.
+//
+// A subtree is shifted as a whole if, after the reductions triggered by its
+// first token, the parser is in the same state as the previous parser was
+// before the subtree; otherwise, it is broken down into its children.
+//
+// Parameters:
+//   - items: The input, in order.
+//   - sfx: The new tokens that replace the leaves after the edit.
+//
+// Returns:
+//   - error: An error if the input is not valid.
+func (p *ParserOf[K]) reparse(items []reuseItem[K], sfx *suffixer[K]) error {
+	assert.Cond(p != nil, "p != nil")
+	assert.Cond(p.table != nil, "p.table != nil")
+
+	syms := tokenStack[K]{p: p}
+
+	p.states = append(p.states[:0], 0)
+
+	// pending is the input, in reverse order.
+	pending := make([]reuseItem[K], 0, len(items))
+
+	for i := len(items) - 1; i >= 0; i-- {
+		pending = append(pending, items[i])
+	}
+
+	for {
+		err := p.check(len(p.states) - 1)
+		if err != nil {
+			return err
+		}
+
+		state := p.states[len(p.states)-1]
+
+		var item reuseItem[K]
+
+		la := etEnd
+
+		if len(pending) > 0 {
+			item = pending[len(pending)-1]
+
+			if p.table.isLeaf(item.node) {
+				la = p.table.id(item.node.Type)
+			} else if first := p.table.firstLeaf(item.node); first != nil {
+				la = p.table.id(first.Type)
+			} else {
+				// Empty subtrees are reduced again.
+				pending = pending[:len(pending)-1]
+				continue
+			}
+		}
+
+		act, ok := p.table.action(state, la)
+
+		if ok && act.kind == reduceEntry {
+			rule := p.table.rule(act.rule)
+
+			err := syms.reduce(act.rule)
+			if err != nil {
+				return fmt.Errorf("while reducing: %w", err)
+			}
+
+			p.states = p.states[:len(p.states)-len(rule.Rhss())]
+
+			next, ok := p.table.goTo(p.states[len(p.states)-1], rule.Lhs())
+			assert.Cond(ok, "p.table.goTo(state, rule.Lhs())")
+
+			p.states = append(p.states, next)
+
+			continue
+		} else if ok && act.kind == acceptEntry {
+			return nil
+		}
+
+		if len(pending) > 0 && !p.table.isLeaf(item.node) {
+			pending = pending[:len(pending)-1]
+
+			next, ok := p.table.goTo(state, p.table.id(item.node.Type))
+
+			if ok && item.state == state {
+				tk := item.node
+
+				if item.suffix {
+					tk = sfx.clone(tk)
+				}
+
+				err := p.Push(tk)
+				assert.Err(err, "p.Push(tk)")
+
+				p.states = append(p.states, next)
+
+				continue
+			}
+
+			// Break the subtree down.
+			child_state := item.state
+
+			children := make([]reuseItem[K], 0, len(item.node.Children))
+
+			for _, child := range item.node.Children {
+				children = append(children, reuseItem[K]{node: child, state: child_state, suffix: item.suffix})
+
+				child_state = p.table.transition(child_state, child.Type)
+			}
+
+			for i := len(children) - 1; i >= 0; i-- {
+				pending = append(pending, children[i])
+			}
+
+			continue
+		}
+
+		if !ok {
+			var tk *slgr.TokenOf[K]
+
+			if len(pending) > 0 {
+				tk = item.node
+			}
+
+			err := NewParseError(tk, state, p.table.Expected(state))
+			return err
+		}
+
+		pending = pending[:len(pending)-1]
+
+		tk := item.node
+
+		if item.suffix {
+			tk = sfx.leaf(tk)
+		}
+
+		err = syms.push(tk)
+		assert.Err(err, "syms.push(tk)")
+
+		p.states = append(p.states, act.state)
+	}
+}
+
+// Reparse parses an edited input stream, reusing the subtrees of the previous
+// parse tree that the edit did not affect.
+//
+// The tokens before the edit must be the same objects as in the previous
+// input stream, and the tokens after the edit must have the same types as in
+// it; typically, the tokens after the edit are the previous ones with their
+// positions shifted.
+//
+// The subtrees that come before the edit are shared with the previous tree.
+// The subtrees that come after the edit are not parsed again either, but they
+// are copied so that their leaves are the new tokens. Thus, only the part of
+// the input around the edit is parsed again; copying the subtrees after it
+// still takes time linear in their size, but it involves no parsing.
+//
+// If the edited input is not valid, or if the parser is not table-driven or
+// runs in GLR mode, the whole input is parsed again; see Parse.
+//
+// Parameters:
+//   - parser: The parser to be used to parse the input stream.
+//   - root: The previous parse tree. It must not have been flattened and it is
+//     not modified.
+//   - old_tokens: The previous input stream.
+//   - tokens: The edited input stream.
+//   - prefix: The number of tokens before the edit.
+//   - suffix: The number of tokens after the edit.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails.
+//
+// Errors:
+//   - common.ErrBadParam: If the prefix or the suffix is not valid.
+//   - any error returned by Parse.
+func Reparse[K slgr.Kind](parser *ParserOf[K], root *slgr.TokenOf[K], old_tokens, tokens []*slgr.TokenOf[K], prefix, suffix int) ([]*slgr.TokenOf[K], error) {
+	if parser == nil {
+		err := common.NewErrNilParam("parser")
+		return nil, err
+	} else if prefix < 0 || suffix < 0 || prefix+suffix > len(old_tokens) || prefix+suffix > len(tokens) {
+		err := common.NewErrBadParam("prefix", "does not fit the input streams")
+		return nil, err
+	}
+
+	if root == nil || parser.table == nil || parser.glr {
+		forest, err := Parse(parser, tokens)
+		return forest, err
+	}
+
+	r := &reuser[K]{
+		table:  parser.table,
+		middle: tokens[prefix : len(tokens)-suffix],
+	}
+
+	if prefix > 0 {
+		r.last_prefix = old_tokens[prefix-1]
+	} else {
+		r.phase = 1
+	}
+
+	last := parser.table.lastLeaf(root)
+
+	if suffix > 0 {
+		r.first_suffix = old_tokens[len(old_tokens)-suffix]
+	} else if last != nil && last.Type == parser.table.eof {
+		r.first_suffix = last
+	}
+
+	if r.phase == 1 {
+		r.enter()
+	}
+
+	r.visit(root, 0)
+
+	if last == nil || last.Type != parser.table.eof {
+		r.items = append(r.items, reuseItem[K]{node: slgr.NewToken(parser.table.eof, ""), state: -1})
+	}
+
+	sfx := &suffixer[K]{
+		table:  parser.table,
+		tokens: tokens[len(tokens)-suffix:],
+	}
+
+	var err error
+
+	if r.phase == 0 {
+		// The previous tree does not match the previous input stream.
+		err = errors.New("tree does not match the tokens")
+	} else {
+		err = parser.reparse(r.items, sfx)
+	}
+
+	if err != nil {
+		_ = parser.Reset()
+
+		forest, err := Parse(parser, tokens)
+		return forest, err
+	}
+
+	defer parser.Reset()
+
+	forest := parser.GetForest()
+	return forest, nil
+}
@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// mustParse parses the given tokens with the given table, or fails the test.
+func mustParse(t *testing.T, table *Table, tokens []*slgr.Token) *slgr.Token {
+	t.Helper()
+
+	forest, err := Parse(newParser(table), tokens)
+	if err != nil {
+		t.Fatalf("Parse() returned an error: %v", err)
+	} else if len(forest) != 1 {
+		t.Fatalf("Parse() returned %q, want one tree", shapes(forest))
+	}
+
+	return forest[0]
+}
+
+func TestReparse(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	old_tokens := newTokens("num", "plus", "num", "plus", "num")
+	root := mustParse(t, table, old_tokens)
+
+	// The second "num" becomes "op num cl".
+	tokens := append([]*slgr.Token{}, old_tokens[:2]...)
+	tokens = append(tokens, newTokens("op", "num", "cl")...)
+	tokens = append(tokens, newTokens("plus", "num")...)
+
+	forest, err := Reparse(newParser(table), root, old_tokens, tokens, 2, 2)
+	if err != nil {
+		t.Fatalf("Reparse() returned an error: %v", err)
+	} else if len(forest) != 1 {
+		t.Fatalf("Reparse() returned %q, want one tree", shapes(forest))
+	}
+
+	tree := forest[0]
+
+	if got, want := shape(tree), shape(mustParse(t, table, tokens)); got != want {
+		t.Errorf("Reparse() = %s, want %s", got, want)
+	}
+
+	// (E (E (E (T num)) plus ...) plus (T num))
+	if tree.Children[0].Children[0] != root.Children[0].Children[0] {
+		t.Errorf("the subtree before the edit is not shared with the previous tree")
+	}
+
+	got := leaves(tree)
+
+	for i, tk := range tokens {
+		if got[i] != tk {
+			t.Errorf("leaf #%d is not the new token", i)
+		}
+	}
+
+	if got := shape(root); got != "(E (E (E (T num)) plus (T num)) plus (T num))" {
+		t.Errorf("the previous tree was modified: %s", got)
+	}
+}
+
+func TestReparseInvalidEdit(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	old_tokens := newTokens("num", "plus", "num")
+	root := mustParse(t, table, old_tokens)
+
+	// The "plus" is deleted.
+	tokens := []*slgr.Token{old_tokens[0], old_tokens[2]}
+
+	_, err := Reparse(newParser(table), root, old_tokens, tokens, 1, 1)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("Reparse() returned %v, want a *ParseError", err)
+	}
+
+	_, err = Reparse(newParser(table), root, old_tokens, tokens, 2, 1)
+	if err == nil {
+		t.Errorf("Reparse() with a prefix and a suffix that overlap returned no error")
+	}
+}
@@ -0,0 +1,411 @@
+package internal
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Augmented is the left-hand side of the augmented rule "S' = S"; which is
+// not the id of any symbol of the grammar.
+const Augmented int = -1
+
+// Item is an LR(1) item with its set of lookaheads.
+type Item struct {
+	// Rule is the index of the rule of the item.
+	Rule int
+
+	// Dot is the position of the dot in the right-hand side of the rule.
+	Dot int
+
+	// Lookaheads is the sorted list of lookaheads of the item.
+	Lookaheads []int
+}
+
+// State is a state of the LALR(1) automaton.
+type State struct {
+	// Items is the closure of the state's kernel, in a deterministic order.
+	Items []*Item
+
+	// Transitions maps a symbol to the state reached after it.
+	Transitions map[int]int
+}
+
+// core identifies an LR(0) item.
+type core struct {
+	// rule is the index of the rule of the item.
+	rule int
+
+	// dot is the position of the dot in the right-hand side of the rule.
+	dot int
+}
+
+// lookaheads is a set of lookaheads for each LR(0) item.
+type lookaheads map[core]map[int]struct{}
+
+// add adds the given lookaheads to the item.
+//
+// Parameters:
+//   - c: The item to add the lookaheads to.
+//   - set: The lookaheads to add.
+//
+// Returns:
+//   - bool: True if at least one lookahead was added, false otherwise.
+func (l lookaheads) add(c core, set map[int]struct{}) bool {
+	prev, ok := l[c]
+	if !ok {
+		prev = make(map[int]struct{}, len(set))
+		l[c] = prev
+	}
+
+	changed := !ok
+
+	for la := range set {
+		_, ok := prev[la]
+		if !ok {
+			prev[la] = struct{}{}
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// sortedCores returns the items in a deterministic order.
+//
+// Returns:
+//   - []core: The sorted items.
+func (l lookaheads) sortedCores() []core {
+	cores := make([]core, 0, len(l))
+
+	for c := range l {
+		cores = append(cores, c)
+	}
+
+	slices.SortFunc(cores, func(a, b core) int {
+		if a.rule != b.rule {
+			return a.rule - b.rule
+		}
+
+		return a.dot - b.dot
+	})
+
+	return cores
+}
+
+// Automaton is the LALR(1) automaton of a grammar.
+type Automaton struct {
+	// Rules is the rules of the grammar. The first rule is the augmented rule
+	// "S' = S", where S is the start symbol.
+	Rules []*Rule
+
+	// States is the states of the automaton. The first state is the initial
+	// state.
+	States []*State
+
+	// by_lhs maps a non-terminal to the indices of its rules.
+	by_lhs map[int][]int
+
+	// nullable is the set of non-terminals that derive the empty string.
+	nullable map[int]bool
+
+	// first maps a non-terminal to its FIRST set.
+	first map[int]map[int]struct{}
+}
+
+// IsTerminal checks whether the given symbol is a terminal; that is, whether
+// it does not appear on the left-hand side of any rule.
+//
+// Parameters:
+//   - symbol: The symbol to check.
+//
+// Returns:
+//   - bool: True if the symbol is a terminal, false otherwise.
+func (a Automaton) IsTerminal(symbol int) bool {
+	_, ok := a.by_lhs[symbol]
+	return !ok
+}
+
+// computeFirst computes the nullable and FIRST sets of every non-terminal.
+func (a *Automaton) computeFirst() {
+	a.nullable = make(map[int]bool)
+	a.first = make(map[int]map[int]struct{})
+
+	for lhs := range a.by_lhs {
+		a.first[lhs] = make(map[int]struct{})
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, rule := range a.Rules[1:] {
+			set := a.first[rule.lhs]
+
+			nullable := true
+
+			for _, rhs := range rule.rhss {
+				if a.IsTerminal(rhs) {
+					_, ok := set[rhs]
+					if !ok {
+						set[rhs] = struct{}{}
+						changed = true
+					}
+
+					nullable = false
+					break
+				}
+
+				for la := range a.first[rhs] {
+					_, ok := set[la]
+					if !ok {
+						set[la] = struct{}{}
+						changed = true
+					}
+				}
+
+				if !a.nullable[rhs] {
+					nullable = false
+					break
+				}
+			}
+
+			if nullable && !a.nullable[rule.lhs] {
+				a.nullable[rule.lhs] = true
+				changed = true
+			}
+		}
+	}
+}
+
+// firstOf returns the FIRST set of the given sequence of symbols followed by
+// the given lookaheads.
+//
+// Parameters:
+//   - symbols: The sequence of symbols.
+//   - follow: The lookaheads that follow the sequence.
+//
+// Returns:
+//   - map[int]struct{}: The FIRST set.
+func (a Automaton) firstOf(symbols []int, follow map[int]struct{}) map[int]struct{} {
+	set := make(map[int]struct{})
+
+	for _, symbol := range symbols {
+		if a.IsTerminal(symbol) {
+			set[symbol] = struct{}{}
+			return set
+		}
+
+		for la := range a.first[symbol] {
+			set[la] = struct{}{}
+		}
+
+		if !a.nullable[symbol] {
+			return set
+		}
+	}
+
+	for la := range follow {
+		set[la] = struct{}{}
+	}
+
+	return set
+}
+
+// closure computes the closure of the given kernel.
+//
+// Parameters:
+//   - kernel: The kernel items with their lookaheads.
+//
+// Returns:
+//   - lookaheads: The closure of the kernel.
+func (a Automaton) closure(kernel lookaheads) lookaheads {
+	items := make(lookaheads, len(kernel))
+
+	for c, set := range kernel {
+		items.add(c, set)
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, c := range items.sortedCores() {
+			rule := a.Rules[c.rule]
+			if c.dot >= len(rule.rhss) {
+				continue
+			}
+
+			next := rule.rhss[c.dot]
+
+			indices, ok := a.by_lhs[next]
+			if !ok {
+				continue
+			}
+
+			set := a.firstOf(rule.rhss[c.dot+1:], items[c])
+
+			for _, idx := range indices {
+				if items.add(core{rule: idx, dot: 0}, set) {
+					changed = true
+				}
+			}
+		}
+	}
+
+	return items
+}
+
+// keyOf returns a key that uniquely identifies the cores of the given kernel.
+//
+// Parameters:
+//   - kernel: The kernel.
+//
+// Returns:
+//   - string: The key.
+func keyOf(kernel lookaheads) string {
+	var builder strings.Builder
+
+	for _, c := range kernel.sortedCores() {
+		_, _ = builder.WriteString(strconv.Itoa(c.rule))
+		_, _ = builder.WriteRune('.')
+		_, _ = builder.WriteString(strconv.Itoa(c.dot))
+		_, _ = builder.WriteRune(';')
+	}
+
+	str := builder.String()
+	return str
+}
+
+// NewAutomaton builds the LALR(1) automaton of the given grammar.
+//
+// States with the same LR(0) core are merged as they are discovered, and the
+// lookaheads are propagated until a fixed point is reached; which yields the
+// same automaton as merging the canonical LR(1) collection.
+//
+// Parameters:
+//   - rules: The rules of the grammar. The left-hand side of the first rule is
+//     the start symbol. (Assumed to not be empty nor to contain nil rules)
+//   - end: The lookahead that signals the end of the input.
+//
+// Returns:
+//   - *Automaton: The automaton. Never returns nil.
+func NewAutomaton(rules []*Rule, end int) *Automaton {
+	augmented := NewRule(Augmented, []int{rules[0].lhs})
+
+	a := &Automaton{
+		Rules:  append([]*Rule{augmented}, rules...),
+		by_lhs: make(map[int][]int),
+	}
+
+	for i, rule := range a.Rules[1:] {
+		a.by_lhs[rule.lhs] = append(a.by_lhs[rule.lhs], i+1)
+	}
+
+	a.computeFirst()
+
+	initial := lookaheads{
+		core{rule: 0, dot: 0}: {end: {}},
+	}
+
+	kernels := []lookaheads{initial}
+	indices := map[string]int{keyOf(initial): 0}
+	transitions := []map[int]int{nil}
+
+	queue := []int{0}
+	queued := map[int]bool{0: true}
+
+	for len(queue) > 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		queued[idx] = false
+
+		items := a.closure(kernels[idx])
+
+		var symbols []int
+		nexts := make(map[int]lookaheads)
+
+		for _, c := range items.sortedCores() {
+			rule := a.Rules[c.rule]
+			if c.dot >= len(rule.rhss) {
+				continue
+			}
+
+			symbol := rule.rhss[c.dot]
+
+			next, ok := nexts[symbol]
+			if !ok {
+				next = make(lookaheads)
+				nexts[symbol] = next
+				symbols = append(symbols, symbol)
+			}
+
+			next.add(core{rule: c.rule, dot: c.dot + 1}, items[c])
+		}
+
+		if transitions[idx] == nil {
+			transitions[idx] = make(map[int]int, len(symbols))
+		}
+
+		for _, symbol := range symbols {
+			next := nexts[symbol]
+			key := keyOf(next)
+
+			target, ok := indices[key]
+			if !ok {
+				target = len(kernels)
+				indices[key] = target
+
+				kernels = append(kernels, next)
+				transitions = append(transitions, nil)
+
+				queue = append(queue, target)
+				queued[target] = true
+			} else {
+				changed := false
+
+				for c, set := range next {
+					if kernels[target].add(c, set) {
+						changed = true
+					}
+				}
+
+				if changed && !queued[target] {
+					queue = append(queue, target)
+					queued[target] = true
+				}
+			}
+
+			transitions[idx][symbol] = target
+		}
+	}
+
+	a.States = make([]*State, 0, len(kernels))
+
+	for i, kernel := range kernels {
+		items := a.closure(kernel)
+
+		state := &State{
+			Items:       make([]*Item, 0, len(items)),
+			Transitions: transitions[i],
+		}
+
+		for _, c := range items.sortedCores() {
+			las := make([]int, 0, len(items[c]))
+
+			for la := range items[c] {
+				las = append(las, la)
+			}
+
+			slices.Sort(las)
+
+			state.Items = append(state.Items, &Item{
+				Rule:       c.rule,
+				Dot:        c.dot,
+				Lookaheads: las,
+			})
+		}
+
+		a.States = append(a.States, state)
+	}
+
+	return a
+}
@@ -1,12 +1,13 @@
 package internal
 
-// Rule is a rule in the grammar.
+// Rule is a rule in the grammar, whose symbols are the ids the table gives
+// to the kinds of the grammar.
 type Rule struct {
 	// lhs is the left-hand side of the rule.
-	lhs string
+	lhs int
 
 	// rhss is the right-hand side of the rule.
-	rhss []string
+	rhss []int
 }
 
 // NewRule creates a new rule with the given left-hand side and right-hand side symbols.
@@ -17,7 +18,7 @@ type Rule struct {
 //
 // Returns:
 //   - *Rule: The newly created rule. Never returns nil.
-func NewRule(lhs string, rhss []string) *Rule {
+func NewRule(lhs int, rhss []int) *Rule {
 	rule := &Rule{
 		lhs:  lhs,
 		rhss: rhss,
@@ -29,14 +30,14 @@ func NewRule(lhs string, rhss []string) *Rule {
 // Rhss returns a copy of the right-hand side symbols of the rule.
 //
 // Returns:
-//   - []string: A copy of the right-hand side symbols of the rule. Returns nil
+//   - []int: A copy of the right-hand side symbols of the rule. Returns nil
 //     if the rule has no right-hand side symbols.
-func (r Rule) Rhss() []string {
+func (r Rule) Rhss() []int {
 	if len(r.rhss) == 0 {
 		return nil
 	}
 
-	rhss := make([]string, len(r.rhss))
+	rhss := make([]int, len(r.rhss))
 	copy(rhss, r.rhss)
 
 	return rhss
@@ -45,7 +46,7 @@ func (r Rule) Rhss() []string {
 // Lhs returns the left-hand side of the rule.
 //
 // Returns:
-//   - string: The left-hand side of the rule. Never returns nil.
-func (r Rule) Lhs() string {
+//   - int: The left-hand side of the rule.
+func (r Rule) Lhs() int {
 	return r.lhs
 }
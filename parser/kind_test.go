@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// sumKind is the typed kind of the tokens of the grammars of sums.
+type sumKind int
+
+const (
+	sumEOF sumKind = iota
+	sumError
+	sumNum
+	sumPlus
+	sumE
+)
+
+// String implements fmt.Stringer.
+func (k sumKind) String() string {
+	switch k {
+	case sumEOF:
+		return "eof"
+	case sumError:
+		return "error"
+	case sumNum:
+		return "num"
+	case sumPlus:
+		return "plus"
+	case sumE:
+		return "E"
+	default:
+		return "sumKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// typedSumRules is an unambiguous grammar of sums, over typed kinds.
+var typedSumRules = []*slgr.RuleOf[sumKind]{
+	slgr.NewRule(sumE, sumE, sumPlus, sumNum),
+	slgr.NewRule(sumE, sumNum),
+}
+
+// sumTokens returns one token per given kind, with consecutive positions on
+// the first line.
+func sumTokens(types ...sumKind) []*slgr.TokenOf[sumKind] {
+	tokens := make([]*slgr.TokenOf[sumKind], 0, len(types))
+	pos := slgr.StartPosition()
+
+	for _, type_ := range types {
+		tk := slgr.NewToken(type_, type_.String())
+		tk.Pos = pos
+
+		pos.Offset += 2
+		pos.Column += 2
+
+		tokens = append(tokens, tk)
+	}
+
+	return tokens
+}
+
+// mustSumTable builds the table of the given rules over typed kinds, or fails
+// the test.
+func mustSumTable(t *testing.T, rules ...*slgr.RuleOf[sumKind]) *TableOf[sumKind] {
+	t.Helper()
+
+	table, err := NewTableOf(rules, sumEOF, sumError)
+	if err != nil {
+		t.Fatalf("NewTableOf() returned an error: %v", err)
+	}
+
+	return table
+}
+
+func TestTypedKindParse(t *testing.T) {
+	table := mustSumTable(t, typedSumRules...)
+
+	if conflicts := table.Conflicts(); len(conflicts) != 0 {
+		t.Fatalf("Conflicts() = %v, want none", conflicts)
+	}
+
+	var builder BuilderOf[sumKind]
+
+	_ = builder.SetTable(table)
+
+	forest, err := builder.Compile().Parse(sumTokens(sumNum, sumPlus, sumNum))
+	if err != nil {
+		t.Fatalf("Parse() returned an error: %v", err)
+	}
+
+	if len(forest) != 1 {
+		t.Fatalf("Parse() returned %d trees, want 1", len(forest))
+	}
+
+	want := `(E 1:1:0 (E 1:1:0 (num "num" 1:1:0)) (plus "plus" 1:3:2) (num "num" 1:5:4))`
+	if got := slgr.EncodeSExpr(forest[0]); got != want {
+		t.Errorf("Parse() = %s, want %s", got, want)
+	}
+
+	_, err = Parse(builder.Build(), sumTokens(sumNum, sumNum))
+
+	var perr *ParseErrorOf[sumKind]
+
+	if !errors.As(err, &perr) {
+		t.Fatalf("Parse() returned %v, want a *ParseErrorOf[sumKind]", err)
+	} else if want := []sumKind{sumEOF, sumPlus}; !slices.Equal(perr.Expected, want) {
+		t.Errorf("Expected = %v, want %v", perr.Expected, want)
+	}
+
+	if got, want := err.Error(), "1:3: unexpected num, expected one of: eof, plus"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	if got := table.Expected(0); !slices.Equal(got, []sumKind{sumNum}) {
+		t.Errorf("Expected(0) = %v, want [num]", got)
+	}
+}
+
+func TestTypedKindRepairAndValue(t *testing.T) {
+	table := mustSumTable(t, typedSumRules...)
+
+	var builder BuilderOf[sumKind]
+
+	_ = builder.SetTable(table)
+	_ = builder.SetRepair(true)
+
+	_, err := Parse(builder.Build(), sumTokens(sumNum, sumPlus))
+
+	perrs := ParseErrorsOf[sumKind](err)
+	if len(perrs) != 1 || len(perrs[0].Repairs) != 1 {
+		t.Fatalf("Parse() returned %v, want one repaired error", err)
+	}
+
+	if r := perrs[0].Repairs[0]; r.Kind != InsertRepair || r.Symbol != sumNum || r.String() != "insert `num` at the end of the input" {
+		t.Errorf("Repairs[0] = %s", r)
+	}
+
+	var actions ActionsOf[sumKind, int]
+
+	_ = actions.SetShiftFn(func(tk *slgr.TokenOf[sumKind]) (int, error) {
+		if tk.Type != sumNum {
+			return 0, nil
+		}
+
+		return 1, nil
+	})
+
+	_ = actions.On(typedSumRules[0], func(rule *slgr.RuleOf[sumKind], args []int) (int, error) {
+		return args[0] + args[2], nil
+	})
+
+	_ = actions.SetDefault(func(rule *slgr.RuleOf[sumKind], args []int) (int, error) {
+		return args[0], nil
+	})
+
+	_ = builder.Reset()
+	_ = builder.SetTable(table)
+
+	n, err := ParseValue(builder.Build(), sumTokens(sumNum, sumPlus, sumNum, sumPlus, sumNum), &actions)
+	if err != nil || n != 3 {
+		t.Errorf("ParseValue() = %d, %v, want 3", n, err)
+	}
+}
+
+func TestTypedKindGLR(t *testing.T) {
+	table := mustSumTable(t,
+		slgr.NewRule(sumE, sumE, sumPlus, sumE),
+		slgr.NewRule(sumE, sumNum),
+	)
+
+	var builder BuilderOf[sumKind]
+
+	_ = builder.SetTable(table)
+	_ = builder.SetGLR(true)
+
+	forest, err := ParseForest(builder.Build(), sumTokens(sumNum, sumPlus, sumNum, sumPlus, sumNum))
+	if err != nil {
+		t.Fatalf("ParseForest() returned an error: %v", err)
+	}
+
+	if got := len(forest.Trees()); got != 2 {
+		t.Errorf("Trees() has %d trees, want 2", got)
+	}
+
+	if root := forest.Root(); root == nil || root.Symbol != sumE {
+		t.Errorf("Root() = %v, want a node of E", root)
+	}
+}
+
+func TestTypedKindParseOneFn(t *testing.T) {
+	// Reduces every number to an E and accepts as soon as the end of the
+	// input is the lookahead.
+	fn := func(p *ParserOf[sumKind]) (Action, error) {
+		top, err := p.Pop()
+		if err != nil {
+			return nil, err
+		}
+
+		switch top.Type {
+		case sumNum:
+			return NewReduceAction(sumE, sumNum), nil
+		case sumE:
+			if la := p.lookahead(); la == nil || la.Type == sumEOF {
+				return NewAcceptAction(sumE, sumE), nil
+			}
+
+			return NewShiftAction(), nil
+		default:
+			return nil, errors.New("unexpected " + top.Type.String())
+		}
+	}
+
+	var builder BuilderOf[sumKind]
+
+	_ = builder.SetParseOneFn(fn)
+
+	_, err := Parse(builder.Build(), sumTokens(sumNum))
+	if err == nil {
+		t.Errorf("Parse() without kinds returned no error")
+	}
+
+	_ = builder.SetKinds(sumEOF, sumError)
+
+	forest, err := Parse(builder.Build(), sumTokens(sumNum))
+	if err != nil {
+		t.Fatalf("Parse() returned an error: %v", err)
+	}
+
+	if len(forest) == 0 || forest[0].Type != sumE {
+		t.Errorf("Parse() = %v, want a tree of E", forest)
+	}
+}
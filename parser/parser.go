@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"slices"
@@ -8,26 +9,87 @@ import (
 
 	slgr "github.com/PlayerR9/SlParser/grammar"
 	"github.com/PlayerR9/SlParser/mygo-lib/common"
-	"github.com/PlayerR9/SlParser/parser/internal"
 	lls "github.com/PlayerR9/mygo-data/stack"
 
 	assert "github.com/PlayerR9/go-verify"
 )
 
-// Parser is a parser that can be used to parse input data into a list of tokens.
-type Parser struct {
+// ParserOf is a parser that can be used to parse input data, whose tokens are
+// of kind K, into a list of tokens.
+type ParserOf[K slgr.Kind] struct {
 	// tokens is the list of tokens to be parsed.
-	tokens []*slgr.Token
+	tokens []*slgr.TokenOf[K]
+
+	// source is the source the tokens are pulled from, if any. It is nil once
+	// exhausted.
+	source TokenSourceOf[K]
+
+	// source_err is the error the source failed with, if any.
+	source_err error
 
 	// parse_one_fn is the function used to parse one token from the list of tokens.
-	parse_one_fn ParseOneFn
+	parse_one_fn ParseOneFnOf[K]
+
+	// table is the parsing table, if the parser is table-driven.
+	table *TableOf[K]
+
+	// eof is the kind of the end-of-input token.
+	eof K
+
+	// error_ is the kind of the error pseudo-terminal.
+	error_ K
+
+	// has_kinds is true if eof and error_ are known.
+	has_kinds bool
 
 	// stack is the stack of tokens that are currently being parsed.
-	stack *lls.RefusableStack[*slgr.Token]
+	stack *lls.RefusableStack[*slgr.TokenOf[K]]
+
+	// states is the stack of states of the table-driven parser.
+	states []int
+
+	// recovery is true if the parser recovers from errors in panic mode.
+	recovery bool
+
+	// repair is true if the parser repairs the input on errors.
+	repair bool
+
+	// glr is true if the parser runs in GLR mode.
+	glr bool
+
+	// packed is the packed parse forest of the last GLR parsing process. It is
+	// kept across Reset.
+	packed *ForestOf[K]
+
+	// diagnostics is the list of errors the parser recovered from.
+	diagnostics []*ParseErrorOf[K]
+
+	// error_tk is the error token of the last recovery, if any.
+	error_tk *slgr.TokenOf[K]
+
+	// shifted is the number of tokens shifted since the last recovery.
+	shifted int
+
+	// max_stack_depth is the maximum depth of the stack, or 0 if there is no
+	// limit.
+	max_stack_depth int
+
+	// max_forest_size is the maximum size of the graph-structured stack and
+	// of the packed parse forest in GLR mode, or 0 if there is no limit.
+	max_forest_size int
+
+	// ctx is the context of the current parsing process, if any.
+	ctx context.Context
+
+	// tracer is the tracer of the parsing processes, if any.
+	tracer TracerOf[K]
 }
 
+// Parser is the parser of the string-based API.
+type Parser = ParserOf[string]
+
 // Reset implements common.Resetter.
-func (p *Parser) Reset() error {
+func (p *ParserOf[K]) Reset() error {
 	if p == nil {
 		return common.ErrNilReceiver
 	}
@@ -37,12 +99,28 @@ func (p *Parser) Reset() error {
 		p.tokens = nil
 	}
 
+	p.source = nil
+	p.source_err = nil
+
 	err := p.stack.Reset()
 	if err != nil {
 		err := fmt.Errorf("while resetting stack: %w", err)
 		return err
 	}
 
+	if len(p.states) > 0 {
+		clear(p.states)
+		p.states = nil
+	}
+
+	if len(p.diagnostics) > 0 {
+		clear(p.diagnostics)
+		p.diagnostics = nil
+	}
+
+	p.error_tk = nil
+	p.shifted = 0
+
 	return nil
 }
 
@@ -53,7 +131,7 @@ func (p *Parser) Reset() error {
 //
 // Returns:
 //   - error: An error if the receiver is nil.
-func (p *Parser) SetInputStream(tokens []*slgr.Token) error {
+func (p *ParserOf[K]) SetInputStream(tokens []*slgr.TokenOf[K]) error {
 	if p == nil {
 		return common.ErrNilReceiver
 	}
@@ -70,7 +148,7 @@ func (p *Parser) SetInputStream(tokens []*slgr.Token) error {
 //
 // Returns:
 //   - error: An error if the receiver is nil.
-func (p *Parser) Push(tk *slgr.Token) error {
+func (p *ParserOf[K]) Push(tk *slgr.TokenOf[K]) error {
 	if p == nil {
 		return common.ErrNilReceiver
 	}
@@ -87,9 +165,9 @@ func (p *Parser) Push(tk *slgr.Token) error {
 // Pop pops the top token off the stack.
 //
 // Returns:
-//   - *slgr.Token: The popped token, or nil if the stack is empty.
+//   - *slgr.TokenOf[K]: The popped token, or nil if the stack is empty.
 //   - error: An error if the receiver is nil or if the stack is empty.
-func (p *Parser) Pop() (*slgr.Token, error) {
+func (p *ParserOf[K]) Pop() (*slgr.TokenOf[K], error) {
 	if p == nil {
 		return nil, common.ErrNilReceiver
 	}
@@ -109,9 +187,14 @@ func (p *Parser) Pop() (*slgr.Token, error) {
 //
 // Returns:
 //   - error: An error if the receiver is nil or if the input stream is empty.
-func (p *Parser) shift() error {
+func (p *ParserOf[K]) shift() error {
 	assert.Cond(p != nil, "p != nil")
 
+	err := p.fill(1)
+	if err != nil {
+		return err
+	}
+
 	if len(p.tokens) == 0 {
 		return errors.New("no tokens to shift")
 	}
@@ -121,7 +204,7 @@ func (p *Parser) shift() error {
 
 	assert.Cond(tk != nil, "tk != nil")
 
-	err := p.Push(tk)
+	err = p.Push(tk)
 	assert.Err(err, "p.Push(tk)")
 
 	return nil
@@ -136,31 +219,29 @@ func (p *Parser) shift() error {
 // the function returns an error.
 //
 // Parameters:
-//   - lhs: The left-hand side symbol for the new token.
-//   - rhss: The right-hand side symbols to match against the stack.
+//   - rule: The rule to reduce. (Assumed to not be nil)
 //
 // Returns:
 //   - error: An error if the receiver is nil, if the stack is empty, or if the
 //     symbols do not match.
-func (p *Parser) reduce(rule *internal.Rule) error {
+func (p *ParserOf[K]) reduce(rule *slgr.RuleOf[K]) error {
 	assert.Cond(p != nil, "p != nil")
 	assert.Cond(rule != nil, "rule != nil")
 
-	rhss := rule.Rhss()
-	slices.Reverse(rhss)
+	for i := len(rule.Rhss) - 1; i >= 0; i-- {
+		rhs := rule.Rhss[i]
 
-	for _, rhs := range rhss {
 		tk, err := p.Pop()
 		if err != nil {
 			if err == lls.ErrEmptyStack {
-				err = fmt.Errorf("want %s, got nothing", strconv.Quote(rhs))
+				err = fmt.Errorf("want %s, got nothing", strconv.Quote(slgr.KindString(rhs)))
 			}
 
 			return err
 		}
 
 		if tk.Type != rhs {
-			err := fmt.Errorf("want %s, got %s", strconv.Quote(rhs), strconv.Quote(tk.Type))
+			err := NewParseError(tk, -1, []K{rhs})
 			return err
 		}
 	}
@@ -172,67 +253,381 @@ func (p *Parser) reduce(rule *internal.Rule) error {
 
 	// slices.Reverse(children)
 
-	lhs := rule.Lhs()
-
-	tk := slgr.NewToken(lhs, "")
+	tk := slgr.NewToken(rule.Lhs, "")
 
 	err = tk.AppendChildren(children)
 	assert.Err(err, "tk.AppendChildren(children)")
 
+	for _, child := range children {
+		if child.Pos.IsValid() {
+			tk.Pos = child.Pos
+			break
+		}
+	}
+
 	err = p.Push(tk)
 	assert.Err(err, "p.Push(tk)")
 
 	return nil
 }
 
+// lookahead returns the next token of the input stream without consuming it,
+// pulling it from the source if needed.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The next token, or nil if the input stream is empty or if
+//     the source failed.
+func (p *ParserOf[K]) lookahead() *slgr.TokenOf[K] {
+	err := p.fill(1)
+	if err != nil || len(p.tokens) == 0 {
+		return nil
+	}
+
+	return p.tokens[0]
+}
+
+// expected returns the terminals that the table-driven parser can shift, or
+// accept, in its current configuration.
+//
+// Because LALR(1) states merge the lookaheads of several contexts, a terminal
+// may have an action in the current state and yet be rejected after the
+// reductions it triggers; such terminals are not returned. The terminals the
+// current state shifts or accepts are known from the table; only the ones it
+// reduces on are checked against the stack.
+//
+// Returns:
+//   - []K: The list of acceptable terminals, sorted by name.
+func (p ParserOf[K]) expected() []K {
+	assert.Cond(p.table != nil, "p.table != nil")
+
+	state := p.states[len(p.states)-1]
+
+	shiftable := p.table.shiftable[state]
+	reducible := p.table.reducible[state]
+
+	expected := make([]int, 0, len(shiftable)+len(reducible))
+	expected = append(expected, shiftable...)
+
+	for _, symbol := range reducible {
+		if p.table.accepts(p.states, symbol) {
+			expected = append(expected, symbol)
+		}
+	}
+
+	if len(reducible) > 0 && len(shiftable) > 0 {
+		slices.Sort(expected)
+	}
+
+	kinds := p.table.kinds(expected)
+	return kinds
+}
+
+// parseTable parses the input stream of tokens using the parsing table.
+//
+// Parameters:
+//   - syms: The stack of symbols the parser works on. (Assumed to not be nil)
+//
+// Returns:
+//   - error: An error if the parsing process fails.
+//
+// Errors:
+//   - *ParseErrorOf[K]: If the input stream is not valid according to the table.
+//   - errors.Join(*ParseError...): If the parser recovered from errors or
+//     repaired the input. See ParseErrors.
+func (p *ParserOf[K]) parseTable(syms symbolStack[K]) error {
+	assert.Cond(p != nil, "p != nil")
+	assert.Cond(p.table != nil, "p.table != nil")
+	assert.Cond(syms != nil, "syms != nil")
+
+	p.states = append(p.states[:0], 0)
+	p.diagnostics = p.diagnostics[:0]
+	p.error_tk = nil
+
+	p.trace(StartEvent, nil, nil, nil)
+
+	for {
+		err := p.check(len(p.states) - 1)
+		if err != nil {
+			p.trace(ErrorEvent, p.peek(), nil, err)
+			return err
+		}
+
+		state := p.states[len(p.states)-1]
+
+		la := p.lookahead()
+		if p.source_err != nil {
+			p.trace(ErrorEvent, nil, nil, p.source_err)
+			return p.source_err
+		}
+
+		type_ := etEnd
+		if la != nil {
+			type_ = p.table.id(la.Type)
+		}
+
+		act, ok := p.table.action(state, type_)
+		if !ok {
+			err := NewParseError(la, state, p.expected())
+			p.trace(ErrorEvent, la, nil, err)
+
+			if !p.recovery && !p.repair {
+				return err
+			}
+
+			perr := err.(*ParseErrorOf[K])
+
+			if p.repair && p.repairFrom(perr) {
+				continue
+			}
+
+			if p.recovery {
+				ok, err := p.recoverFrom(perr, syms)
+				if err != nil {
+					p.trace(ErrorEvent, p.peek(), nil, err)
+					return err
+				} else if ok {
+					continue
+				}
+			}
+
+			if p.source_err != nil {
+				p.trace(ErrorEvent, nil, nil, p.source_err)
+				return p.source_err
+			}
+
+			if len(p.diagnostics) == 0 || p.diagnostics[len(p.diagnostics)-1] != perr {
+				p.diagnostics = append(p.diagnostics, perr)
+			}
+
+			return p.diagnosticsError()
+		}
+
+		switch act.kind {
+		case shiftEntry:
+			p.tokens = p.tokens[1:]
+
+			err := syms.push(la)
+			if err != nil {
+				err := fmt.Errorf("while shifting: %w", err)
+				p.trace(ErrorEvent, la, nil, err)
+
+				return err
+			}
+
+			p.states = append(p.states, act.state)
+			p.shifted++
+
+			p.trace(ShiftEvent, la, nil, nil)
+		case reduceEntry:
+			rule := p.table.rule(act.rule)
+
+			err := syms.reduce(act.rule)
+			if err != nil {
+				err := fmt.Errorf("while reducing: %w", err)
+				p.trace(ErrorEvent, la, nil, err)
+
+				return err
+			}
+
+			p.states = p.states[:len(p.states)-len(rule.Rhss())]
+
+			next, ok := p.table.goTo(p.states[len(p.states)-1], rule.Lhs())
+			assert.Cond(ok, "p.table.goTo(state, rule.Lhs())")
+
+			p.states = append(p.states, next)
+
+			p.trace(ReduceEvent, nil, p.table.rules[act.rule-1], nil)
+		case acceptEntry:
+			p.trace(AcceptEvent, nil, nil, nil)
+
+			return p.diagnosticsError()
+		}
+	}
+}
+
 // Parse parses the input stream of tokens into a single token.
 //
+// If the parser has a parsing table, the table drives the parsing process;
+// otherwise, the parsing function does. In GLR mode, a single parse tree of the
+// packed parse forest (see GetPackedForest) is pushed onto the stack: the one
+// that takes the first derivation of every ambiguous node. Use a Disambiguator
+// on the packed parse forest to choose among the trees instead.
+//
 // Returns:
-//   - *slgr.Token: The parsed token, or nil if the parsing process fails.
+//   - *slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
 //   - error: An error if the receiver is nil or if the parsing process fails.
-func (p *Parser) Parse() error {
+//
+// Errors:
+//   - *ParseErrorOf[K]: If the input stream is not valid.
+//   - any other error: If the parsing process fails.
+func (p *ParserOf[K]) Parse() error {
 	if p == nil {
 		return common.ErrNilReceiver
 	}
 
+	p.packed = nil
+
+	if !p.has_kinds {
+		err := errors.New("no end-of-input kind; see Builder.SetKinds")
+		return err
+	}
+
+	if p.table != nil && p.glr {
+		forest, err := p.parseGLR()
+		if err != nil {
+			return err
+		}
+
+		p.packed = forest
+
+		tree := firstTree(forest.root, make(map[*ForestNodeOf[K]]bool))
+
+		err = p.Push(tree)
+		assert.Err(err, "p.Push(tree)")
+
+		return nil
+	} else if p.table != nil {
+		err := p.parseTable(tokenStack[K]{p: p})
+		return err
+	}
+
+	p.trace(StartEvent, nil, nil, nil)
+
+	tk := p.lookahead()
+
 	err := p.shift() // Initial shift.
 	if err != nil {
-		return errors.New("initial shift failed")
+		err := errors.New("initial shift failed")
+		p.trace(ErrorEvent, nil, nil, err)
+
+		return err
 	}
 
+	p.trace(ShiftEvent, tk, nil, nil)
+
+	depth := 1
 	is_done := false
 
 	for !is_done {
+		err := p.check(depth)
+		if err != nil {
+			p.trace(ErrorEvent, p.peek(), nil, err)
+			return err
+		}
+
 		act, err := p.parse_one_fn(p)
 		assert.Err(p.stack.Refuse(), "p.stack.Refuse()")
 
 		if err != nil {
+			p.trace(ErrorEvent, p.peek(), nil, err)
 			return err
 		}
 
 		switch act := act.(type) {
 		case *ShiftAction:
+			tk := p.lookahead()
+
 			err := p.shift()
 			if err != nil {
-				return fmt.Errorf("while shifting: %w", err)
+				err := fmt.Errorf("while shifting: %w", err)
+				p.trace(ErrorEvent, tk, nil, err)
+
+				return err
 			}
-		case *ReduceAction:
+
+			p.trace(ShiftEvent, tk, nil, nil)
+
+			depth++
+		case *ReduceActionOf[K]:
 			err := p.reduce(act.rule)
 			if err != nil {
-				return fmt.Errorf("while reducing: %w", err)
+				err := fmt.Errorf("while reducing: %w", err)
+				p.trace(ErrorEvent, p.peek(), nil, err)
+
+				return err
 			}
-		case *AcceptAction:
+
+			p.trace(ReduceEvent, nil, act.rule, nil)
+
+			depth -= len(act.rule.Rhss) - 1
+		case *AcceptActionOf[K]:
 			err := p.reduce(act.rule)
 			if err != nil {
-				return fmt.Errorf("while reducing: %w", err)
+				err := fmt.Errorf("while reducing: %w", err)
+				p.trace(ErrorEvent, p.peek(), nil, err)
+
+				return err
 			}
 
+			p.trace(ReduceEvent, nil, act.rule, nil)
+
 			is_done = true
 		default:
-			return fmt.Errorf("unknown action type: %T", act)
+			err := fmt.Errorf("unknown action type: %T", act)
+			p.trace(ErrorEvent, p.peek(), nil, err)
+
+			return err
+		}
+	}
+
+	p.trace(AcceptEvent, nil, nil, nil)
+
+	return nil
+}
+
+// ParseContext is like Parse, but it stops as soon as the context is done.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//
+// Returns:
+//   - error: An error if the receiver is nil, if the parsing process fails or
+//     if the context is done.
+//
+// Errors:
+//   - ctx.Err(): If the context is done.
+//   - *common.ErrLimitExceeded: If the maximum stack depth is exceeded.
+//   - *ParseErrorOf[K]: If the input stream is not valid.
+//   - any other error: If the parsing process fails.
+func (p *ParserOf[K]) ParseContext(ctx context.Context) error {
+	if p == nil {
+		return common.ErrNilReceiver
+	}
+
+	p.ctx = ctx
+	defer func() { p.ctx = nil }()
+
+	err := p.Parse()
+	return err
+}
+
+// check checks that the context of the parsing process is not done and that
+// the stack is not too deep.
+//
+// Parameters:
+//   - depth: The current depth of the stack.
+//
+// Returns:
+//   - error: An error if the parsing process must stop.
+//
+// Errors:
+//   - ctx.Err(): If the context is done.
+//   - *common.ErrLimitExceeded: If the maximum stack depth is exceeded.
+func (p ParserOf[K]) check(depth int) error {
+	if p.ctx != nil {
+		select {
+		case <-p.ctx.Done():
+			err := p.ctx.Err()
+			return err
+		default:
 		}
 	}
 
+	if p.max_stack_depth > 0 && depth > p.max_stack_depth {
+		err := common.NewErrLimitExceeded("stack depth", p.max_stack_depth)
+		return err
+	}
+
 	return nil
 }
 
@@ -243,40 +638,47 @@ func (p *Parser) Parse() error {
 // not modify the internal state of the receiver.
 //
 // Returns:
-//   - []*slgr.Token: A slice of tokens in the parse forest.
-func (p Parser) GetForest() []*slgr.Token {
+//   - []*slgr.TokenOf[K]: A slice of tokens in the parse forest.
+func (p ParserOf[K]) GetForest() []*slgr.TokenOf[K] {
 	forest := p.stack.Slice()
 	return forest
 }
 
 // Parse parses the input stream of tokens using the provided parser.
 //
+// If the parser recovers from errors or repairs the input, the partial forest
+// is returned alongside the error, even if the parsing process could not
+// complete.
+//
 // Parameters:
 //   - parser: The parser to be used to parse the input stream.
 //   - tokens: The list of tokens to be used as the input stream.
 //
 // Returns:
-//   - []*slgr.Token: The parsed token, or nil if the parsing process fails.
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
 //   - error: An error if the receiver is nil or if the parsing process fails.
-func Parse(parser *Parser, tokens []*slgr.Token) ([]*slgr.Token, error) {
+func Parse[K slgr.Kind](parser *ParserOf[K], tokens []*slgr.TokenOf[K]) ([]*slgr.TokenOf[K], error) {
 	if parser == nil {
 		err := common.NewErrNilParam("parser")
 		return nil, err
 	}
 
-	defer parser.Reset()
-
-	eof_tk := slgr.NewToken(EtEOF, "")
-	tokens = append(tokens, eof_tk)
-
-	err := parser.SetInputStream(tokens)
-	assert.Err(err, "parser.SetInputStream(tokens)")
-
-	err = parser.Parse()
-	if err != nil {
-		return nil, err
-	}
+	forest, err := ParseFrom(parser, NewSliceSource(tokens))
+	return forest, err
+}
 
-	forest := parser.GetForest()
-	return forest, nil
+// ParseContext is like Parse, but it stops as soon as the context is done.
+// See Parser.ParseContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - parser: The parser to be used to parse the input stream.
+//   - tokens: The list of tokens to be used as the input stream.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails or if the context is done.
+func ParseContext[K slgr.Kind](ctx context.Context, parser *ParserOf[K], tokens []*slgr.TokenOf[K]) ([]*slgr.TokenOf[K], error) {
+	forest, err := ParseFromContext(ctx, parser, NewSliceSource(tokens))
+	return forest, err
 }
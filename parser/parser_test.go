@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+func TestParseCanceled(t *testing.T) {
+	table := mustTable(t, exprRules...)
+	tokens := numTokens("1", "+", "2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ParseContext(ctx, newParser(table), tokens)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseContext() returned %v, want context.Canceled", err)
+	}
+
+	_, err = ParseValueContext(ctx, newParser(table), tokens, sumActions(t))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseValueContext() returned %v, want context.Canceled", err)
+	}
+
+	_, err = ParseForestContext(ctx, newGLRParser(table), tokens)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("ParseForestContext() returned %v, want context.Canceled", err)
+	}
+}
+
+func TestParseMaxStackDepth(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetMaxStackDepth(4)
+
+	// Every parenthesis deepens the stack by two.
+	tokens := numTokens("(", "(", "(", "1", ")", ")", ")")
+
+	var le *common.ErrLimitExceeded
+
+	_, err := Parse(builder.Build(), tokens)
+	if !errors.As(err, &le) {
+		t.Errorf("Parse() returned %v, want a *common.ErrLimitExceeded", err)
+	}
+
+	_, err = ParseValue(builder.Build(), tokens, sumActions(t))
+	if !errors.As(err, &le) {
+		t.Errorf("ParseValue() returned %v, want a *common.ErrLimitExceeded", err)
+	}
+
+	_, err = Parse(builder.Build(), newTokens("op", "num", "cl"))
+	if err != nil {
+		t.Errorf("Parse() returned an error below the limit: %v", err)
+	}
+}
@@ -0,0 +1,268 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+const (
+	// RecoveryShifts is the number of tokens that must be shifted after a
+	// recovery before a new error is reported. Errors that occur earlier are
+	// assumed to be a consequence of the previous one and their lookahead is
+	// silently discarded.
+	RecoveryShifts int = 3
+)
+
+// canShiftError returns the state reached by shifting the error pseudo-terminal
+// in the given state.
+//
+// Parameters:
+//   - state: The state.
+//
+// Returns:
+//   - int: The state reached by shifting the error pseudo-terminal.
+//   - bool: True if the error pseudo-terminal can be shifted, false
+//     otherwise.
+func (p ParserOf[K]) canShiftError(state int) (int, bool) {
+	act, ok := p.table.action(state, p.table.id(p.error_))
+	if !ok || act.kind != shiftEntry {
+		return 0, false
+	}
+
+	return act.state, true
+}
+
+// discard consumes the lookahead and appends it to the current error token.
+//
+// Returns:
+//   - bool: True if the lookahead was discarded, false if it cannot be; that
+//     is, if the input is over or the lookahead is the end-of-input token.
+func (p *ParserOf[K]) discard() bool {
+	assert.Cond(p != nil, "p != nil")
+
+	la := p.lookahead()
+	if la == nil || la.Type == p.eof {
+		return false
+	}
+
+	p.tokens = p.tokens[1:]
+
+	if p.error_tk != nil {
+		p.error_tk.Children = append(p.error_tk.Children, la)
+	}
+
+	return true
+}
+
+// recoveryPlan finds how to recover from an error, without changing the
+// state of the parser: the state nearest to the top of the stack that can shift the
+// error pseudo-terminal, and the number of input tokens to discard after it
+// until the lookahead is acceptable. The input tokens are only pulled from
+// the source, if needed, to look at them.
+//
+// Returns:
+//   - int: The number of states to pop.
+//   - int: The state reached by shifting the error pseudo-terminal.
+//   - int: The number of input tokens to discard.
+//   - bool: True if the parser can recover, false otherwise.
+func (p *ParserOf[K]) recoveryPlan() (int, int, int, bool) {
+	assert.Cond(p != nil, "p != nil")
+
+	top := len(p.states) - 1
+
+	next, ok := p.canShiftError(p.states[top])
+
+	for !ok && top > 0 {
+		top--
+		next, ok = p.canShiftError(p.states[top])
+	}
+
+	if !ok {
+		return 0, 0, 0, false
+	}
+
+	for skip := 0; ; skip++ {
+		err := p.fill(skip + 1)
+		if err != nil {
+			return 0, 0, 0, false
+		}
+
+		type_ := etEnd
+		if skip < len(p.tokens) {
+			type_ = p.table.id(p.tokens[skip].Type)
+		}
+
+		_, ok := p.table.action(next, type_)
+		if ok {
+			return len(p.states) - 1 - top, next, skip, true
+		}
+
+		if type_ == etEnd || type_ == p.table.id(p.eof) {
+			return 0, 0, 0, false
+		}
+	}
+}
+
+// recoverFrom recovers from the given error in panic mode.
+//
+// The parser pops states until one can shift the error pseudo-terminal and
+// shifts an EtError token holding the popped tokens. Then, it discards the
+// input tokens, which are also added to the EtError token, until the lookahead
+// is acceptable. If it cannot recover, neither the stack nor the input are
+// changed; so that the partial result is the stack as it was at the error.
+//
+// Parameters:
+//   - perr: The error to recover from. (Assumed to not be nil)
+//   - syms: The stack of symbols the parser works on. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the parser recovered, false if it could not.
+//   - error: An error if the EtError token cannot be pushed onto the stack.
+func (p *ParserOf[K]) recoverFrom(perr *ParseErrorOf[K], syms symbolStack[K]) (bool, error) {
+	assert.Cond(p != nil, "p != nil")
+	assert.Cond(perr != nil, "perr != nil")
+	assert.Cond(syms != nil, "syms != nil")
+
+	if p.error_tk != nil && p.shifted < RecoveryShifts {
+		// Still recovering from the previous error.
+		ok := p.discard()
+		return ok, nil
+	}
+
+	p.diagnostics = append(p.diagnostics, perr)
+
+	pops, next, skip, ok := p.recoveryPlan()
+	if !ok {
+		return false, nil
+	}
+
+	var popped []*slgr.TokenOf[K]
+
+	for range pops {
+		tk, err := syms.pop()
+		assert.Err(err, "syms.pop()")
+
+		if tk != nil {
+			popped = append([]*slgr.TokenOf[K]{tk}, popped...)
+		}
+
+		p.states = p.states[:len(p.states)-1]
+	}
+
+	error_tk := slgr.NewToken(p.error_, "")
+	error_tk.Children = popped
+
+	if perr.Token != nil && perr.Token.Pos.IsValid() {
+		error_tk.Pos = perr.Token.Pos
+	}
+
+	for _, tk := range popped {
+		if tk.Pos.IsValid() {
+			error_tk.Pos = tk.Pos
+			break
+		}
+	}
+
+	err := syms.push(error_tk)
+	if err != nil {
+		err := fmt.Errorf("while shifting: %w", err)
+		return false, err
+	}
+
+	p.states = append(p.states, next)
+	p.error_tk = error_tk
+	p.shifted = 0
+
+	p.trace(ShiftEvent, error_tk, nil, nil)
+
+	for range skip {
+		ok := p.discard()
+		assert.Cond(ok, "p.discard()")
+	}
+
+	return true, nil
+}
+
+// Diagnostics returns the errors the parser recovered from during the last
+// parsing process.
+//
+// Returns:
+//   - []*ParseErrorOf[K]: The errors, in the order in which they occurred; or nil
+//     if there were none.
+func (p ParserOf[K]) Diagnostics() []*ParseErrorOf[K] {
+	if len(p.diagnostics) == 0 {
+		return nil
+	}
+
+	diagnostics := make([]*ParseErrorOf[K], len(p.diagnostics))
+	copy(diagnostics, p.diagnostics)
+
+	return diagnostics
+}
+
+// diagnosticsError returns an error that joins every diagnostic.
+//
+// Returns:
+//   - error: The joined error, or nil if there are no diagnostics.
+func (p ParserOf[K]) diagnosticsError() error {
+	if len(p.diagnostics) == 0 {
+		return nil
+	}
+
+	errs := make([]error, 0, len(p.diagnostics))
+
+	for _, d := range p.diagnostics {
+		errs = append(errs, d)
+	}
+
+	err := errors.Join(errs...)
+	return err
+}
+
+// ParseErrors returns every *ParseError contained in the given error, such as
+// the ones returned by Parse when the parser recovers from errors. See
+// ParseErrorsOf.
+//
+// Parameters:
+//   - err: The error to inspect.
+//
+// Returns:
+//   - []*ParseError: The parse errors, in order; or nil if there are none.
+func ParseErrors(err error) []*ParseError {
+	perrs := ParseErrorsOf[string](err)
+	return perrs
+}
+
+// ParseErrorsOf returns every *ParseErrorOf[K] contained in the given error,
+// such as the ones returned by Parse when the parser recovers from errors.
+//
+// Parameters:
+//   - err: The error to inspect.
+//
+// Returns:
+//   - []*ParseErrorOf[K]: The parse errors, in order; or nil if there are
+//     none.
+func ParseErrorsOf[K slgr.Kind](err error) []*ParseErrorOf[K] {
+	if err == nil {
+		return nil
+	}
+
+	switch inner := err.(type) {
+	case *ParseErrorOf[K]:
+		return []*ParseErrorOf[K]{inner}
+	case interface{ Unwrap() []error }:
+		var perrs []*ParseErrorOf[K]
+
+		for _, e := range inner.Unwrap() {
+			perrs = append(perrs, ParseErrorsOf[K](e)...)
+		}
+
+		return perrs
+	default:
+		perrs := ParseErrorsOf[K](errors.Unwrap(err))
+		return perrs
+	}
+}
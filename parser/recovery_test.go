@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// stmtRules is a grammar of statements that recovers from errors up to the
+// next semicolon.
+var stmtRules = []*slgr.Rule{
+	slgr.NewRule("S", "S", "Stmt"),
+	slgr.NewRule("S", "Stmt"),
+	slgr.NewRule("Stmt", "x", "semi"),
+	slgr.NewRule("Stmt", EtError, "semi"),
+}
+
+// newRecoveringParser returns a parser driven by the given table that recovers
+// from errors in panic mode.
+func newRecoveringParser(table *Table) *Parser {
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetRecovery(true)
+
+	return builder.Build()
+}
+
+// shapes returns the shape of each given tree.
+func shapes(forest []*slgr.Token) []string {
+	strs := make([]string, 0, len(forest))
+
+	for _, tk := range forest {
+		strs = append(strs, shape(tk))
+	}
+
+	return strs
+}
+
+func TestRecovery(t *testing.T) {
+	table := mustTable(t, stmtRules...)
+
+	tests := []struct {
+		name   string
+		input  []string
+		want   string
+		errors []string
+	}{
+		{
+			name:  "no error",
+			input: []string{"x", "semi", "x", "semi"},
+			want:  "(S (S (Stmt x semi)) (Stmt x semi))",
+		},
+		{
+			name:   "discarded token",
+			input:  []string{"x", "semi", "y", "semi", "x", "semi"},
+			want:   "(S (S (Stmt (EtError x semi y) semi)) (Stmt x semi))",
+			errors: []string{"y"},
+		},
+		{
+			name:   "popped and discarded tokens",
+			input:  []string{"x", "y", "y", "semi"},
+			want:   "(S (Stmt (EtError x y y) semi))",
+			errors: []string{"y"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forest, err := Parse(newRecoveringParser(table), newTokens(test.input...))
+
+			perrs := ParseErrors(err)
+			if err != nil && len(perrs) == 0 {
+				t.Fatalf("Parse() returned %v, want parse errors", err)
+			}
+
+			var got []string
+			for _, perr := range perrs {
+				got = append(got, perr.Token.Type)
+			}
+
+			if !slices.Equal(got, test.errors) {
+				t.Errorf("errors at %q, want at %q", got, test.errors)
+			}
+
+			if len(forest) != 1 {
+				t.Fatalf("Parse() returned %q, want one tree", shapes(forest))
+			}
+
+			if got := shape(forest[0]); got != test.want {
+				t.Errorf("tree = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRecoveryFailure(t *testing.T) {
+	// The grammar has no error production, so the parser cannot recover and
+	// the partial result is the stack as it was at the error.
+	table := mustTable(t, exprRules...)
+
+	forest, err := Parse(newRecoveringParser(table), newTokens("num", "plus", "num", "num"))
+
+	perrs := ParseErrors(err)
+	if len(perrs) != 1 {
+		t.Fatalf("Parse() returned %v, want one parse error", err)
+	} else if perrs[0].Token == nil || perrs[0].Token.Type != "num" {
+		t.Errorf("error at %v, want at the second num", perrs[0].Token)
+	}
+
+	// The forest lists the stack from its top.
+	got := shapes(forest)
+	want := []string{"num", "plus", "(E (T num))"}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("partial result = %q, want %q", got, want)
+	}
+}
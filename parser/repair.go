@@ -0,0 +1,451 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+const (
+	// MaxRepairCost is the maximum number of insertions, deletions and
+	// substitutions a repair can be made of.
+	MaxRepairCost int = 3
+
+	// RepairShifts is the number of input tokens that must be shifted after a
+	// repair for it to be accepted; unless the input is accepted before.
+	RepairShifts int = 3
+)
+
+// RepairKind is the kind of an edit of a repair.
+type RepairKind int
+
+const (
+	// InsertRepair inserts a terminal before a token.
+	InsertRepair RepairKind = iota
+
+	// DeleteRepair deletes a token.
+	DeleteRepair
+
+	// ReplaceRepair replaces a token with a terminal.
+	ReplaceRepair
+)
+
+// String implements fmt.Stringer.
+func (k RepairKind) String() string {
+	switch k {
+	case InsertRepair:
+		return "insert"
+	case DeleteRepair:
+		return "delete"
+	case ReplaceRepair:
+		return "replace"
+	default:
+		return "RepairKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// RepairOf is an edit of the input that lets the parser continue after an
+// error.
+type RepairOf[K slgr.Kind] struct {
+	// Kind is the kind of the edit.
+	Kind RepairKind
+
+	// Symbol is the terminal that is inserted or substituted. The zero value
+	// for deletions.
+	Symbol K
+
+	// Token is the token before which Symbol is inserted, or the token that is
+	// deleted or replaced. Nil, or the end-of-input token, if Symbol is
+	// inserted at the end of the input.
+	Token *slgr.TokenOf[K]
+
+	// eof is true if Token is the end-of-input token of the parser that made
+	// the repair.
+	eof bool
+}
+
+// Repair is the repair of the string-based API.
+type Repair = RepairOf[string]
+
+// atEnd checks whether the repair's token is at the end of the input; that
+// is, whether it is nil or the end-of-input token. Without a parser, only the
+// EtEOF token of the string-based API is known to be the end-of-input token.
+//
+// Returns:
+//   - bool: True if the token is at the end of the input, false otherwise.
+func (r RepairOf[K]) atEnd() bool {
+	ok := r.Token == nil || r.eof || any(r.Token.Type) == any(EtEOF)
+	return ok
+}
+
+// where returns the description of the position of the repair's token.
+//
+// Returns:
+//   - string: The position of the token.
+func (r RepairOf[K]) where() string {
+	if r.atEnd() {
+		return "the end of the input"
+	} else if !r.Token.Pos.IsValid() {
+		return "`" + slgr.KindString(r.Token.Type) + "`"
+	}
+
+	str := "line " + strconv.Itoa(r.Token.Pos.Line) + ", column " + strconv.Itoa(r.Token.Pos.Column)
+	return str
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"insert `<symbol>` before <where>"
+//	"delete `<type>` at <where>"
+//	"replace `<type>` at <where> with `<symbol>`"
+func (r RepairOf[K]) String() string {
+	var builder strings.Builder
+
+	switch r.Kind {
+	case InsertRepair:
+		_, _ = builder.WriteString("insert `")
+		_, _ = builder.WriteString(slgr.KindString(r.Symbol))
+
+		if r.atEnd() {
+			_, _ = builder.WriteString("` at ")
+		} else {
+			_, _ = builder.WriteString("` before ")
+		}
+
+		_, _ = builder.WriteString(r.where())
+	case DeleteRepair, ReplaceRepair:
+		var type_ string
+
+		if r.Token != nil {
+			type_ = slgr.KindString(r.Token.Type)
+		}
+
+		_, _ = builder.WriteString(r.Kind.String())
+		_, _ = builder.WriteString(" `")
+		_, _ = builder.WriteString(type_)
+		_, _ = builder.WriteString("` at ")
+		_, _ = builder.WriteString(r.where())
+
+		if r.Kind == ReplaceRepair {
+			_, _ = builder.WriteString(" with `")
+			_, _ = builder.WriteString(slgr.KindString(r.Symbol))
+			_, _ = builder.WriteRune('`')
+		}
+	default:
+		_, _ = builder.WriteString(r.Kind.String())
+	}
+
+	str := builder.String()
+	return str
+}
+
+// simulate runs the parsing table on the given stack of states as if the given
+// terminal was the lookahead, until it is shifted.
+//
+// Parameters:
+//   - states: The stack of states. It is not modified.
+//   - type_: The id of the lookahead.
+//
+// Returns:
+//   - []int: The stack of states after the terminal was shifted.
+//   - bool: True if the input was accepted instead.
+//   - bool: True if the terminal can be shifted or accepted, false otherwise.
+func (t TableOf[K]) simulate(states []int, type_ int) ([]int, bool, bool) {
+	states = slices.Clone(states)
+
+	for {
+		act, ok := t.action(states[len(states)-1], type_)
+		if !ok {
+			return nil, false, false
+		}
+
+		switch act.kind {
+		case shiftEntry:
+			states = append(states, act.state)
+			return states, false, true
+		case reduceEntry:
+			rule := t.rule(act.rule)
+
+			states = states[:len(states)-len(rule.Rhss())]
+
+			next, ok := t.goTo(states[len(states)-1], rule.Lhs())
+			assert.Cond(ok, "t.goTo(state, rule.Lhs())")
+
+			states = append(states, next)
+		case acceptEntry:
+			return states, true, true
+		}
+	}
+}
+
+// edit is a single edit of a candidate repair.
+type edit struct {
+	// kind is the kind of the edit.
+	kind RepairKind
+
+	// symbol is the id of the inserted or substituted terminal.
+	symbol int
+
+	// pos is the index, in the remaining input, of the token the edit applies
+	// to.
+	pos int
+}
+
+// candidate is a configuration explored while searching for a repair.
+type candidate struct {
+	// states is the stack of states.
+	states []int
+
+	// pos is the index of the next input token.
+	pos int
+
+	// edits is the edits made so far.
+	edits []edit
+
+	// shifts is the number of input tokens shifted since the last edit.
+	shifts int
+}
+
+// key returns a key that identifies the configuration of the candidate,
+// regardless of how it was reached.
+//
+// Returns:
+//   - string: The key.
+func (c candidate) key() string {
+	var builder strings.Builder
+
+	for _, state := range c.states {
+		_, _ = builder.WriteString(strconv.Itoa(state))
+		_, _ = builder.WriteRune(',')
+	}
+
+	_, _ = builder.WriteRune('|')
+	_, _ = builder.WriteString(strconv.Itoa(c.pos))
+	_, _ = builder.WriteRune('|')
+	_, _ = builder.WriteString(strconv.Itoa(c.shifts))
+
+	str := builder.String()
+	return str
+}
+
+// with returns a copy of the candidate with the given edit appended.
+//
+// Parameters:
+//   - states: The new stack of states.
+//   - pos: The new index of the next input token.
+//   - e: The edit.
+//
+// Returns:
+//   - *candidate: The new candidate. Never returns nil.
+func (c candidate) with(states []int, pos int, e edit) *candidate {
+	edits := make([]edit, len(c.edits), len(c.edits)+1)
+	copy(edits, c.edits)
+
+	next := &candidate{
+		states: states,
+		pos:    pos,
+		edits:  append(edits, e),
+		shifts: 0,
+	}
+
+	return next
+}
+
+// findRepair searches the cheapest sequence of insertions, deletions and
+// substitutions that lets the parser shift RepairShifts input tokens, or
+// accept the input, from its current configuration.
+//
+// Configurations are explored by increasing cost, where every edit costs 1 and
+// shifting an input token is free; among the repairs of minimal cost, the
+// first one found is chosen. Insertions are tried in alphabetical order,
+// before deletions and substitutions.
+//
+// Returns:
+//   - []edit: The edits of the repair, or nil if no repair costs at most
+//     MaxRepairCost.
+func (p ParserOf[K]) findRepair() []edit {
+	types := make([]int, 0, len(p.tokens))
+
+	for _, tk := range p.tokens {
+		types = append(types, p.table.id(tk.Type))
+	}
+
+	eof := p.table.id(p.eof)
+	error_ := p.table.id(p.error_)
+
+	typeAt := func(pos int) int {
+		if pos < len(types) {
+			return types[pos]
+		}
+
+		return etEnd
+	}
+
+	level := []*candidate{{states: slices.Clone(p.states)}}
+
+	for cost := 0; cost <= MaxRepairCost && len(level) > 0; cost++ {
+		var next []*candidate
+
+		seen := make(map[string]struct{})
+
+		for len(level) > 0 {
+			c := level[0]
+			level = level[1:]
+
+			key := c.key()
+
+			_, ok := seen[key]
+			if ok {
+				continue
+			}
+
+			seen[key] = struct{}{}
+
+			la := typeAt(c.pos)
+
+			// Shifting an input token is free.
+			states, accepted, ok := p.table.simulate(c.states, la)
+			if ok && len(c.edits) > 0 && (accepted || c.shifts+1 >= RepairShifts) {
+				return c.edits
+			} else if ok && !accepted {
+				level = append(level, &candidate{
+					states: states,
+					pos:    c.pos + 1,
+					edits:  c.edits,
+					shifts: c.shifts + 1,
+				})
+			}
+
+			if cost == MaxRepairCost {
+				continue
+			}
+
+			for _, symbol := range p.table.expected(c.states[len(c.states)-1]) {
+				if symbol == error_ || symbol == eof {
+					continue
+				}
+
+				states, accepted, ok := p.table.simulate(c.states, symbol)
+				if !ok || accepted {
+					continue
+				}
+
+				next = append(next, c.with(states, c.pos, edit{kind: InsertRepair, symbol: symbol, pos: c.pos}))
+			}
+
+			if la == etEnd || la == eof {
+				continue
+			}
+
+			next = append(next, c.with(c.states, c.pos+1, edit{kind: DeleteRepair, pos: c.pos}))
+
+			for _, symbol := range p.table.expected(c.states[len(c.states)-1]) {
+				if symbol == error_ || symbol == eof || symbol == la {
+					continue
+				}
+
+				states, accepted, ok := p.table.simulate(c.states, symbol)
+				if !ok || accepted {
+					continue
+				}
+
+				next = append(next, c.with(states, c.pos+1, edit{kind: ReplaceRepair, symbol: symbol, pos: c.pos}))
+			}
+		}
+
+		level = next
+	}
+
+	return nil
+}
+
+// repairFrom repairs the input so that the parser can continue after the
+// given error. The repair is recorded in the error, which is added to the
+// diagnostics.
+//
+// Parameters:
+//   - perr: The error to repair. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the input was repaired, false if no repair was found.
+func (p *ParserOf[K]) repairFrom(perr *ParseErrorOf[K]) bool {
+	assert.Cond(p != nil, "p != nil")
+	assert.Cond(perr != nil, "perr != nil")
+
+	// A repair looks at most this many tokens ahead.
+	err := p.fill(MaxRepairCost*(RepairShifts+1) + 1)
+	if err != nil {
+		return false
+	}
+
+	edits := p.findRepair()
+	if len(edits) == 0 {
+		return false
+	}
+
+	tokenAt := func(pos int) *slgr.TokenOf[K] {
+		if pos < len(p.tokens) {
+			return p.tokens[pos]
+		}
+
+		return nil
+	}
+
+	tokens := make([]*slgr.TokenOf[K], 0, len(p.tokens)+len(edits))
+	repairs := make([]*RepairOf[K], 0, len(edits))
+
+	var i int
+
+	for _, e := range edits {
+		for ; i < e.pos; i++ {
+			tokens = append(tokens, p.tokens[i])
+		}
+
+		tk := tokenAt(e.pos)
+
+		r := &RepairOf[K]{
+			Kind:  e.kind,
+			Token: tk,
+			eof:   tk != nil && tk.Type == p.eof,
+		}
+
+		if e.kind != DeleteRepair {
+			r.Symbol = p.table.symbols[e.symbol]
+		}
+
+		repairs = append(repairs, r)
+
+		switch e.kind {
+		case InsertRepair:
+			inserted := slgr.NewToken(r.Symbol, "")
+
+			if tk != nil {
+				inserted.Pos = tk.Pos
+			}
+
+			tokens = append(tokens, inserted)
+		case DeleteRepair:
+			i++
+		case ReplaceRepair:
+			replaced := slgr.NewToken(r.Symbol, tk.Data)
+			replaced.Pos = tk.Pos
+
+			tokens = append(tokens, replaced)
+			i++
+		}
+	}
+
+	tokens = append(tokens, p.tokens[i:]...)
+
+	p.tokens = tokens
+
+	perr.Repairs = repairs
+	p.diagnostics = append(p.diagnostics, perr)
+
+	return true
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// newRepairingParser returns a parser driven by the given table that repairs
+// the input on errors.
+func newRepairingParser(table *Table) *Parser {
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetRepair(true)
+
+	return builder.Build()
+}
+
+func TestRepair(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	tests := []struct {
+		name    string
+		input   []string
+		repairs []string
+		want    string
+	}{
+		{
+			name:    "extra operator",
+			input:   []string{"num", "plus", "plus", "num"},
+			repairs: []string{"delete `plus` at line 1, column 10"},
+			want:    "(E (E (T num)) plus (T num))",
+		},
+		{
+			name:    "wrong operator",
+			input:   []string{"num", "op", "num"},
+			repairs: []string{"replace `op` at line 1, column 5 with `plus`"},
+			want:    "(E (E (T num)) plus (T num))",
+		},
+		{
+			name:    "unclosed parenthesis",
+			input:   []string{"op", "num"},
+			repairs: []string{"insert `cl` at the end of the input"},
+			want:    "(E (T op (E (T num)) cl))",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			forest, err := Parse(newRepairingParser(table), newTokens(test.input...))
+
+			perrs := ParseErrors(err)
+			if len(perrs) != 1 {
+				t.Fatalf("Parse() returned %v, want one parse error", err)
+			}
+
+			var repairs []string
+			for _, r := range perrs[0].Repairs {
+				repairs = append(repairs, r.String())
+			}
+
+			if len(repairs) != len(test.repairs) {
+				t.Fatalf("Repairs = %q, want %q", repairs, test.repairs)
+			}
+
+			for i, r := range repairs {
+				if r != test.repairs[i] {
+					t.Errorf("Repairs[%d] = %q, want %q", i, r, test.repairs[i])
+				}
+			}
+
+			if len(forest) != 1 {
+				t.Fatalf("Parse() returned %q, want one tree", shapes(forest))
+			}
+
+			if got := shape(forest[0]); got != test.want {
+				t.Errorf("tree = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRepairString(t *testing.T) {
+	tk := slgr.NewToken("num", "1")
+
+	tests := []struct {
+		repair Repair
+		want   string
+	}{
+		{Repair{Kind: InsertRepair, Symbol: "plus", Token: tk}, "insert `plus` before `num`"},
+		{Repair{Kind: InsertRepair, Symbol: "cl"}, "insert `cl` at the end of the input"},
+		{Repair{Kind: InsertRepair, Symbol: "cl", Token: slgr.NewToken(EtEOF, "")}, "insert `cl` at the end of the input"},
+		{Repair{Kind: DeleteRepair, Token: tk}, "delete `num` at `num`"},
+		{Repair{Kind: ReplaceRepair, Symbol: "op", Token: tk}, "replace `num` at `num` with `op`"},
+	}
+
+	for _, test := range tests {
+		if got := test.repair.String(); got != test.want {
+			t.Errorf("String() = %q, want %q", got, test.want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// ShiftFnOf is the function that turns a shifted token into a value.
+//
+// Parameters:
+//   - tk: The shifted token. Never nil. Its type is the error kind of the
+//     parser (EtError in the string-based API) when it recovers from an error.
+//
+// Returns:
+//   - V: The value of the token.
+//   - error: An error if the token cannot be turned into a value.
+type ShiftFnOf[K slgr.Kind, V any] func(tk *slgr.TokenOf[K]) (V, error)
+
+// ShiftFn is the shift function of the string-based API.
+type ShiftFn[V any] = ShiftFnOf[string, V]
+
+// ReduceFnOf is the semantic action of a rule; that is, the function that
+// computes the value of the left-hand side from the values of the right-hand
+// side (the "$$ = ..." of yacc).
+//
+// Parameters:
+//   - rule: The reduced rule. Never nil.
+//   - args: The values of the right-hand side symbols, in order ($1, $2, ...).
+//     The slice belongs to the parser and must not be retained.
+//
+// Returns:
+//   - V: The value of the left-hand side.
+//   - error: An error if the value cannot be computed.
+type ReduceFnOf[K slgr.Kind, V any] func(rule *slgr.RuleOf[K], args []V) (V, error)
+
+// ReduceFn is the semantic action of the string-based API.
+type ReduceFn[V any] = ReduceFnOf[string, V]
+
+// ActionsOf is the set of semantic actions of a table-driven parser. An empty
+// ActionsOf is ready to use.
+type ActionsOf[K slgr.Kind, V any] struct {
+	// shift is the function that turns shifted tokens into values.
+	shift ShiftFnOf[K, V]
+
+	// reduce maps a rule of the table to its semantic action.
+	reduce map[*slgr.RuleOf[K]]ReduceFnOf[K, V]
+
+	// fallback is the semantic action of the rules that have none.
+	fallback ReduceFnOf[K, V]
+}
+
+// Actions is the set of semantic actions of the string-based API.
+type Actions[V any] = ActionsOf[string, V]
+
+// SetShiftFn sets the function that turns shifted tokens into values. If
+// none is set, every token has the zero value.
+//
+// Parameters:
+//   - fn: The shift function.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (a *ActionsOf[K, V]) SetShiftFn(fn ShiftFnOf[K, V]) error {
+	if a == nil {
+		return common.ErrNilReceiver
+	}
+
+	a.shift = fn
+
+	return nil
+}
+
+// On sets the semantic action of the given rule. Rules are matched by
+// identity, so the rule must be one of the rules the table was built from.
+//
+// Parameters:
+//   - rule: The rule.
+//   - fn: The semantic action.
+//
+// Returns:
+//   - error: An error if the receiver is nil or if the rule or the function
+//     is nil.
+func (a *ActionsOf[K, V]) On(rule *slgr.RuleOf[K], fn ReduceFnOf[K, V]) error {
+	if a == nil {
+		return common.ErrNilReceiver
+	} else if rule == nil {
+		err := common.NewErrNilParam("rule")
+		return err
+	} else if fn == nil {
+		err := common.NewErrNilParam("fn")
+		return err
+	}
+
+	if a.reduce == nil {
+		a.reduce = make(map[*slgr.RuleOf[K]]ReduceFnOf[K, V])
+	}
+
+	a.reduce[rule] = fn
+
+	return nil
+}
+
+// SetDefault sets the semantic action of the rules that have none. If none is
+// set, such rules take the value of their first right-hand side symbol
+// ("$$ = $1"), or the zero value if their right-hand side is empty.
+//
+// Parameters:
+//   - fn: The default semantic action.
+//
+// Returns:
+//   - error: An error if the receiver is nil.
+func (a *ActionsOf[K, V]) SetDefault(fn ReduceFnOf[K, V]) error {
+	if a == nil {
+		return common.ErrNilReceiver
+	}
+
+	a.fallback = fn
+
+	return nil
+}
+
+// valueStack is the symbolStack that runs semantic actions on a stack of
+// values, without building a parse tree.
+type valueStack[K slgr.Kind, V any] struct {
+	// p is the parser.
+	p *ParserOf[K]
+
+	// actions is the semantic actions.
+	actions *ActionsOf[K, V]
+
+	// values is the stack of values.
+	values []V
+}
+
+// push implements symbolStack.
+func (vs *valueStack[K, V]) push(tk *slgr.TokenOf[K]) error {
+	var value V
+
+	if vs.actions.shift != nil {
+		v, err := vs.actions.shift(tk)
+		if err != nil {
+			return err
+		}
+
+		value = v
+	}
+
+	vs.values = append(vs.values, value)
+
+	return nil
+}
+
+// reduce implements symbolStack.
+func (vs *valueStack[K, V]) reduce(idx int) error {
+	rule := vs.p.table.rules[idx-1]
+
+	n := len(rule.Rhss)
+	if n > len(vs.values) {
+		err := fmt.Errorf("want %d values, got %d", n, len(vs.values))
+		return err
+	}
+
+	args := vs.values[len(vs.values)-n:]
+
+	fn, ok := vs.actions.reduce[rule]
+	if !ok {
+		fn = vs.actions.fallback
+	}
+
+	var value V
+
+	if fn != nil {
+		v, err := fn(rule, args)
+		if err != nil {
+			err := fmt.Errorf("rule %s: %w", rule.String(), err)
+			return err
+		}
+
+		value = v
+	} else if n > 0 {
+		value = args[0]
+	}
+
+	clear(args)
+	vs.values = append(vs.values[:len(vs.values)-n], value)
+
+	return nil
+}
+
+// pop implements symbolStack.
+func (vs *valueStack[K, V]) pop() (*slgr.TokenOf[K], error) {
+	if len(vs.values) == 0 {
+		err := errors.New("no values to pop")
+		return nil, err
+	}
+
+	var zero V
+
+	vs.values[len(vs.values)-1] = zero
+	vs.values = vs.values[:len(vs.values)-1]
+
+	return nil, nil
+}
+
+// ParseValue parses the input stream of tokens using the provided parser and
+// runs the semantic actions at every reduction, instead of building a parse
+// tree.
+//
+// When the parser recovers from errors, the EtError token is given to the
+// shift function; unlike in a parse tree, it does not hold the discarded
+// tokens.
+//
+// Parameters:
+//   - parser: The parser to be used to parse the input stream. It must be
+//     table-driven; see Builder.SetTable. GLR mode is ignored.
+//   - tokens: The list of tokens to be used as the input stream.
+//   - actions: The semantic actions. If nil, every value is the zero value.
+//
+// Returns:
+//   - V: The value of the start symbol.
+//   - error: An error if the parsing process fails.
+//
+// Errors:
+//   - common.ErrBadParam: If the parser is nil or is not table-driven.
+//   - *ParseErrorOf[K]: If the input stream is not valid.
+//   - errors.Join(*ParseError...): If the parser recovered from errors or
+//     repaired the input. The value is still returned. See ParseErrors.
+//   - any other error: If a semantic action fails.
+func ParseValue[K slgr.Kind, V any](parser *ParserOf[K], tokens []*slgr.TokenOf[K], actions *ActionsOf[K, V]) (V, error) {
+	value, err := ParseValueContext(context.Background(), parser, tokens, actions)
+	return value, err
+}
+
+// ParseValueContext is like ParseValue, but it stops as soon as the context is
+// done. See Parser.ParseContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - parser: The parser to be used to parse the input stream. It must be
+//     table-driven; see Builder.SetTable. GLR mode is ignored.
+//   - tokens: The list of tokens to be used as the input stream.
+//   - actions: The semantic actions. If nil, every value is the zero value.
+//
+// Returns:
+//   - V: The value of the start symbol.
+//   - error: An error if the parsing process fails or if the context is done.
+//
+// Errors:
+//   - common.ErrBadParam: If the parser is nil or is not table-driven.
+//   - ctx.Err(): If the context is done.
+//   - *common.ErrLimitExceeded: If the maximum stack depth is exceeded.
+//   - *ParseErrorOf[K]: If the input stream is not valid.
+//   - errors.Join(*ParseError...): If the parser recovered from errors or
+//     repaired the input. The value is still returned. See ParseErrors.
+//   - any other error: If a semantic action fails.
+func ParseValueContext[K slgr.Kind, V any](ctx context.Context, parser *ParserOf[K], tokens []*slgr.TokenOf[K], actions *ActionsOf[K, V]) (V, error) {
+	var zero V
+
+	if parser == nil {
+		err := common.NewErrNilParam("parser")
+		return zero, err
+	} else if parser.table == nil {
+		err := common.NewErrBadParam("parser", "must be table-driven")
+		return zero, err
+	}
+
+	if actions == nil {
+		actions = new(ActionsOf[K, V])
+	}
+
+	defer parser.Reset()
+
+	parser.ctx = ctx
+	defer func() { parser.ctx = nil }()
+
+	err := parser.SetTokenSource(NewSliceSource(tokens))
+	assert.Err(err, "parser.SetTokenSource(source)")
+
+	vs := &valueStack[K, V]{
+		p:       parser,
+		actions: actions,
+	}
+
+	err = parser.parseTable(vs)
+	if err != nil && (!parser.recovery && !parser.repair || len(ParseErrorsOf[K](err)) == 0 || len(vs.values) == 0) {
+		return zero, err
+	}
+
+	value := vs.values[len(vs.values)-1]
+	return value, err
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// sumActions returns the semantic actions that evaluate exprRules.
+func sumActions(t *testing.T) *Actions[int] {
+	t.Helper()
+
+	var actions Actions[int]
+
+	_ = actions.SetShiftFn(func(tk *slgr.Token) (int, error) {
+		if tk.Type != "num" {
+			return 0, nil
+		}
+
+		n, err := strconv.Atoi(tk.Data)
+		return n, err
+	})
+
+	_ = actions.On(exprRules[0], func(rule *slgr.Rule, args []int) (int, error) {
+		return args[0] + args[2], nil
+	})
+
+	_ = actions.On(exprRules[3], func(rule *slgr.Rule, args []int) (int, error) {
+		return args[1], nil
+	})
+
+	_ = actions.SetDefault(func(rule *slgr.Rule, args []int) (int, error) {
+		return args[0], nil
+	})
+
+	return &actions
+}
+
+// numTokens returns the tokens of the given data, where numbers are of type
+// "num" and "+", "(" and ")" of type "plus", "op" and "cl".
+func numTokens(data ...string) []*slgr.Token {
+	types := map[string]string{"+": "plus", "(": "op", ")": "cl"}
+
+	tokens := make([]*slgr.Token, 0, len(data))
+
+	for _, d := range data {
+		type_, ok := types[d]
+		if !ok {
+			type_ = "num"
+		}
+
+		tokens = append(tokens, slgr.NewToken(type_, d))
+	}
+
+	return tokens
+}
+
+func TestParseValue(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	value, err := ParseValue(newParser(table), numTokens("1", "+", "(", "20", "+", "300", ")"), sumActions(t))
+	if err != nil {
+		t.Fatalf("ParseValue() returned an error: %v", err)
+	}
+
+	if value != 321 {
+		t.Errorf("ParseValue() = %d, want 321", value)
+	}
+}
+
+func TestParseValueErrors(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	_, err := ParseValue(newParser(table), numTokens("1", "+", "x"), sumActions(t))
+
+	var ne *strconv.NumError
+	if !errors.As(err, &ne) {
+		t.Errorf("ParseValue() returned %v, want the error of the shift function", err)
+	}
+
+	_, err = ParseValue(newParser(table), numTokens("1", "+"), sumActions(t))
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Errorf("ParseValue() returned %v, want a *ParseError", err)
+	}
+}
+
+func TestParseValueRecovery(t *testing.T) {
+	table := mustTable(t, stmtRules...)
+
+	errShift := errors.New("cannot shift an error")
+
+	var actions Actions[int]
+
+	_ = actions.SetShiftFn(func(tk *slgr.Token) (int, error) {
+		if tk.Type == EtError {
+			return 0, errShift
+		}
+
+		return 1, nil
+	})
+
+	_ = actions.SetDefault(func(rule *slgr.Rule, args []int) (int, error) {
+		var sum int
+
+		for _, arg := range args {
+			sum += arg
+		}
+
+		return sum, nil
+	})
+
+	// The shift function fails on the EtError token of the recovery.
+	_, err := ParseValue(newRecoveringParser(table), newTokens("x", "semi", "y", "semi"), &actions)
+	if !errors.Is(err, errShift) {
+		t.Errorf("ParseValue() returned %v, want the error of the shift function", err)
+	}
+}
@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"context"
+	"io"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// TokenSourceOf is a source of tokens the parser pulls from on demand, such as
+// a lexer.
+type TokenSourceOf[K slgr.Kind] interface {
+	// Next returns the next token of the source.
+	//
+	// Returns:
+	//   - *slgr.TokenOf[K]: The next token. Nil tokens are skipped.
+	//   - error: An error if the next token cannot be produced.
+	//
+	// Errors:
+	//   - io.EOF: If the source is exhausted.
+	//   - any other error: If the source fails.
+	Next() (*slgr.TokenOf[K], error)
+}
+
+// TokenSource is the token source of the string-based API.
+type TokenSource = TokenSourceOf[string]
+
+// ExpectingSourceOf is a TokenSourceOf that is told, before each token is pulled,
+// which terminals the parser accepts next; such as a lexer that resolves
+// context-dependent tokens.
+type ExpectingSourceOf[K slgr.Kind] interface {
+	TokenSourceOf[K]
+
+	// SetExpected sets the terminals the parser accepts next.
+	//
+	// Parameters:
+	//   - expected: The accepted terminals. If nil, they are unknown and every
+	//     terminal must be considered.
+	//
+	// Returns:
+	//   - error: An error if the terminals cannot be set.
+	SetExpected(expected []K) error
+}
+
+// ExpectingSource is the expecting source of the string-based API.
+type ExpectingSource = ExpectingSourceOf[string]
+
+// sliceSource is a TokenSource that yields the tokens of a slice.
+type sliceSource[K slgr.Kind] struct {
+	// tokens is the tokens that have not been yielded yet.
+	tokens []*slgr.TokenOf[K]
+}
+
+// Next implements TokenSource.
+func (s *sliceSource[K]) Next() (*slgr.TokenOf[K], error) {
+	if len(s.tokens) == 0 {
+		return nil, io.EOF
+	}
+
+	tk := s.tokens[0]
+	s.tokens = s.tokens[1:]
+
+	return tk, nil
+}
+
+// NewSliceSource creates a new TokenSource that yields the given tokens. The
+// slice is not copied nor modified.
+//
+// Parameters:
+//   - tokens: The tokens to yield.
+//
+// Returns:
+//   - TokenSourceOf[K]: The new source. Never returns nil.
+func NewSliceSource[K slgr.Kind](tokens []*slgr.TokenOf[K]) TokenSourceOf[K] {
+	return &sliceSource[K]{
+		tokens: tokens,
+	}
+}
+
+// SetTokenSource sets the source the parser pulls the input tokens from. An
+// end-of-input token is appended once the source is exhausted.
+//
+// Tokens are pulled only when the parser needs them, so syntax errors are
+// reported before the rest of the input is read. In GLR mode, however, the
+// whole source is read before parsing.
+//
+// Parameters:
+//   - source: The source of tokens.
+//
+// Returns:
+//   - error: An error if the receiver or the source is nil.
+func (p *ParserOf[K]) SetTokenSource(source TokenSourceOf[K]) error {
+	if p == nil {
+		return common.ErrNilReceiver
+	} else if source == nil {
+		err := common.NewErrNilParam("source")
+		return err
+	}
+
+	p.source = source
+	p.source_err = nil
+
+	return nil
+}
+
+// fill pulls tokens from the source until the input stream holds at least n
+// tokens or the source is exhausted.
+//
+// Parameters:
+//   - n: The number of tokens to hold. If negative, the whole source is read.
+//
+// Returns:
+//   - error: An error if the source fails. It is also kept in the parser.
+func (p *ParserOf[K]) fill(n int) error {
+	assert.Cond(p != nil, "p != nil")
+
+	if p.source_err != nil {
+		return p.source_err
+	}
+
+	for p.source != nil && (n < 0 || len(p.tokens) < n) {
+		if src, ok := p.source.(ExpectingSourceOf[K]); ok {
+			err := src.SetExpected(p.nextExpected())
+			if err != nil {
+				p.source_err = err
+				return err
+			}
+		}
+
+		tk, err := p.source.Next()
+		if err == io.EOF {
+			eof_tk := slgr.NewToken(p.eof, "")
+
+			// Sources that know where they are, such as lexers, locate the end
+			// of the input.
+			if src, ok := p.source.(interface{ Pos() slgr.Position }); ok {
+				eof_tk.Pos = src.Pos()
+			}
+
+			p.tokens = append(p.tokens, eof_tk)
+			p.source = nil
+		} else if err != nil {
+			p.source_err = err
+			return err
+		} else if tk != nil {
+			p.tokens = append(p.tokens, tk)
+		}
+	}
+
+	return nil
+}
+
+// nextExpected returns the terminals the parser accepts as the next token to
+// be pulled from the source.
+//
+// Returns:
+//   - []K: The accepted terminals, or nil if they are unknown; that is,
+//     if the parser is not table-driven, runs in GLR mode, or if the next
+//     token to be pulled is not the lookahead.
+func (p ParserOf[K]) nextExpected() []K {
+	if p.table == nil || p.glr || len(p.states) == 0 || len(p.tokens) > 0 {
+		return nil
+	}
+
+	expected := p.expected()
+	return expected
+}
+
+// ParseFrom parses the tokens pulled from the given source using the provided
+// parser. See Parse and Parser.SetTokenSource.
+//
+// Parameters:
+//   - parser: The parser to be used to parse the input stream.
+//   - source: The source of tokens.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails, or if the source fails.
+func ParseFrom[K slgr.Kind](parser *ParserOf[K], source TokenSourceOf[K]) ([]*slgr.TokenOf[K], error) {
+	forest, err := ParseFromContext(context.Background(), parser, source)
+	return forest, err
+}
+
+// ParseFromContext is like ParseFrom, but it stops as soon as the context is
+// done. See Parser.ParseContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - parser: The parser to be used to parse the input stream.
+//   - source: The source of tokens.
+//
+// Returns:
+//   - []*slgr.TokenOf[K]: The parsed token, or nil if the parsing process fails.
+//   - error: An error if the parsing process fails, if the source fails or if
+//     the context is done.
+func ParseFromContext[K slgr.Kind](ctx context.Context, parser *ParserOf[K], source TokenSourceOf[K]) ([]*slgr.TokenOf[K], error) {
+	if parser == nil {
+		err := common.NewErrNilParam("parser")
+		return nil, err
+	}
+
+	defer parser.Reset()
+
+	err := parser.SetTokenSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.ParseContext(ctx)
+	if err != nil && (parser.source_err != nil || !parser.recovery && !parser.repair || len(ParseErrorsOf[K](err)) == 0) {
+		return nil, err
+	}
+
+	forest := parser.GetForest()
+	return forest, err
+}
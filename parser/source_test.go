@@ -0,0 +1,132 @@
+package parser
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// countingSource is a TokenSource that yields the given tokens and counts how
+// many were pulled, then fails with err if it is not nil.
+type countingSource struct {
+	tokens []*slgr.Token
+	pulled int
+	err    error
+}
+
+// Next implements TokenSource.
+func (s *countingSource) Next() (*slgr.Token, error) {
+	if s.pulled == len(s.tokens) {
+		if s.err != nil {
+			return nil, s.err
+		}
+
+		return nil, io.EOF
+	}
+
+	tk := s.tokens[s.pulled]
+	s.pulled++
+
+	return tk, nil
+}
+
+func TestParseFrom(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	src := &countingSource{tokens: newTokens("num", "plus", "num")}
+
+	forest, err := ParseFrom(newParser(table), src)
+	if err != nil {
+		t.Fatalf("ParseFrom() returned an error: %v", err)
+	} else if len(forest) != 1 {
+		t.Fatalf("ParseFrom() returned %q, want one tree", shapes(forest))
+	}
+
+	if got, want := shape(forest[0]), "(E (E (T num)) plus (T num))"; got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+}
+
+func TestParseFromPullsOnDemand(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	// The error is at the second token; the rest of the input is not read.
+	src := &countingSource{tokens: newTokens("num", "num", "plus", "num", "plus", "num")}
+
+	_, err := ParseFrom(newParser(table), src)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("ParseFrom() returned %v, want a *ParseError", err)
+	}
+
+	if src.pulled != 2 {
+		t.Errorf("%d tokens were pulled, want 2", src.pulled)
+	}
+}
+
+func TestParseFromSourceError(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	errSource := errors.New("source failure")
+
+	src := &countingSource{tokens: newTokens("num", "plus"), err: errSource}
+
+	forest, err := ParseFrom(newParser(table), src)
+	if !errors.Is(err, errSource) {
+		t.Errorf("ParseFrom() returned %v, want the error of the source", err)
+	}
+
+	if forest != nil {
+		t.Errorf("ParseFrom() returned %q, want no tree", shapes(forest))
+	}
+}
+
+// expectingSource is a countingSource that records the terminals it is told
+// before each token is pulled.
+type expectingSource struct {
+	countingSource
+	expected [][]string
+}
+
+// SetExpected implements ExpectingSource.
+func (s *expectingSource) SetExpected(expected []string) error {
+	s.expected = append(s.expected, expected)
+	return nil
+}
+
+func TestParseFromExpectingSource(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	src := &expectingSource{
+		countingSource: countingSource{tokens: newTokens("op", "num", "cl")},
+	}
+
+	_, err := ParseFrom(newParser(table), src)
+	if err != nil {
+		t.Fatalf("ParseFrom() returned an error: %v", err)
+	}
+
+	// "cl" is a lookahead of the reductions after the first "num" but cannot
+	// follow it at the top level, and the reverse holds for EtEOF inside the
+	// parentheses.
+	want := [][]string{
+		{"num", "op"},
+		{"num", "op"},
+		{"cl", "plus"},
+		{"EtEOF", "plus"},
+	}
+
+	if len(src.expected) != len(want) {
+		t.Fatalf("SetExpected() was called with %q, want %q", src.expected, want)
+	}
+
+	for i, expected := range src.expected {
+		if !slices.Equal(expected, want[i]) {
+			t.Errorf("SetExpected() #%d = %q, want %q", i+1, expected, want[i])
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+// symbolStack is the stack of symbols a table-driven parser works on, next to
+// its stack of states.
+type symbolStack[K slgr.Kind] interface {
+	// push pushes a token shifted from the input, or an EtError token.
+	//
+	// Parameters:
+	//   - tk: The token to push. (Assumed to not be nil)
+	//
+	// Returns:
+	//   - error: An error if the token cannot be pushed.
+	push(tk *slgr.TokenOf[K]) error
+
+	// reduce replaces the symbols of the right-hand side of a rule, on top of
+	// the stack, with a single symbol.
+	//
+	// Parameters:
+	//   - idx: The index of the rule in the table's automaton.
+	//
+	// Returns:
+	//   - error: An error if the rule cannot be reduced.
+	reduce(idx int) error
+
+	// pop pops the top symbol of the stack.
+	//
+	// Returns:
+	//   - *slgr.TokenOf[K]: The token of the symbol, or nil if it has none.
+	//   - error: An error if the stack is empty.
+	pop() (*slgr.TokenOf[K], error)
+}
+
+// tokenStack is the symbolStack that builds a parse tree on the parser's stack
+// of tokens.
+type tokenStack[K slgr.Kind] struct {
+	// p is the parser.
+	p *ParserOf[K]
+}
+
+// push implements symbolStack.
+func (ts tokenStack[K]) push(tk *slgr.TokenOf[K]) error {
+	err := ts.p.Push(tk)
+	return err
+}
+
+// reduce implements symbolStack.
+func (ts tokenStack[K]) reduce(idx int) error {
+	err := ts.p.reduce(ts.p.table.rules[idx-1])
+	return err
+}
+
+// pop implements symbolStack.
+func (ts tokenStack[K]) pop() (*slgr.TokenOf[K], error) {
+	tk, err := ts.p.Pop()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ts.p.stack.Accept()
+	assert.Err(err, "ts.p.stack.Accept()")
+
+	return tk, nil
+}
@@ -0,0 +1,658 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+	"github.com/PlayerR9/SlParser/parser/internal"
+	assert "github.com/PlayerR9/go-verify"
+)
+
+const (
+	// etEnd is the id of the lookahead that is seen once every token,
+	// including the end-of-input token, has been consumed.
+	etEnd int = 0
+
+	// noSymbol is the id of the kinds that are not symbols of the grammar.
+	noSymbol int = -1
+)
+
+// entryKind is the kind of an entry of the action table.
+type entryKind int
+
+const (
+	// shiftEntry shifts the lookahead and goes to the entry's state.
+	shiftEntry entryKind = iota
+
+	// reduceEntry reduces the entry's rule.
+	reduceEntry
+
+	// acceptEntry accepts the input.
+	acceptEntry
+)
+
+// entry is an entry of the action table.
+type entry struct {
+	// kind is the kind of the entry.
+	kind entryKind
+
+	// state is the state to go to, for shift entries.
+	state int
+
+	// rule is the index of the rule to reduce, for reduce entries.
+	rule int
+}
+
+// ConflictOf is a conflict of the action table; that is, a state and a
+// lookahead for which more than one action is possible.
+type ConflictOf[K slgr.Kind] struct {
+	// State is the state in which the conflict occurs.
+	State int
+
+	// Lookahead is the lookahead on which the conflict occurs. It is the zero
+	// value if End is true.
+	Lookahead K
+
+	// End is true if the conflict occurs once every token, including the
+	// end-of-input token, has been consumed.
+	End bool
+
+	// Shift is true if one of the actions is a shift.
+	Shift bool
+
+	// Accept is true if one of the actions is accepting the input; which
+	// takes precedence over the reductions, as the augmented start rule comes
+	// before every other rule.
+	Accept bool
+
+	// Rules is the rules that can be reduced, in order of precedence.
+	Rules []*slgr.RuleOf[K]
+}
+
+// Conflict is the conflict of the string-based API.
+type Conflict = ConflictOf[string]
+
+// kind returns the kind of the conflict; such as "shift/reduce" or
+// "accept/reduce".
+//
+// Returns:
+//   - string: The kinds of the conflicting actions, in order of precedence,
+//     separated by slashes.
+func (c ConflictOf[K]) kind() string {
+	var kinds []string
+
+	if c.Shift {
+		kinds = append(kinds, "shift")
+	}
+
+	if c.Accept {
+		kinds = append(kinds, "accept")
+	}
+
+	if len(c.Rules) > 0 {
+		kinds = append(kinds, "reduce")
+	}
+
+	if len(kinds) == 1 {
+		kinds = append(kinds, kinds[0])
+	}
+
+	str := strings.Join(kinds, "/")
+	return str
+}
+
+// lookahead returns the name of the lookahead of the conflict.
+//
+// Returns:
+//   - string: The name of the lookahead, or an empty string at the end of the
+//     input.
+func (c ConflictOf[K]) lookahead() string {
+	if c.End {
+		return ""
+	}
+
+	str := slgr.KindString(c.Lookahead)
+	return str
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"state <state>, on <lookahead>: <kind> conflict between <actions>"
+func (c ConflictOf[K]) String() string {
+	var actions []string
+
+	if c.Shift {
+		actions = append(actions, "shift")
+	}
+
+	if c.Accept {
+		actions = append(actions, "accept")
+	}
+
+	for _, rule := range c.Rules {
+		actions = append(actions, "reduce "+strconv.Quote(rule.String()))
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString("state ")
+	_, _ = builder.WriteString(strconv.Itoa(c.State))
+	_, _ = builder.WriteString(", on ")
+	_, _ = builder.WriteString(strconv.Quote(c.lookahead()))
+	_, _ = builder.WriteString(": ")
+	_, _ = builder.WriteString(c.kind())
+	_, _ = builder.WriteString(" conflict between ")
+	_, _ = builder.WriteString(strings.Join(actions, " and "))
+
+	str := builder.String()
+	return str
+}
+
+// TableOf is an LALR(1) parsing table of a grammar whose symbols are of kind
+// K.
+//
+// The table gives every kind of the grammar an id, in the order of their names
+// (see slgr.KindString); its actions are then looked up by id rather than by
+// kind.
+type TableOf[K slgr.Kind] struct {
+	// rules is the rules of the grammar, as given by the user.
+	rules []*slgr.RuleOf[K]
+
+	// eof is the kind of the end-of-input token.
+	eof K
+
+	// error_ is the kind of the error pseudo-terminal.
+	error_ K
+
+	// symbols maps an id to its kind. The kind of etEnd is the zero value.
+	symbols []K
+
+	// ids maps a kind to its id.
+	ids map[K]int
+
+	// automaton is the LALR(1) automaton the table is built from.
+	automaton *internal.Automaton
+
+	// actions is, for each state and each lookahead, the possible actions.
+	// The first action is the one chosen by the deterministic parser.
+	actions [][][]entry
+
+	// gotos is, for each state and each non-terminal, the state to go to; or
+	// -1 if there is none.
+	gotos [][]int
+
+	// conflicts is the list of conflicts of the table.
+	conflicts []*ConflictOf[K]
+
+	// shiftable is, for each state, the sorted terminals the state shifts or
+	// accepts; which are acceptable whatever the rest of the stack is.
+	shiftable [][]int
+
+	// reducible is, for each state, the sorted terminals the state reduces on;
+	// which may be rejected after the reductions, as LALR(1) states merge the
+	// lookaheads of several contexts.
+	reducible [][]int
+}
+
+// Table is the parsing table of the string-based API.
+type Table = TableOf[string]
+
+// id returns the id of the given kind.
+//
+// Parameters:
+//   - k: The kind.
+//
+// Returns:
+//   - int: The id of the kind, or noSymbol if it is not a symbol of the
+//     grammar.
+func (t TableOf[K]) id(k K) int {
+	id, ok := t.ids[k]
+	if !ok {
+		return noSymbol
+	}
+
+	return id
+}
+
+// kinds returns the kinds of the given ids.
+//
+// Parameters:
+//   - ids: The ids. (Assumed to not contain etEnd nor noSymbol)
+//
+// Returns:
+//   - []K: The kinds, in the same order.
+func (t TableOf[K]) kinds(ids []int) []K {
+	kinds := make([]K, 0, len(ids))
+
+	for _, id := range ids {
+		kinds = append(kinds, t.symbols[id])
+	}
+
+	return kinds
+}
+
+// entries returns the possible actions of the given state on the given
+// lookahead.
+//
+// Parameters:
+//   - state: The current state.
+//   - lookahead: The id of the lookahead.
+//
+// Returns:
+//   - []entry: The actions, or nil if there are none.
+func (t TableOf[K]) entries(state, lookahead int) []entry {
+	if lookahead < 0 {
+		return nil
+	}
+
+	return t.actions[state][lookahead]
+}
+
+// action returns the action of the given state on the given lookahead.
+//
+// Parameters:
+//   - state: The current state.
+//   - lookahead: The id of the lookahead.
+//
+// Returns:
+//   - entry: The action.
+//   - bool: True if there is an action, false otherwise.
+func (t TableOf[K]) action(state, lookahead int) (entry, bool) {
+	entries := t.entries(state, lookahead)
+	if len(entries) == 0 {
+		return entry{}, false
+	}
+
+	return entries[0], true
+}
+
+// goTo returns the state to go to after reducing to the given non-terminal.
+//
+// Parameters:
+//   - state: The state uncovered by the reduction.
+//   - lhs: The id of the non-terminal that was reduced to.
+//
+// Returns:
+//   - int: The state to go to.
+//   - bool: True if there is such a state, false otherwise.
+func (t TableOf[K]) goTo(state, lhs int) (int, bool) {
+	if lhs < 0 {
+		return 0, false
+	}
+
+	next := t.gotos[state][lhs]
+	return next, next >= 0
+}
+
+// rule returns the rule with the given index.
+//
+// Parameters:
+//   - idx: The index of the rule in the automaton.
+//
+// Returns:
+//   - *internal.Rule: The rule.
+func (t TableOf[K]) rule(idx int) *internal.Rule {
+	return t.automaton.Rules[idx]
+}
+
+// accepts checks whether the given terminal can be shifted or accepted after
+// the reductions it triggers on the given stack of states. Unlike simulate,
+// the stack is not copied: only the states pushed by the reductions are kept
+// aside.
+//
+// Parameters:
+//   - states: The stack of states. It is not modified.
+//   - type_: The id of the lookahead.
+//
+// Returns:
+//   - bool: True if the terminal can be shifted or accepted, false otherwise.
+func (t TableOf[K]) accepts(states []int, type_ int) bool {
+	// depth is the number of states of the stack that are not popped yet, and
+	// pushed the states pushed on top of them.
+	depth := len(states)
+
+	var pushed []int
+
+	top := func() int {
+		if len(pushed) > 0 {
+			return pushed[len(pushed)-1]
+		}
+
+		return states[depth-1]
+	}
+
+	for {
+		act, ok := t.action(top(), type_)
+		if !ok {
+			return false
+		} else if act.kind != reduceEntry {
+			return true
+		}
+
+		rule := t.rule(act.rule)
+		size := len(rule.Rhss())
+
+		if size <= len(pushed) {
+			pushed = pushed[:len(pushed)-size]
+		} else {
+			depth -= size - len(pushed)
+			pushed = pushed[:0]
+		}
+
+		next, ok := t.goTo(top(), rule.Lhs())
+		assert.Cond(ok, "t.goTo(state, rule.Lhs())")
+
+		pushed = append(pushed, next)
+	}
+}
+
+// NumStates returns the number of states of the table.
+//
+// Returns:
+//   - int: The number of states.
+func (t TableOf[K]) NumStates() int {
+	return len(t.actions)
+}
+
+// Rules returns a copy of the rules the table was built from.
+//
+// Returns:
+//   - []*slgr.RuleOf[K]: The rules of the grammar.
+func (t TableOf[K]) Rules() []*slgr.RuleOf[K] {
+	rules := make([]*slgr.RuleOf[K], len(t.rules))
+	copy(rules, t.rules)
+
+	return rules
+}
+
+// Conflicts returns the conflicts of the table.
+//
+// Conflicts are resolved like yacc does: a shift is preferred over a reduce
+// and, between two reduces, the rule that was given first is preferred.
+// Accepting the input counts as reducing the augmented start rule, which
+// comes before every other rule.
+//
+// Returns:
+//   - []*ConflictOf[K]: The conflicts of the table, or nil if there are none.
+func (t TableOf[K]) Conflicts() []*ConflictOf[K] {
+	if len(t.conflicts) == 0 {
+		return nil
+	}
+
+	conflicts := make([]*ConflictOf[K], len(t.conflicts))
+	copy(conflicts, t.conflicts)
+
+	return conflicts
+}
+
+// expected returns the ids of the terminals that are acceptable in the given
+// state.
+//
+// Parameters:
+//   - state: The state. (Assumed to exist)
+//
+// Returns:
+//   - []int: The sorted list of ids of the acceptable terminals.
+func (t TableOf[K]) expected(state int) []int {
+	var expected []int
+
+	for la, entries := range t.actions[state] {
+		if la != etEnd && len(entries) > 0 {
+			expected = append(expected, la)
+		}
+	}
+
+	return expected
+}
+
+// Expected returns the terminals that are acceptable in the given state.
+//
+// Parameters:
+//   - state: The state.
+//
+// Returns:
+//   - []K: The list of acceptable terminals, sorted by name; or nil if the
+//     state does not exist.
+func (t TableOf[K]) Expected(state int) []K {
+	if state < 0 || state >= len(t.actions) {
+		return nil
+	}
+
+	expected := t.kinds(t.expected(state))
+	return expected
+}
+
+// symbolIDs gives an id to every kind of the given rules and to the given
+// kinds, in the order of their names; ties are broken by order of appearance.
+// The id 0 is etEnd.
+//
+// Parameters:
+//   - rules: The rules. (Assumed to not contain nil rules)
+//   - kinds: The other kinds.
+//
+// Returns:
+//   - []K: The kind of every id.
+//   - map[K]int: The id of every kind.
+func symbolIDs[K slgr.Kind](rules []*slgr.RuleOf[K], kinds ...K) ([]K, map[K]int) {
+	var symbols []K
+
+	seen := make(map[K]struct{})
+
+	add := func(k K) {
+		_, ok := seen[k]
+		if !ok {
+			seen[k] = struct{}{}
+			symbols = append(symbols, k)
+		}
+	}
+
+	for _, rule := range rules {
+		add(rule.Lhs)
+
+		for _, rhs := range rule.Rhss {
+			add(rhs)
+		}
+	}
+
+	for _, k := range kinds {
+		add(k)
+	}
+
+	slices.SortStableFunc(symbols, func(a, b K) int {
+		return strings.Compare(slgr.KindString(a), slgr.KindString(b))
+	})
+
+	var zero K
+
+	symbols = append([]K{zero}, symbols...)
+
+	ids := make(map[K]int, len(symbols)-1)
+
+	for id, k := range symbols[1:] {
+		ids[k] = id + 1
+	}
+
+	return symbols, ids
+}
+
+// NewTable builds the LALR(1) parsing table of the given grammar of the
+// string-based API; whose end-of-input token and error pseudo-terminal are
+// EtEOF and EtError. See NewTableOf.
+//
+// Parameters:
+//   - rules: The rules of the grammar.
+//
+// Returns:
+//   - *Table: The parsing table.
+//   - error: An error if the grammar is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If there are no rules or if a rule is nil or has an
+//     empty left-hand side.
+//   - error: If EtEOF is the left-hand side of a rule.
+func NewTable(rules []*slgr.Rule) (*Table, error) {
+	for i, rule := range rules {
+		if rule == nil || rule.Lhs == EtEOF {
+			break
+		} else if rule.Lhs == "" {
+			err := common.NewErrBadParam("rules["+strconv.Itoa(i)+"]", "must have a left-hand side")
+			return nil, err
+		}
+	}
+
+	t, err := NewTableOf(rules, EtEOF, EtError)
+	return t, err
+}
+
+// NewTableOf builds the LALR(1) parsing table of the given grammar.
+//
+// The left-hand side of the first rule is the start symbol. Since Parse always
+// appends an end-of-input token to the input, the grammar can either consume
+// it explicitly (e.g., "Source = Source1 EtEOF .") or not mention it at all, in
+// which case the input is accepted when it is the lookahead.
+//
+// Conflicts do not prevent the table from being built; see TableOf.Conflicts.
+//
+// Parameters:
+//   - rules: The rules of the grammar.
+//   - eof: The kind of the end-of-input token.
+//   - error_: The kind of the error pseudo-terminal. See Builder.SetRecovery.
+//
+// Returns:
+//   - *TableOf[K]: The parsing table.
+//   - error: An error if the grammar is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If there are no rules or if a rule is nil.
+//   - error: If eof is the left-hand side of a rule.
+func NewTableOf[K slgr.Kind](rules []*slgr.RuleOf[K], eof, error_ K) (*TableOf[K], error) {
+	if len(rules) == 0 {
+		err := common.NewErrBadParam("rules", "must not be empty")
+		return nil, err
+	}
+
+	for i, rule := range rules {
+		if rule == nil {
+			err := common.NewErrNilParam("rules[" + strconv.Itoa(i) + "]")
+			return nil, err
+		} else if rule.Lhs == eof {
+			err := fmt.Errorf("%s cannot be the left-hand side of a rule", slgr.KindString(eof))
+			return nil, err
+		}
+	}
+
+	symbols, ids := symbolIDs(rules, eof, error_)
+
+	irules := make([]*internal.Rule, 0, len(rules))
+
+	end := ids[eof]
+
+	for _, rule := range rules {
+		rhss := make([]int, 0, len(rule.Rhss))
+
+		for _, rhs := range rule.Rhss {
+			rhss = append(rhss, ids[rhs])
+		}
+
+		if slices.Contains(rule.Rhss, eof) {
+			end = etEnd
+		}
+
+		irules = append(irules, internal.NewRule(ids[rule.Lhs], rhss))
+	}
+
+	a := internal.NewAutomaton(irules, end)
+
+	t := &TableOf[K]{
+		rules:     slices.Clone(rules),
+		eof:       eof,
+		error_:    error_,
+		symbols:   symbols,
+		ids:       ids,
+		automaton: a,
+		actions:   make([][][]entry, 0, len(a.States)),
+		gotos:     make([][]int, 0, len(a.States)),
+	}
+
+	for i, state := range a.States {
+		actions := make([][]entry, len(symbols))
+
+		gotos := make([]int, len(symbols))
+		for j := range gotos {
+			gotos[j] = -1
+		}
+
+		for symbol, next := range state.Transitions {
+			if a.IsTerminal(symbol) {
+				actions[symbol] = append(actions[symbol], entry{kind: shiftEntry, state: next})
+			} else {
+				gotos[symbol] = next
+			}
+		}
+
+		for _, item := range state.Items {
+			rule := a.Rules[item.Rule]
+			if item.Dot < len(rule.Rhss()) {
+				continue
+			}
+
+			e := entry{kind: reduceEntry, rule: item.Rule}
+			if item.Rule == 0 {
+				e.kind = acceptEntry
+			}
+
+			for _, la := range item.Lookaheads {
+				actions[la] = append(actions[la], e)
+			}
+		}
+
+		for la, entries := range actions {
+			if len(entries) < 2 {
+				continue
+			}
+
+			c := &ConflictOf[K]{
+				State:     i,
+				Lookahead: symbols[la],
+				End:       la == etEnd,
+			}
+
+			for _, e := range entries {
+				switch e.kind {
+				case shiftEntry:
+					c.Shift = true
+				case acceptEntry:
+					c.Accept = true
+				case reduceEntry:
+					c.Rules = append(c.Rules, rules[e.rule-1])
+				}
+			}
+
+			t.conflicts = append(t.conflicts, c)
+		}
+
+		t.actions = append(t.actions, actions)
+		t.gotos = append(t.gotos, gotos)
+
+		var shiftable, reducible []int
+
+		for _, la := range t.expected(i) {
+			if actions[la][0].kind == reduceEntry {
+				reducible = append(reducible, la)
+			} else {
+				shiftable = append(shiftable, la)
+			}
+		}
+
+		t.shiftable = append(t.shiftable, shiftable)
+		t.reducible = append(t.reducible, reducible)
+	}
+
+	return t, nil
+}
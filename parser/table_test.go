@@ -0,0 +1,235 @@
+package parser
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// newTokens returns one token per given type, with the type as data and
+// consecutive positions on the first line.
+func newTokens(types ...string) []*slgr.Token {
+	tokens := make([]*slgr.Token, 0, len(types))
+	pos := slgr.StartPosition()
+
+	for _, type_ := range types {
+		tk := slgr.NewToken(type_, type_)
+		tk.Pos = pos
+
+		pos.Offset += len(type_) + 1
+		pos.Column += len(type_) + 1
+
+		tokens = append(tokens, tk)
+	}
+
+	return tokens
+}
+
+// mustTable builds the table of the given rules, or fails the test.
+func mustTable(t *testing.T, rules ...*slgr.Rule) *Table {
+	t.Helper()
+
+	table, err := NewTable(rules)
+	if err != nil {
+		t.Fatalf("NewTable() returned an error: %v", err)
+	}
+
+	return table
+}
+
+// newParser returns a parser driven by the given table.
+func newParser(table *Table) *Parser {
+	var builder Builder
+
+	_ = builder.SetTable(table)
+
+	return builder.Build()
+}
+
+// exprRules is an unambiguous grammar of sums of parenthesized numbers.
+var exprRules = []*slgr.Rule{
+	slgr.NewRule("E", "E", "plus", "T"),
+	slgr.NewRule("E", "T"),
+	slgr.NewRule("T", "num"),
+	slgr.NewRule("T", "op", "E", "cl"),
+}
+
+func TestTableParse(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	if conflicts := table.Conflicts(); conflicts != nil {
+		t.Fatalf("Conflicts() = %v, want none", conflicts)
+	}
+
+	forest, err := Parse(newParser(table), newTokens("num", "plus", "op", "num", "plus", "num", "cl"))
+	if err != nil {
+		t.Fatalf("Parse() returned an error: %v", err)
+	} else if len(forest) != 1 {
+		t.Fatalf("Parse() returned %d trees, want 1", len(forest))
+	}
+
+	want := "(E (E (T num)) plus (T op (E (E (T num)) plus (T num)) cl))"
+
+	if got := shape(forest[0]); got != want {
+		t.Errorf("tree = %s, want %s", got, want)
+	}
+}
+
+// shape returns the structure of the given tree, without data nor positions:
+// terminals are written as their type, and non-terminals as their type and
+// children in parentheses.
+func shape(tk *slgr.Token) string {
+	if tk == nil {
+		return "<nil>"
+	} else if len(tk.Children) == 0 {
+		return tk.Type
+	}
+
+	parts := []string{tk.Type}
+
+	for _, child := range tk.Children {
+		parts = append(parts, shape(child))
+	}
+
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func TestTableExpected(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	tests := []struct {
+		name     string
+		input    []string
+		got      string
+		expected []string
+	}{
+		{"missing operand", []string{"num", "plus"}, EtEOF, []string{"num", "op"}},
+		{"unexpected token", []string{"num", "num"}, "num", []string{"EtEOF", "plus"}},
+		{"unclosed parenthesis", []string{"op", "num"}, EtEOF, []string{"cl", "plus"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(newParser(table), newTokens(test.input...))
+
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("Parse() returned %v, want a *ParseError", err)
+			}
+
+			if pe.Token == nil || pe.Token.Type != test.got {
+				t.Errorf("Token = %v, want a %s token", pe.Token, test.got)
+			}
+
+			if !slices.Equal(pe.Expected, test.expected) {
+				t.Errorf("Expected = %q, want %q", pe.Expected, test.expected)
+			}
+		})
+	}
+}
+
+func TestTableConflicts(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []*slgr.Rule
+		lookahead string
+		shift     bool
+		accept    bool
+		reduces   []string
+		kind      string
+	}{
+		{
+			name: "shift/reduce",
+			rules: []*slgr.Rule{
+				slgr.NewRule("E", "E", "plus", "E"),
+				slgr.NewRule("E", "num"),
+			},
+			lookahead: "plus",
+			shift:     true,
+			reduces:   []string{"E = E plus E ."},
+			kind:      "shift/reduce",
+		},
+		{
+			name: "reduce/reduce",
+			rules: []*slgr.Rule{
+				slgr.NewRule("S", "A"),
+				slgr.NewRule("S", "B"),
+				slgr.NewRule("A", "x"),
+				slgr.NewRule("B", "x"),
+			},
+			lookahead: EtEOF,
+			reduces:   []string{"A = x .", "B = x ."},
+			kind:      "reduce/reduce",
+		},
+		{
+			name: "accept/reduce",
+			rules: []*slgr.Rule{
+				slgr.NewRule("S", "A"),
+				slgr.NewRule("S", "x"),
+				slgr.NewRule("A", "S"),
+			},
+			lookahead: EtEOF,
+			accept:    true,
+			reduces:   []string{"A = S ."},
+			kind:      "accept/reduce",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conflicts := mustTable(t, test.rules...).Conflicts()
+			if len(conflicts) != 1 {
+				t.Fatalf("Conflicts() = %v, want one conflict", conflicts)
+			}
+
+			c := conflicts[0]
+
+			if c.Lookahead != test.lookahead || c.Shift != test.shift || c.Accept != test.accept {
+				t.Errorf("conflict = %+v, want on %s with shift=%t and accept=%t", c, test.lookahead, test.shift, test.accept)
+			}
+
+			if got := ruleStrings(c.Rules); !slices.Equal(got, test.reduces) {
+				t.Errorf("Rules = %q, want %q", got, test.reduces)
+			}
+
+			if !strings.Contains(c.String(), " "+test.kind+" conflict ") {
+				t.Errorf("String() = %q, want a %s conflict", c.String(), test.kind)
+			}
+		})
+	}
+}
+
+// ruleStrings returns the string representation of the given rules.
+func ruleStrings(rules []*slgr.Rule) []string {
+	strs := make([]string, 0, len(rules))
+
+	for _, rule := range rules {
+		strs = append(strs, rule.String())
+	}
+
+	return strs
+}
+
+func TestNewTableErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []*slgr.Rule
+	}{
+		{"no rules", nil},
+		{"nil rule", []*slgr.Rule{nil}},
+		{"empty left-hand side", []*slgr.Rule{slgr.NewRule("", "a")}},
+		{"EtEOF left-hand side", []*slgr.Rule{slgr.NewRule(EtEOF, "a")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewTable(test.rules)
+			if err == nil {
+				t.Errorf("NewTable() returned no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package parser
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// EventKind is the kind of a parsing event.
+type EventKind int
+
+const (
+	// StartEvent occurs when a parsing process starts.
+	StartEvent EventKind = iota
+
+	// ShiftEvent occurs when a token is shifted onto the stack. This includes
+	// the EtError tokens of the recoveries.
+	ShiftEvent
+
+	// ReduceEvent occurs when a rule is reduced.
+	ReduceEvent
+
+	// AcceptEvent occurs when the input is accepted.
+	AcceptEvent
+
+	// ErrorEvent occurs when the parser fails; or, if it recovers or repairs
+	// the input, before it does so.
+	ErrorEvent
+)
+
+// String implements fmt.Stringer.
+func (k EventKind) String() string {
+	switch k {
+	case StartEvent:
+		return "start"
+	case ShiftEvent:
+		return "shift"
+	case ReduceEvent:
+		return "reduce"
+	case AcceptEvent:
+		return "accept"
+	case ErrorEvent:
+		return "error"
+	default:
+		return "EventKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
+// EventOf is a step of a parsing process.
+type EventOf[K slgr.Kind] struct {
+	// Kind is the kind of the event.
+	Kind EventKind
+
+	// Token is the shifted token, or the lookahead of an error. Nil for the
+	// other events and for errors whose lookahead was not read yet.
+	Token *slgr.TokenOf[K]
+
+	// Rule is the reduced rule. Nil for the other events.
+	Rule *slgr.RuleOf[K]
+
+	// State is the state of the table-driven parser after the event, or -1
+	// for parsers driven by a parsing function.
+	State int
+
+	// Stack is the symbols on the stack after the event, from the bottom to
+	// the top.
+	Stack []K
+
+	// Err is the error of an ErrorEvent. Nil for the other events.
+	Err error
+}
+
+// Event is the event of the string-based API.
+type Event = EventOf[string]
+
+// TracerOf receives the events of the parsing processes of a parser. See
+// Builder.SetTracer.
+type TracerOf[K slgr.Kind] interface {
+	// Trace is called at every step of the parsing process.
+	//
+	// Parameters:
+	//   - event: The event. Never nil.
+	Trace(event *EventOf[K])
+}
+
+// Tracer is the tracer of the string-based API.
+type Tracer = TracerOf[string]
+
+// traceTracer is a Tracer that writes a trace table. See NewTraceTracer.
+type traceTracer[K slgr.Kind] struct {
+	// mu guards the fields below.
+	mu sync.Mutex
+
+	// w is the writer the table is aligned in.
+	w *tabwriter.Writer
+
+	// step is the number of the next step.
+	step int
+}
+
+// Trace implements Tracer.
+func (t *traceTracer[K]) Trace(event *EventOf[K]) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if event.Kind == StartEvent {
+		_, _ = io.WriteString(t.w, "step\tstate\tstack\taction\n")
+		t.step = 0
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(strconv.Itoa(t.step))
+	_, _ = builder.WriteRune('\t')
+
+	if event.State >= 0 {
+		_, _ = builder.WriteString(strconv.Itoa(event.State))
+	} else {
+		_, _ = builder.WriteRune('-')
+	}
+
+	_, _ = builder.WriteRune('\t')
+	for i, symbol := range event.Stack {
+		if i > 0 {
+			_, _ = builder.WriteRune(' ')
+		}
+
+		_, _ = builder.WriteString(slgr.KindString(symbol))
+	}
+
+	_, _ = builder.WriteRune('\t')
+	_, _ = builder.WriteString(event.Kind.String())
+
+	switch event.Kind {
+	case ShiftEvent:
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(slgr.KindString(event.Token.Type))
+
+		if event.Token.Data != "" {
+			_, _ = builder.WriteString(" (")
+			_, _ = builder.WriteString(strconv.Quote(event.Token.Data))
+			_, _ = builder.WriteRune(')')
+		}
+	case ReduceEvent:
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(event.Rule.String())
+	case ErrorEvent:
+		_, _ = builder.WriteString(": ")
+		_, _ = builder.WriteString(event.Err.Error())
+	}
+
+	_, _ = builder.WriteRune('\n')
+
+	_, _ = io.WriteString(t.w, builder.String())
+
+	t.step++
+
+	if event.Kind == AcceptEvent || event.Kind == ErrorEvent {
+		_ = t.w.Flush()
+	}
+}
+
+// NewTraceTracer creates a Tracer that writes a bison-style trace table: one
+// row per step, with the state, the stack and the action after the step. The
+// rows are written, with aligned columns, when the input is accepted or when
+// an error occurs.
+//
+// The tracer is safe for concurrent use, but it traces one parsing process at
+// a time: the rows of processes that run concurrently are interleaved and
+// misnumbered. To trace the parsers of a shared Definition, create one tracer
+// per parser with Builder.SetTracerFunc.
+//
+// Format:
+//
+//	step  state  stack               action
+//	0     0                          start
+//	1     3      uppercase_id        shift uppercase_id ("Foo")
+//	2     5      uppercase_id equal  shift equal ("=")
+//
+// Parameters:
+//   - w: The writer to write the trace table to.
+//
+// Returns:
+//   - Tracer: The new tracer.
+//   - error: An error if the writer is nil.
+func NewTraceTracer(w io.Writer) (Tracer, error) {
+	t, err := NewTraceTracerOf[string](w)
+	return t, err
+}
+
+// NewTraceTracerOf is like NewTraceTracer, for parsers over any kind of token.
+//
+// Parameters:
+//   - w: The writer to write the trace table to.
+//
+// Returns:
+//   - TracerOf[K]: The new tracer.
+//   - error: An error if the writer is nil.
+func NewTraceTracerOf[K slgr.Kind](w io.Writer) (TracerOf[K], error) {
+	if w == nil {
+		err := common.NewErrNilParam("w")
+		return nil, err
+	}
+
+	t := &traceTracer[K]{
+		w: tabwriter.NewWriter(w, 0, 4, 2, ' ', 0),
+	}
+
+	return t, nil
+}
+
+// symbolOf returns the symbol that is shifted to reach the given state.
+//
+// Parameters:
+//   - state: The state.
+//
+// Returns:
+//   - K: The symbol, or the zero value for the initial state.
+func (t TableOf[K]) symbolOf(state int) K {
+	for _, item := range t.automaton.States[state].Items {
+		if item.Dot > 0 {
+			symbol := t.automaton.Rules[item.Rule].Rhss()[item.Dot-1]
+			return t.symbols[symbol]
+		}
+	}
+
+	var zero K
+	return zero
+}
+
+// trace sends an event to the tracer, if any.
+//
+// Parameters:
+//   - kind: The kind of the event.
+//   - tk: The token of the event, if any.
+//   - rule: The rule of the event, if any.
+//   - err: The error of the event, if any.
+func (p ParserOf[K]) trace(kind EventKind, tk *slgr.TokenOf[K], rule *slgr.RuleOf[K], err error) {
+	if p.tracer == nil {
+		return
+	}
+
+	event := &EventOf[K]{
+		Kind:  kind,
+		Token: tk,
+		Rule:  rule,
+		State: -1,
+		Err:   err,
+	}
+
+	if p.table != nil && len(p.states) > 0 {
+		event.State = p.states[len(p.states)-1]
+
+		event.Stack = make([]K, 0, len(p.states)-1)
+
+		for _, state := range p.states[1:] {
+			event.Stack = append(event.Stack, p.table.symbolOf(state))
+		}
+	} else {
+		tokens := p.stack.Slice()
+
+		event.Stack = make([]K, 0, len(tokens))
+
+		for i := len(tokens) - 1; i >= 0; i-- {
+			event.Stack = append(event.Stack, tokens[i].Type)
+		}
+	}
+
+	p.tracer.Trace(event)
+}
+
+// peek returns the next token of the input stream without pulling it from the
+// source.
+//
+// Returns:
+//   - *slgr.TokenOf[K]: The next token, or nil if it was not read yet.
+func (p ParserOf[K]) peek() *slgr.TokenOf[K] {
+	if len(p.tokens) == 0 {
+		return nil
+	}
+
+	return p.tokens[0]
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tracedParser returns a parser driven by the given table that writes its
+// trace table to the given buffer.
+func tracedParser(t *testing.T, table *Table, buf *bytes.Buffer) *Parser {
+	t.Helper()
+
+	tracer, err := NewTraceTracer(buf)
+	if err != nil {
+		t.Fatalf("NewTraceTracer() returned an error: %v", err)
+	}
+
+	var builder Builder
+
+	_ = builder.SetTable(table)
+	_ = builder.SetTracer(tracer)
+
+	return builder.Build()
+}
+
+// traceLines returns the lines of the given trace table, without trailing
+// spaces.
+func traceLines(buf *bytes.Buffer) []string {
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " ")
+	}
+
+	return lines
+}
+
+func TestTraceTracer(t *testing.T) {
+	var buf bytes.Buffer
+
+	_, err := Parse(tracedParser(t, mustTable(t, exprRules...), &buf), newTokens("num", "plus", "num"))
+	if err != nil {
+		t.Fatalf("Parse() returned an error: %v", err)
+	}
+
+	lines := traceLines(&buf)
+
+	fields := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		fields = append(fields, strings.Fields(line))
+	}
+
+	if !slices.Equal(fields[0], []string{"step", "state", "stack", "action"}) {
+		t.Errorf("header = %q", lines[0])
+	}
+
+	if !slices.Equal(fields[1], []string{"0", "0", "start"}) {
+		t.Errorf("first step = %q, want the start", lines[1])
+	}
+
+	// The state, in the second column, depends on how the table is built.
+	if want := []string{"num", "shift", "num", `("num")`}; fields[2][0] != "1" || !slices.Equal(fields[2][2:], want) {
+		t.Errorf("second step = %q, want the shift of num", lines[2])
+	}
+
+	if last := fields[len(fields)-1]; last[len(last)-1] != "accept" {
+		t.Errorf("last step = %q, want the accept", lines[len(lines)-1])
+	}
+}
+
+func TestTraceTracerFlushesOnErrors(t *testing.T) {
+	table := mustTable(t, exprRules...)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	errSource := errors.New("source failure")
+
+	tests := []struct {
+		name  string
+		parse func(p *Parser) error
+		want  string
+	}{
+		{
+			name: "syntax error",
+			parse: func(p *Parser) error {
+				_, err := Parse(p, newTokens("num", "num"))
+				return err
+			},
+			want: "error: ",
+		},
+		{
+			name: "context done",
+			parse: func(p *Parser) error {
+				_, err := ParseContext(canceled, p, newTokens("num"))
+				return err
+			},
+			want: "error: " + context.Canceled.Error(),
+		},
+		{
+			name: "source failure",
+			parse: func(p *Parser) error {
+				_, err := ParseFrom(p, &countingSource{tokens: newTokens("num"), err: errSource})
+				return err
+			},
+			want: "error: " + errSource.Error(),
+		},
+		{
+			name: "semantic action failure",
+			parse: func(p *Parser) error {
+				_, err := ParseValue(p, numTokens("1", "+", "x"), sumActions(t))
+				return err
+			},
+			want: "error: while shifting: ",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := test.parse(tracedParser(t, table, &buf))
+			if err == nil {
+				t.Fatalf("the parsing process returned no error")
+			}
+
+			lines := traceLines(&buf)
+
+			if last := lines[len(lines)-1]; !strings.Contains(last, test.want) {
+				t.Errorf("last step = %q, want it to contain %q", last, test.want)
+			}
+		})
+	}
+}
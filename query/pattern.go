@@ -0,0 +1,296 @@
+package query
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pattern is a compiled node pattern.
+type pattern struct {
+	// kind is the type the node must have, or an empty string for any type.
+	kind string
+
+	// data is the data the node must have, if any.
+	data *string
+
+	// children is the patterns of the children of the node. They must match
+	// children of the node in the same order, but not necessarily
+	// consecutive ones.
+	children []*pattern
+
+	// deep is true if the pattern matches a descendant of the parent node
+	// instead of a child.
+	deep bool
+
+	// capture is the name of the capture of the node, or an empty string if
+	// the node is not captured.
+	capture string
+}
+
+// String returns the query notation of the pattern.
+//
+// Returns:
+//   - string: The query notation of the pattern.
+func (p pattern) String() string {
+	var builder strings.Builder
+
+	if p.deep {
+		_, _ = builder.WriteString("// ")
+	}
+
+	switch {
+	case p.data != nil && p.kind == "" && len(p.children) == 0:
+		_, _ = builder.WriteString(strconv.Quote(*p.data))
+	case p.kind == "" && p.data == nil && len(p.children) == 0:
+		_, _ = builder.WriteRune('_')
+	default:
+		_, _ = builder.WriteRune('(')
+
+		if p.kind == "" {
+			_, _ = builder.WriteRune('_')
+		} else {
+			_, _ = builder.WriteString(p.kind)
+		}
+
+		if p.data != nil {
+			_, _ = builder.WriteRune(' ')
+			_, _ = builder.WriteString(strconv.Quote(*p.data))
+		}
+
+		for _, child := range p.children {
+			_, _ = builder.WriteRune(' ')
+			_, _ = builder.WriteString(child.String())
+		}
+
+		_, _ = builder.WriteRune(')')
+	}
+
+	if p.capture != "" {
+		_, _ = builder.WriteString(" @")
+		_, _ = builder.WriteString(p.capture)
+	}
+
+	str := builder.String()
+	return str
+}
+
+// SyntaxError occurs when a query cannot be compiled.
+type SyntaxError struct {
+	// Offset is the byte offset, in the query, of the error.
+	Offset int
+
+	// Reason is the reason of the error.
+	Reason string
+}
+
+// Error implements error.
+//
+// Format:
+//
+//	"<reason> at offset <offset>"
+func (e SyntaxError) Error() string {
+	str := e.Reason + " at offset " + strconv.Itoa(e.Offset)
+	return str
+}
+
+// NewSyntaxError returns an error with the given offset and reason.
+//
+// Parameters:
+//   - offset: The byte offset, in the query, of the error.
+//   - reason: The reason of the error.
+//
+// Returns:
+//   - error: An instance of SyntaxError. Never returns nil.
+func NewSyntaxError(offset int, reason string) error {
+	e := &SyntaxError{
+		Offset: offset,
+		Reason: reason,
+	}
+
+	return e
+}
+
+// compiler compiles the text of a query into patterns.
+type compiler struct {
+	// src is the text of the query.
+	src string
+
+	// pos is the byte offset of the next character to read.
+	pos int
+}
+
+// skipSpaces skips the white space at the current offset.
+func (c *compiler) skipSpaces() {
+	for c.pos < len(c.src) && strings.IndexByte(" \t\r\n", c.src[c.pos]) >= 0 {
+		c.pos++
+	}
+}
+
+// isNameByte checks whether the given byte can be part of a name; that is,
+// of a node type or of a capture.
+//
+// Parameters:
+//   - b: The byte to check.
+//
+// Returns:
+//   - bool: True if the byte can be part of a name, false otherwise.
+func isNameByte(b byte) bool {
+	return b == '_' || b == '-' || b == '.' || '0' <= b && b <= '9' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// name reads a name at the current offset.
+//
+// Returns:
+//   - string: The name.
+//   - error: An error if there is no name at the current offset.
+func (c *compiler) name() (string, error) {
+	start := c.pos
+
+	for c.pos < len(c.src) && isNameByte(c.src[c.pos]) {
+		c.pos++
+	}
+
+	if c.pos == start {
+		err := NewSyntaxError(start, "expected a name")
+		return "", err
+	}
+
+	return c.src[start:c.pos], nil
+}
+
+// quoted reads a quoted string at the current offset.
+//
+// Returns:
+//   - string: The unquoted string.
+//   - error: An error if the string is not terminated or not valid.
+func (c *compiler) quoted() (string, error) {
+	start := c.pos
+
+	c.pos++
+
+	for c.pos < len(c.src) && c.src[c.pos] != '"' {
+		if c.src[c.pos] == '\\' {
+			c.pos++
+		}
+
+		c.pos++
+	}
+
+	if c.pos >= len(c.src) {
+		err := NewSyntaxError(start, "unterminated string")
+		return "", err
+	}
+
+	c.pos++
+
+	str, err := strconv.Unquote(c.src[start:c.pos])
+	if err != nil {
+		err := NewSyntaxError(start, "invalid string")
+		return "", err
+	}
+
+	return str, nil
+}
+
+// pattern compiles the pattern at the current offset.
+//
+// Returns:
+//   - *pattern: The compiled pattern.
+//   - error: An error if the pattern is not valid.
+func (c *compiler) pattern() (*pattern, error) {
+	c.skipSpaces()
+
+	if c.pos >= len(c.src) {
+		err := NewSyntaxError(c.pos, "expected a pattern")
+		return nil, err
+	}
+
+	p := new(pattern)
+
+	switch b := c.src[c.pos]; {
+	case b == '"':
+		data, err := c.quoted()
+		if err != nil {
+			return nil, err
+		}
+
+		p.data = &data
+	case b == '(':
+		c.pos++
+		c.skipSpaces()
+
+		kind, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if kind != "_" {
+			p.kind = kind
+		}
+
+		c.skipSpaces()
+
+		if c.pos < len(c.src) && c.src[c.pos] == '"' {
+			data, err := c.quoted()
+			if err != nil {
+				return nil, err
+			}
+
+			p.data = &data
+		}
+
+		for {
+			c.skipSpaces()
+
+			if c.pos >= len(c.src) {
+				err := NewSyntaxError(c.pos, "expected ')'")
+				return nil, err
+			}
+
+			if c.src[c.pos] == ')' {
+				c.pos++
+				break
+			}
+
+			deep := strings.HasPrefix(c.src[c.pos:], "//")
+			if deep {
+				c.pos += 2
+			}
+
+			child, err := c.pattern()
+			if err != nil {
+				return nil, err
+			}
+
+			child.deep = deep
+			p.children = append(p.children, child)
+		}
+	case isNameByte(b):
+		kind, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		if kind != "_" {
+			p.kind = kind
+		}
+	default:
+		err := NewSyntaxError(c.pos, "unexpected "+strconv.QuoteRune(rune(b)))
+		return nil, err
+	}
+
+	c.skipSpaces()
+
+	if c.pos < len(c.src) && c.src[c.pos] == '@' {
+		c.pos++
+
+		capture, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		p.capture = capture
+	}
+
+	return p, nil
+}
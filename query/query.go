@@ -0,0 +1,268 @@
+// Package query selects the nodes of token trees with tree-sitter-like
+// S-expression patterns.
+//
+// A pattern is one of:
+//
+//	(Type child ...)   a node of the given type whose children match the
+//	                   child patterns, in order but not necessarily
+//	                   consecutively
+//	(Type "data" ...)  the same, for a node with the given data
+//	Type               a node of the given type, with any children
+//	"data"             a node with the given data, of any type
+//	_                  any node; "(_ ...)" is any node with such children
+//
+// A child pattern prefixed with "//" matches any descendant instead of a
+// child, and a pattern followed by "@name" captures the node it matched. For
+// instance, the following query matches every Rule whose RhsCls contains an
+// OrGroup, and captures the rule and the group:
+//
+//	(Rule (RhsCls //(OrGroup) @group)) @rule
+package query
+
+import (
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// Query is a compiled query. It is safe for concurrent use.
+type Query struct {
+	// root is the pattern of the matched nodes.
+	root *pattern
+}
+
+// String implements fmt.Stringer.
+//
+// The string is the query in a normalised notation; which compiles to the
+// same query.
+func (q Query) String() string {
+	if q.root == nil {
+		return ""
+	}
+
+	str := q.root.String()
+	return str
+}
+
+// Compile compiles the given query. See the package documentation for the
+// syntax.
+//
+// Parameters:
+//   - src: The text of the query.
+//
+// Returns:
+//   - *Query: The compiled query, or nil if the query is not valid.
+//   - error: An error if the query is not valid.
+//
+// Errors:
+//   - *SyntaxError: If the query is not valid.
+func Compile(src string) (*Query, error) {
+	c := &compiler{
+		src: src,
+	}
+
+	root, err := c.pattern()
+	if err != nil {
+		return nil, err
+	}
+
+	c.skipSpaces()
+
+	if c.pos < len(c.src) {
+		err := NewSyntaxError(c.pos, "expected the end of the query")
+		return nil, err
+	}
+
+	q := &Query{
+		root: root,
+	}
+
+	return q, nil
+}
+
+// Match is a node matched by a query.
+type Match struct {
+	// Node is the matched node.
+	Node *slgr.Token
+
+	// Captures maps the names of the captures to the captured nodes. If a
+	// name is captured more than once, it maps to the last capture.
+	Captures map[string]*slgr.Token
+}
+
+// capture is a node captured while matching.
+type capture struct {
+	// name is the name of the capture.
+	name string
+
+	// node is the captured node.
+	node *slgr.Token
+}
+
+// matcher matches patterns and collects the captures.
+type matcher struct {
+	// captures is the captures of the current match, in order.
+	captures []capture
+}
+
+// match checks whether the given node matches the given pattern. On failure,
+// the captures are left as they were.
+//
+// Parameters:
+//   - p: The pattern. (Assumed to not be nil)
+//   - node: The node. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the node matches the pattern, false otherwise.
+func (m *matcher) match(p *pattern, node *slgr.Token) bool {
+	if p.kind != "" && node.Type != p.kind {
+		return false
+	}
+
+	if p.data != nil && node.Data != *p.data {
+		return false
+	}
+
+	mark := len(m.captures)
+
+	// As the child patterns do not depend on each other, matching each one
+	// with the first child it can match is enough.
+	next := 0
+
+	for _, child := range p.children {
+		for next < len(node.Children) && !m.matchChild(child, node.Children[next]) {
+			next++
+		}
+
+		if next == len(node.Children) {
+			m.captures = m.captures[:mark]
+			return false
+		}
+
+		next++
+	}
+
+	if p.capture != "" {
+		m.captures = append(m.captures, capture{
+			name: p.capture,
+			node: node,
+		})
+	}
+
+	return true
+}
+
+// matchChild checks whether the given child matches the given child pattern;
+// that is, whether the child, or one of its descendants if the pattern is
+// deep, matches the pattern.
+//
+// Parameters:
+//   - p: The child pattern. (Assumed to not be nil)
+//   - child: The child. (Assumed to not be nil)
+//
+// Returns:
+//   - bool: True if the child matches the pattern, false otherwise.
+func (m *matcher) matchChild(p *pattern, child *slgr.Token) bool {
+	if !p.deep {
+		ok := m.match(p, child)
+		return ok
+	}
+
+	found := !slgr.Inspect(child, slgr.PreOrder, func(node *slgr.Token) slgr.Signal {
+		if m.match(p, node) {
+			return slgr.Halt
+		}
+
+		return slgr.Continue
+	})
+
+	return found
+}
+
+// Match checks whether the given node matches the query.
+//
+// Parameters:
+//   - node: The node.
+//
+// Returns:
+//   - *Match: The match, or nil if the node does not match the query.
+func (q Query) Match(node *slgr.Token) *Match {
+	if q.root == nil || node == nil {
+		return nil
+	}
+
+	var m matcher
+
+	if !m.match(q.root, node) {
+		return nil
+	}
+
+	match := &Match{
+		Node: node,
+	}
+
+	if len(m.captures) > 0 {
+		match.Captures = make(map[string]*slgr.Token, len(m.captures))
+
+		for _, c := range m.captures {
+			match.Captures[c.name] = c.node
+		}
+	}
+
+	return match
+}
+
+// FindAll returns the matches of the query among the nodes of the tree rooted
+// at the given node, in pre-order.
+//
+// Parameters:
+//   - root: The root of the tree.
+//
+// Returns:
+//   - []*Match: The matches, or nil if there are none.
+func (q Query) FindAll(root *slgr.Token) []*Match {
+	if root == nil {
+		return nil
+	}
+
+	var matches []*Match
+
+	_ = slgr.Inspect(root, slgr.PreOrder, func(node *slgr.Token) slgr.Signal {
+		match := q.Match(node)
+		if match != nil {
+			matches = append(matches, match)
+		}
+
+		return slgr.Continue
+	})
+
+	return matches
+}
+
+// FindAll compiles the given query and returns its matches among the nodes of
+// the tree rooted at the given node. See Compile and Query.FindAll.
+//
+// Parameters:
+//   - src: The text of the query.
+//   - root: The root of the tree.
+//
+// Returns:
+//   - []*Match: The matches, or nil if there are none.
+//   - error: An error if the query is not valid.
+//
+// Errors:
+//   - common.ErrBadParam: If the root is nil.
+//   - *SyntaxError: If the query is not valid.
+func FindAll(src string, root *slgr.Token) ([]*Match, error) {
+	if root == nil {
+		err := common.NewErrNilParam("root")
+		return nil, err
+	}
+
+	q, err := Compile(src)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := q.FindAll(root)
+	return matches, nil
+}
@@ -0,0 +1,176 @@
+package query
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+)
+
+// node returns a token of the given type and data with the given children.
+func node(type_, data string, children ...*slgr.Token) *slgr.Token {
+	tk := slgr.NewToken(type_, data)
+	_ = tk.AppendChildren(children)
+
+	return tk
+}
+
+// sampleTree returns the tree of the rules "A = x ( y | z ) ." and
+// "B = w .".
+func sampleTree() *slgr.Token {
+	return node("Source", "",
+		node("Rule", "",
+			node("Lhs", "A"),
+			node("RhsCls", "",
+				node("Rhs", "x"),
+				node("OrGroup", "", node("Rhs", "y"), node("Rhs", "z")),
+			),
+		),
+		node("Rule", "",
+			node("Lhs", "B"),
+			node("RhsCls", "", node("Rhs", "w")),
+		),
+	)
+}
+
+func TestFindAll(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		matches  []string
+		captures map[string][]string
+	}{
+		{
+			name:    "type",
+			query:   "Rhs",
+			matches: []string{"x", "y", "z", "w"},
+		},
+		{
+			name:    "data",
+			query:   `"y"`,
+			matches: []string{"y"},
+		},
+		{
+			name:    "type and data",
+			query:   `(Lhs "B")`,
+			matches: []string{"B"},
+		},
+		{
+			name:     "deep child with captures",
+			query:    "(Rule Lhs @name (RhsCls //(OrGroup) @group)) @rule",
+			matches:  []string{""},
+			captures: map[string][]string{"name": {"A"}, "group": {"OrGroup"}, "rule": {"Rule"}},
+		},
+		{
+			name:    "children in order but not consecutive",
+			query:   `(OrGroup "" (Rhs "z"))`,
+			matches: []string{""},
+		},
+		{
+			name:    "children out of order",
+			query:   `(OrGroup (Rhs "z") (Rhs "y"))`,
+			matches: nil,
+		},
+		{
+			name:     "wildcard",
+			query:    `(_ (Rhs "w") @rhs)`,
+			matches:  []string{""},
+			captures: map[string][]string{"rhs": {"w"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			matches, err := FindAll(test.query, sampleTree())
+			if err != nil {
+				t.Fatalf("FindAll() returned an error: %v", err)
+			}
+
+			var got []string
+			for _, m := range matches {
+				got = append(got, m.Node.Data)
+			}
+
+			if !slices.Equal(got, test.matches) {
+				t.Fatalf("matched %q, want %q", got, test.matches)
+			}
+
+			for name, want := range test.captures {
+				c, ok := matches[0].Captures[name]
+				if !ok {
+					t.Errorf("capture @%s is missing", name)
+					continue
+				}
+
+				label := c.Data
+				if label == "" {
+					label = c.Type
+				}
+
+				if label != want[0] {
+					t.Errorf("capture @%s = %s, want %s", name, label, want[0])
+				}
+			}
+
+			if test.captures == nil && len(matches) > 0 && matches[0].Captures != nil {
+				t.Errorf("Captures = %v, want none", matches[0].Captures)
+			}
+		})
+	}
+}
+
+func TestCompileString(t *testing.T) {
+	tests := []struct {
+		src  string
+		want string
+	}{
+		{"Rhs", "(Rhs)"},
+		{"_", "_"},
+		{"  ( Rule   Lhs )  ", "(Rule (Lhs))"},
+		{`(_ "a b"  //  x  @c) @d`, `(_ "a b" // (x) @c) @d`},
+		{`"q\"uote"`, `"q\"uote"`},
+	}
+
+	for _, test := range tests {
+		q, err := Compile(test.src)
+		if err != nil {
+			t.Errorf("Compile(%q) returned an error: %v", test.src, err)
+			continue
+		}
+
+		if got := q.String(); got != test.want {
+			t.Errorf("Compile(%q).String() = %q, want %q", test.src, got, test.want)
+		}
+
+		again, err := Compile(q.String())
+		if err != nil || again.String() != q.String() {
+			t.Errorf("the string of %q does not compile to the same query", test.src)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		offset int
+	}{
+		{"", 0},
+		{"(Rule", 5},
+		{`"open`, 0},
+		{"(Rule) extra", 7},
+		{"Rule @", 6},
+		{"(Rule #)", 6},
+	}
+
+	for _, test := range tests {
+		_, err := Compile(test.src)
+
+		var se *SyntaxError
+		if !errors.As(err, &se) {
+			t.Errorf("Compile(%q) returned %v, want a *SyntaxError", test.src, err)
+		} else if se.Offset != test.offset {
+			t.Errorf("Compile(%q) failed at offset %d, want %d: %v", test.src, se.Offset, test.offset, err)
+		}
+	}
+}
@@ -0,0 +1,588 @@
+package query
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+const (
+	// MaxRewritePasses is the maximum number of passes of Rewrite. Rules that
+	// make the tree grow forever, without ever repeating it, fail beyond it.
+	MaxRewritePasses int = 1000
+
+	// MaxRewriteNodes is the maximum number of nodes Rewrite creates. Rules
+	// that make the tree grow exponentially fail beyond it.
+	MaxRewriteNodes int = 1 << 20
+)
+
+var (
+	// ErrRewriteCycle occurs when rewriting a tree yields a tree it already
+	// yielded; so the rules never reach a fixpoint. This error can be checked
+	// with the == operator.
+	//
+	// Format:
+	// 	"rewrite rules cycle"
+	ErrRewriteCycle error
+)
+
+func init() {
+	ErrRewriteCycle = errors.New("rewrite rules cycle")
+}
+
+// template is a compiled replacement of a rewrite rule.
+type template struct {
+	// kind is the type of the new node, or an empty string if the template
+	// inserts a capture.
+	kind string
+
+	// data is the data of the new node.
+	data string
+
+	// children is the templates of the children of the new node.
+	children []*template
+
+	// capture is the name of the inserted capture, if any.
+	capture string
+
+	// splice is true if the children of the capture are inserted instead of
+	// the capture itself.
+	splice bool
+}
+
+// String returns the template notation of the template.
+//
+// Returns:
+//   - string: The template notation of the template.
+func (t template) String() string {
+	if t.kind == "" {
+		str := "@" + t.capture
+
+		if t.splice {
+			str += "..."
+		}
+
+		return str
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteRune('(')
+	_, _ = builder.WriteString(t.kind)
+
+	if t.data != "" {
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(strconv.Quote(t.data))
+	}
+
+	for _, child := range t.children {
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(child.String())
+	}
+
+	_, _ = builder.WriteRune(')')
+
+	str := builder.String()
+	return str
+}
+
+// template compiles the template at the current offset.
+//
+// Parameters:
+//   - top: Whether the template is the whole replacement, which must be a
+//     single node.
+//
+// Returns:
+//   - *template: The compiled template.
+//   - error: An error if the template is not valid.
+func (c *compiler) template(top bool) (*template, error) {
+	c.skipSpaces()
+
+	if c.pos >= len(c.src) {
+		err := NewSyntaxError(c.pos, "expected a template")
+		return nil, err
+	}
+
+	t := new(template)
+
+	switch b := c.src[c.pos]; {
+	case b == '@':
+		c.pos++
+
+		capture, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		t.capture = strings.TrimSuffix(capture, "...")
+		t.splice = t.capture != capture
+
+		if t.splice && top {
+			err := NewSyntaxError(c.pos-3, "cannot splice the whole replacement")
+			return nil, err
+		}
+	case b == '(':
+		c.pos++
+		c.skipSpaces()
+
+		kind, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		t.kind = kind
+
+		c.skipSpaces()
+
+		if c.pos < len(c.src) && c.src[c.pos] == '"' {
+			data, err := c.quoted()
+			if err != nil {
+				return nil, err
+			}
+
+			t.data = data
+		}
+
+		for {
+			c.skipSpaces()
+
+			if c.pos >= len(c.src) {
+				err := NewSyntaxError(c.pos, "expected ')'")
+				return nil, err
+			}
+
+			if c.src[c.pos] == ')' {
+				c.pos++
+				break
+			}
+
+			child, err := c.template(false)
+			if err != nil {
+				return nil, err
+			}
+
+			t.children = append(t.children, child)
+		}
+	case isNameByte(b):
+		kind, err := c.name()
+		if err != nil {
+			return nil, err
+		}
+
+		t.kind = kind
+	default:
+		err := NewSyntaxError(c.pos, "unexpected "+strconv.QuoteRune(rune(b)))
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// captures returns the names of the captures of the pattern and of its
+// children.
+//
+// Parameters:
+//   - names: The set the names are added to. (Assumed to not be nil)
+func (p pattern) captures(names map[string]struct{}) {
+	if p.capture != "" {
+		names[p.capture] = struct{}{}
+	}
+
+	for _, child := range p.children {
+		child.captures(names)
+	}
+}
+
+// check checks that the template only inserts the given captures.
+//
+// Parameters:
+//   - names: The names of the captures.
+//
+// Returns:
+//   - error: An error if the template inserts another capture.
+func (t template) check(names map[string]struct{}) error {
+	if t.kind == "" {
+		_, ok := names[t.capture]
+		if !ok {
+			err := errors.New("unknown capture @" + t.capture)
+			return err
+		}
+
+		return nil
+	}
+
+	for _, child := range t.children {
+		err := child.check(names)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// build builds the nodes of the template. Captures are deep-copied, so that a
+// capture inserted more than once does not make the tree a graph.
+//
+// Parameters:
+//   - captures: The captures of the match.
+//
+// Returns:
+//   - []*slgr.Token: The new nodes; or, for captures, a copy of the captured
+//     node or of its children.
+func (t template) build(captures map[string]*slgr.Token) []*slgr.Token {
+	if t.kind == "" {
+		node := captures[t.capture]
+		if node == nil {
+			return nil
+		} else if !t.splice {
+			return []*slgr.Token{node.Copy()}
+		}
+
+		children := make([]*slgr.Token, 0, len(node.Children))
+
+		for _, child := range node.Children {
+			if child != nil {
+				children = append(children, child.Copy())
+			}
+		}
+
+		return children
+	}
+
+	tk := slgr.NewToken(t.kind, t.data)
+
+	for _, child := range t.children {
+		tk.Children = append(tk.Children, child.build(captures)...)
+	}
+
+	for _, child := range tk.Children {
+		if child.Pos.IsValid() {
+			tk.Pos = child.Pos
+			break
+		}
+	}
+
+	return []*slgr.Token{tk}
+}
+
+// RewriteRule is a rule that replaces the nodes matched by a query with a
+// template. See NewRewriteRule.
+type RewriteRule struct {
+	// query is the query of the replaced nodes.
+	query *Query
+
+	// template is the replacement.
+	template *template
+}
+
+// String implements fmt.Stringer.
+//
+// Format:
+//
+//	"<query> => <template>"
+func (r RewriteRule) String() string {
+	str := r.query.String() + " => " + r.template.String()
+	return str
+}
+
+// NewRewriteRule creates a new rule that replaces the nodes matched by the
+// given query with the given template.
+//
+// A template is one of:
+//
+//	(Type child ...)    a new node of the given type, with the given children
+//	(Type "data" ...)   the same, with the given data
+//	Type                a new node of the given type, without children
+//	@name               the node of the given capture of the query
+//	@name...            the children of the node of the given capture; only
+//	                    as a child
+//
+// New nodes have the position of their first child that has one; or, for the
+// replacement, the position of the replaced node if none has one. Captures
+// are inserted as deep copies of the captured nodes.
+//
+// Parameters:
+//   - query: The text of the query. See Compile.
+//   - template: The text of the template.
+//
+// Returns:
+//   - *RewriteRule: The new rule, or nil if the query or the template is not
+//     valid.
+//   - error: An error if the query or the template is not valid.
+//
+// Errors:
+//   - *SyntaxError: If the query or the template is not valid.
+//   - any other error: If the template inserts a capture that is not in the
+//     query.
+func NewRewriteRule(query, template string) (*RewriteRule, error) {
+	q, err := Compile(query)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &compiler{
+		src: template,
+	}
+
+	t, err := c.template(true)
+	if err != nil {
+		return nil, err
+	}
+
+	c.skipSpaces()
+
+	if c.pos < len(c.src) {
+		err := NewSyntaxError(c.pos, "expected the end of the template")
+		return nil, err
+	}
+
+	names := make(map[string]struct{})
+	q.root.captures(names)
+
+	err = t.check(names)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &RewriteRule{
+		query:    q,
+		template: t,
+	}
+
+	return r, nil
+}
+
+// apply replaces the given node if a rule matches it.
+//
+// Parameters:
+//   - rules: The rules, by priority.
+//   - node: The node. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.Token: The replacement.
+//   - bool: True if a rule matched, false otherwise.
+func apply(rules []*RewriteRule, node *slgr.Token) (*slgr.Token, bool) {
+	for _, r := range rules {
+		match := r.query.Match(node)
+		if match == nil {
+			continue
+		}
+
+		tk := r.template.build(match.Captures)[0]
+
+		if r.template.kind != "" && !tk.Pos.IsValid() {
+			tk.Pos = node.Pos
+		}
+
+		return tk, true
+	}
+
+	return nil, false
+}
+
+// rewriter rewrites trees with rules. The rewritten trees share the subtrees
+// that are not rewritten with the previous trees; but, as templates copy the
+// captures they insert, no node appears twice in a tree.
+type rewriter struct {
+	// rules is the rules, by priority.
+	rules []*RewriteRule
+
+	// done maps the nodes rewritten during the current pass to their
+	// replacements.
+	done map[*slgr.Token]*slgr.Token
+
+	// keys maps the structures of the nodes, as returned by key, to their
+	// identifiers.
+	keys map[string]int
+
+	// ids maps the nodes to the identifiers of their structures.
+	ids map[*slgr.Token]int
+
+	// created is the number of nodes created so far, including the copies of
+	// the nodes whose children were rewritten and of the captures.
+	created int
+}
+
+// id returns the identifier of the structure of the given node; that is, of
+// its type, its data and the structures of its children. Nodes have the same
+// identifier if, and only if, they have the same structure.
+//
+// Parameters:
+//   - node: The node. (Assumed to not be nil)
+//
+// Returns:
+//   - int: The identifier.
+func (r *rewriter) id(node *slgr.Token) int {
+	id, ok := r.ids[node]
+	if ok {
+		return id
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteString(strconv.Quote(node.Type))
+	_, _ = builder.WriteString(strconv.Quote(node.Data))
+
+	for _, child := range node.Children {
+		_, _ = builder.WriteRune(' ')
+		_, _ = builder.WriteString(strconv.Itoa(r.id(child)))
+	}
+
+	key := builder.String()
+
+	id, ok = r.keys[key]
+	if !ok {
+		id = len(r.keys)
+		r.keys[key] = id
+	}
+
+	r.ids[node] = id
+
+	return id
+}
+
+// pass rewrites the tree rooted at the given node once, from the leaves to
+// the root: each node is replaced by the first rule that matches it, after
+// its children were rewritten. The tree is not modified.
+//
+// Parameters:
+//   - node: The root of the tree. (Assumed to not be nil)
+//
+// Returns:
+//   - *slgr.Token: The rewritten tree, which shares the subtrees that were
+//     not rewritten; or nil on error.
+//   - error: An error if more than MaxRewriteNodes nodes were created.
+func (r *rewriter) pass(node *slgr.Token) (*slgr.Token, error) {
+	tk, ok := r.done[node]
+	if ok {
+		return tk, nil
+	}
+
+	var children []*slgr.Token
+
+	for i, child := range node.Children {
+		new_child, err := r.pass(child)
+		if err != nil {
+			return nil, err
+		}
+
+		if new_child == child {
+			continue
+		}
+
+		if children == nil {
+			children = node.GetChildren()
+		}
+
+		children[i] = new_child
+	}
+
+	tk = node
+
+	if children != nil {
+		tk = &slgr.Token{
+			Type:     node.Type,
+			Data:     node.Data,
+			Pos:      node.Pos,
+			Children: children,
+		}
+
+		r.created++
+	}
+
+	replacement, ok := apply(r.rules, tk)
+	if ok {
+		tk = replacement
+
+		_ = slgr.Inspect(tk, slgr.PreOrder, func(*slgr.Token) slgr.Signal {
+			r.created++
+			return slgr.Continue
+		})
+	}
+
+	if r.created > MaxRewriteNodes {
+		err := common.NewErrLimitExceeded("rewrite node", MaxRewriteNodes)
+		return nil, err
+	}
+
+	r.done[node] = tk
+
+	return tk, nil
+}
+
+// Rewrite rewrites the tree rooted at the given node with the given rules
+// until none of them matches; that is, until a fixpoint. At each pass, every
+// node is replaced by the first rule that matches it, from the leaves to the
+// root. The tree is not modified.
+//
+// Parameters:
+//   - root: The root of the tree.
+//   - rules: The rules, by priority.
+//
+// Returns:
+//   - *slgr.Token: The rewritten tree, which shares the subtrees that were
+//     not rewritten; or nil on error.
+//   - error: An error if the rules do not reach a fixpoint.
+//
+// Errors:
+//   - common.ErrBadParam: If the root is nil.
+//   - ErrRewriteCycle: If the rules rewrite a tree into a tree they already
+//     yielded.
+//   - *common.ErrLimitExceeded: If the rules do not reach a fixpoint within
+//     MaxRewritePasses passes or MaxRewriteNodes new nodes.
+func Rewrite(root *slgr.Token, rules []*RewriteRule) (*slgr.Token, error) {
+	if root == nil {
+		err := common.NewErrNilParam("root")
+		return nil, err
+	}
+
+	r := &rewriter{
+		keys: make(map[string]int),
+		ids:  make(map[*slgr.Token]int),
+	}
+
+	for _, rule := range rules {
+		if rule != nil {
+			r.rules = append(r.rules, rule)
+		}
+	}
+
+	if len(r.rules) == 0 {
+		return root, nil
+	}
+
+	seen := map[int]struct{}{
+		r.id(root): {},
+	}
+
+	for range MaxRewritePasses {
+		r.done = make(map[*slgr.Token]*slgr.Token)
+
+		new_root, err := r.pass(root)
+		if err != nil {
+			return nil, err
+		}
+
+		if new_root == root {
+			return root, nil
+		}
+
+		root = new_root
+
+		id := r.id(root)
+
+		_, ok := seen[id]
+		if ok {
+			return nil, ErrRewriteCycle
+		}
+
+		seen[id] = struct{}{}
+	}
+
+	err := common.NewErrLimitExceeded("rewrite pass", MaxRewritePasses)
+	return nil, err
+}
@@ -0,0 +1,242 @@
+package query
+
+import (
+	"errors"
+	"testing"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	"github.com/PlayerR9/SlParser/mygo-lib/common"
+)
+
+// mustRules compiles the given rules, as pairs of a query and a template.
+func mustRules(t *testing.T, pairs ...string) []*RewriteRule {
+	t.Helper()
+
+	var rules []*RewriteRule
+
+	for i := 0; i < len(pairs); i += 2 {
+		rule, err := NewRewriteRule(pairs[i], pairs[i+1])
+		if err != nil {
+			t.Fatalf("NewRewriteRule(%q, %q) returned an error: %v", pairs[i], pairs[i+1], err)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		want  string
+	}{
+		{
+			name:  "no rules",
+			rules: nil,
+			want:  `(Source (Rule (Lhs "A") (RhsCls (Rhs "x") (OrGroup (Rhs "y") (Rhs "z")))) (Rule (Lhs "B") (RhsCls (Rhs "w"))))`,
+		},
+		{
+			name:  "splice",
+			rules: []string{"OrGroup @g", "(Alt @g...)"},
+			want:  `(Source (Rule (Lhs "A") (RhsCls (Rhs "x") (Alt (Rhs "y") (Rhs "z")))) (Rule (Lhs "B") (RhsCls (Rhs "w"))))`,
+		},
+		{
+			name:  "data and leaves",
+			rules: []string{`(Rule (Lhs "B"))`, `(Empty "b" Marker)`},
+			want:  `(Source (Rule (Lhs "A") (RhsCls (Rhs "x") (OrGroup (Rhs "y") (Rhs "z")))) (Empty "b" (Marker)))`,
+		},
+		{
+			name: "fixpoint over several passes",
+			rules: []string{
+				`(OrGroup (Rhs "y") @y (Rhs "z") @z)`, "(Seq @z @y)",
+				`(Seq (Rhs "z") @a _ @b)`, "(Done @b @a)",
+			},
+			want: `(Source (Rule (Lhs "A") (RhsCls (Rhs "x") (Done (Rhs "y") (Rhs "z")))) (Rule (Lhs "B") (RhsCls (Rhs "w"))))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			root := sampleTree()
+			before := slgr.EncodeSExpr(root)
+
+			got, err := Rewrite(root, mustRules(t, test.rules...))
+			if err != nil {
+				t.Fatalf("Rewrite() returned an error: %v", err)
+			}
+
+			if str := slgr.EncodeSExpr(got); str != test.want {
+				t.Errorf("Rewrite() = %s, want %s", str, test.want)
+			}
+
+			if after := slgr.EncodeSExpr(root); after != before {
+				t.Errorf("Rewrite() modified the tree: %s, want %s", after, before)
+			}
+		})
+	}
+}
+
+func TestRewriteCopiesCaptures(t *testing.T) {
+	rules := mustRules(t,
+		"(Dup (Rhs) @x)", "(Pair @x @x)",
+		"(Spread Rhs @s)", "(Pair @s... @s...)",
+	)
+
+	captured := node("Rhs", "x", node("Leaf", "l"))
+
+	tests := []struct {
+		name string
+		root *slgr.Token
+		want string
+	}{
+		{
+			name: "capture",
+			root: node("Dup", "", captured),
+			want: `(Pair (Rhs "x" (Leaf "l")) (Rhs "x" (Leaf "l")))`,
+		},
+		{
+			name: "splice",
+			root: node("Spread", "", captured),
+			want: `(Pair (Leaf "l") (Leaf "l"))`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Rewrite(test.root, rules)
+			if err != nil {
+				t.Fatalf("Rewrite() returned an error: %v", err)
+			}
+
+			if str := slgr.EncodeSExpr(got); str != test.want {
+				t.Fatalf("Rewrite() = %s, want %s", str, test.want)
+			}
+
+			inputs := make(map[*slgr.Token]struct{})
+
+			_ = slgr.Inspect(test.root, slgr.PreOrder, func(node *slgr.Token) slgr.Signal {
+				inputs[node] = struct{}{}
+				return slgr.Continue
+			})
+
+			seen := make(map[*slgr.Token]struct{})
+
+			_ = slgr.Inspect(got, slgr.PreOrder, func(node *slgr.Token) slgr.Signal {
+				if _, ok := seen[node]; ok {
+					t.Errorf("%s appears twice in the rewritten tree", node)
+				}
+
+				if _, ok := inputs[node]; ok {
+					t.Errorf("%s is shared with the input tree", node)
+				}
+
+				seen[node] = struct{}{}
+				return slgr.Continue
+			})
+
+			got.Children[0].Data = "changed"
+
+			if captured.Data != "x" || captured.Children[0].Data != "l" {
+				t.Errorf("modifying the rewritten tree modified the captured node")
+			}
+		})
+	}
+}
+
+func TestRewriteErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		root  *slgr.Token
+		rules []string
+		check func(err error) bool
+	}{
+		{
+			name:  "nil root",
+			root:  nil,
+			rules: []string{"A", "B"},
+			check: func(err error) bool {
+				var e *common.ErrBadParam
+				return errors.As(err, &e)
+			},
+		},
+		{
+			name:  "cycle",
+			root:  node("Source", "", node("A", "")),
+			rules: []string{"A", "B", "B", "A"},
+			check: func(err error) bool {
+				return err == ErrRewriteCycle
+			},
+		},
+		{
+			name:  "exponential growth",
+			root:  node("X", ""),
+			rules: []string{"X @x", "(X @x @x)"},
+			check: func(err error) bool {
+				var e *common.ErrLimitExceeded
+				return errors.As(err, &e) && e.Max == MaxRewriteNodes
+			},
+		},
+		{
+			name:  "endless growth",
+			root:  node("Top", ""),
+			rules: []string{"Top @t", "(Top Leaf @t...)"},
+			check: func(err error) bool {
+				var e *common.ErrLimitExceeded
+				return errors.As(err, &e) && e.Max == MaxRewritePasses
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Rewrite(test.root, mustRules(t, test.rules...))
+			if !test.check(err) {
+				t.Fatalf("Rewrite() returned the error %v", err)
+			}
+
+			if got != nil {
+				t.Errorf("Rewrite() = %s, want nil", slgr.EncodeSExpr(got))
+			}
+		})
+	}
+}
+
+func TestNewRewriteRule(t *testing.T) {
+	rule, err := NewRewriteRule("(Rule Lhs @name) @rule", ` ( Named "n" @name  @rule... ) `)
+	if err != nil {
+		t.Fatalf("NewRewriteRule() returned an error: %v", err)
+	}
+
+	want := `(Rule (Lhs) @name) @rule => (Named "n" @name @rule...)`
+	if got := rule.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	tests := []struct {
+		query    string
+		template string
+		syntax   bool
+	}{
+		{"(Rule", "A", true},
+		{"A", "", true},
+		{"A", "(B", true},
+		{"A", "B C", true},
+		{"A @a", "@a...", true},
+		{"A @a", "(B @c)", false},
+	}
+
+	for _, test := range tests {
+		_, err := NewRewriteRule(test.query, test.template)
+		if err == nil {
+			t.Errorf("NewRewriteRule(%q, %q) returned no error", test.query, test.template)
+			continue
+		}
+
+		var se *SyntaxError
+		if errors.As(err, &se) != test.syntax {
+			t.Errorf("NewRewriteRule(%q, %q) returned %v, want a syntax error: %t", test.query, test.template, err, test.syntax)
+		}
+	}
+}
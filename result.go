@@ -1,6 +1,7 @@
 package slp
 
 import (
+	"context"
 	"errors"
 
 	slpast "github.com/PlayerR9/SlParser/ast"
@@ -166,6 +167,21 @@ func (r Result[N]) Copy() Result[N] {
 //   - Result[N]: A new result containing the list of lexed tokens or an error
 //     if the lexing process fails.
 func (r Result[N]) Lex(lexer *sllx.Lexer) Result[N] {
+	r = r.LexContext(context.Background(), lexer)
+	return r
+}
+
+// LexContext is like Lex, but it stops as soon as the context is done. See
+// sllx.LexContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - lexer: A lexer that can be used to lex the input data.
+//
+// Returns:
+//   - Result[N]: A new result containing the list of lexed tokens or an error
+//     if the lexing process fails or if the context is done.
+func (r Result[N]) LexContext(ctx context.Context, lexer *sllx.Lexer) Result[N] {
 	if lexer == nil {
 		r := r.Copy()
 		r.err = common.NewErrNilParam("lexer")
@@ -180,7 +196,7 @@ func (r Result[N]) Lex(lexer *sllx.Lexer) Result[N] {
 		return r
 	}
 
-	tokens, err := sllx.Lex(lexer, *r.data)
+	tokens, err := sllx.LexContext(ctx, lexer, *r.data)
 	if err != nil {
 		r := r.Copy()
 		r.err = err
@@ -205,6 +221,21 @@ func (r Result[N]) Lex(lexer *sllx.Lexer) Result[N] {
 //   - Result[N]: A new result containing the root token or an error if the
 //     parsing process fails.
 func (r Result[N]) Parse(parser *slpx.Parser) Result[N] {
+	r = r.ParseContext(context.Background(), parser)
+	return r
+}
+
+// ParseContext is like Parse, but it stops as soon as the context is done.
+// See slpx.ParseContext.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - parser: A parser that can be used to parse the tokens.
+//
+// Returns:
+//   - Result[N]: A new result containing the root token or an error if the
+//     parsing process fails or if the context is done.
+func (r Result[N]) ParseContext(ctx context.Context, parser *slpx.Parser) Result[N] {
 	if r.tokens == nil {
 		r := r.Copy()
 		r.err = errors.New("missing tokens")
@@ -212,7 +243,7 @@ func (r Result[N]) Parse(parser *slpx.Parser) Result[N] {
 		return r
 	}
 
-	forest, err := slpx.Parse(parser, *r.tokens)
+	forest, err := slpx.ParseContext(ctx, parser, *r.tokens)
 	if err != nil {
 		r := r.Copy()
 		r.err = err
@@ -230,6 +261,180 @@ func (r Result[N]) Parse(parser *slpx.Parser) Result[N] {
 	return r
 }
 
+// Stream lexes and parses the input data at once: the parser pulls the tokens
+// from the lexer on demand, so they are never collected and syntax errors are
+// reported before the rest of the input is lexed. Unlike Lex and Parse, the
+// result holds no list of tokens.
+//
+// Parameters:
+//   - lexer: A lexer that can be used to lex the input data.
+//   - parser: A parser that can be used to parse the tokens.
+//
+// Returns:
+//   - Result[N]: A new result containing the root token or an error if the
+//     lexing or the parsing process fails.
+func (r Result[N]) Stream(lexer *sllx.Lexer, parser *slpx.Parser) Result[N] {
+	r = r.StreamContext(context.Background(), lexer, parser)
+	return r
+}
+
+// StreamContext is like Stream, but it stops as soon as the context is done.
+//
+// Parameters:
+//   - ctx: The context. Must not be nil.
+//   - lexer: A lexer that can be used to lex the input data.
+//   - parser: A parser that can be used to parse the tokens.
+//
+// Returns:
+//   - Result[N]: A new result containing the root token or an error if the
+//     lexing or the parsing process fails, or if the context is done.
+func (r Result[N]) StreamContext(ctx context.Context, lexer *sllx.Lexer, parser *slpx.Parser) Result[N] {
+	if lexer == nil {
+		r := r.Copy()
+		r.err = common.NewErrNilParam("lexer")
+
+		return r
+	}
+
+	if r.data == nil {
+		r := r.Copy()
+		r.err = errors.New("missing data")
+
+		return r
+	}
+
+	defer lexer.Reset()
+
+	_, err := lexer.Write(*r.data)
+	if err != nil {
+		r := r.Copy()
+		r.err = err
+		return r
+	}
+
+	forest, err := slpx.ParseFromContext(ctx, parser, lexer)
+	if err != nil {
+		r := r.Copy()
+		r.err = err
+		return r
+	}
+
+	if len(forest) != 1 {
+		r := r.Copy()
+		r.err = errors.New("expected one root token")
+		return r
+	}
+
+	r = r.Copy()
+	r.root = &forest[0]
+	return r
+}
+
+// Reparse applies the given edits to the input data and lexes and parses it
+// again incrementally: only the tokens around the edits are lexed again (see
+// sllx.Relex), and the subtrees of the root token that the edits did not
+// affect are reused (see slpx.Reparse). The tokens and subtrees after the
+// edits are still copied to shift their positions, so the whole process is
+// linear in the size of the input; but only the part around the edits is
+// lexed and parsed again.
+//
+// The edits are applied one after the other, so that the offset of each edit
+// refers to the data edited by the previous ones. If the result has no root
+// token, or has an error, the edited data is lexed and parsed from scratch.
+// The root token must not have been flattened (see Flatten). The node of the
+// new result is missing; see Ast.
+//
+// Parameters:
+//   - lexer: A lexer that can be used to lex the input data.
+//   - parser: A parser that can be used to parse the tokens.
+//   - edits: The edits to apply.
+//
+// Returns:
+//   - Result[N]: A new result containing the edited data, its tokens and its
+//     root token; or an error if the edits are not valid or if the lexing or
+//     the parsing process fails.
+func (r Result[N]) Reparse(lexer *sllx.Lexer, parser *slpx.Parser, edits []slgr.Edit) Result[N] {
+	if lexer == nil {
+		r := r.Copy()
+		r.err = common.NewErrNilParam("lexer")
+
+		return r
+	}
+
+	if r.data == nil {
+		r := r.Copy()
+		r.err = errors.New("missing data")
+
+		return r
+	}
+
+	data, edit, err := slgr.ApplyEdits(*r.data, edits)
+	if err != nil {
+		r := r.Copy()
+		r.err = err
+		return r
+	}
+
+	r_new := NewResult[N](data)
+
+	if r.tokens == nil || r.root == nil || r.err != nil {
+		r_new = r_new.Lex(lexer).Parse(parser)
+		return r_new
+	}
+
+	tokens, prefix, suffix, err := sllx.Relex(lexer, *r.tokens, data, edit)
+	if err != nil {
+		r_new.err = err
+		return r_new
+	}
+
+	r_new.tokens = &tokens
+
+	forest, err := slpx.Reparse(parser, *r.root, *r.tokens, tokens, prefix, suffix)
+	if err != nil {
+		r_new.err = err
+		return r_new
+	}
+
+	if len(forest) != 1 {
+		r_new.err = errors.New("expected one root token")
+		return r_new
+	}
+
+	r_new.root = &forest[0]
+	return r_new
+}
+
+// Flatten removes the helper non-terminals generated by slgr.Desugar from the
+// root token. See slpast.Flatten for details.
+//
+// The root token is modified in place.
+//
+// Parameters:
+//   - helpers: The helper non-terminals to remove.
+//
+// Returns:
+//   - Result[N]: A new result containing the flattened root token or an error
+//     if the root token is missing.
+func (r Result[N]) Flatten(helpers []string) Result[N] {
+	if r.root == nil {
+		r := r.Copy()
+		r.err = errors.New("missing root")
+
+		return r
+	}
+
+	err := slpast.Flatten(*r.root, helpers)
+	if err != nil {
+		r := r.Copy()
+		r.err = err
+		return r
+	}
+
+	r = r.Copy()
+	return r
+}
+
 // Ast applies a function to the root token to convert it into a node of type N.
 //
 // The function attempts to construct a node from the root token using the provided
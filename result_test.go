@@ -0,0 +1,137 @@
+package slp
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"unicode"
+
+	slgr "github.com/PlayerR9/SlParser/grammar"
+	sllx "github.com/PlayerR9/SlParser/lexer"
+	slpx "github.com/PlayerR9/SlParser/parser"
+)
+
+// lexSum lexes the tokens of sums of parenthesized numbers, skipping spaces.
+func lexSum(scanner io.RuneScanner) (*slgr.Token, error) {
+	c, _, err := scanner.ReadRune()
+	if err != nil {
+		return nil, err
+	}
+
+	switch c {
+	case ' ', '\n':
+		return nil, nil
+	case '+':
+		return slgr.NewToken("plus", "+"), nil
+	case '(':
+		return slgr.NewToken("op", "("), nil
+	case ')':
+		return slgr.NewToken("cl", ")"), nil
+	}
+
+	if !unicode.IsDigit(c) {
+		return nil, fmt.Errorf("unexpected character %q", c)
+	}
+
+	var builder strings.Builder
+
+	_, _ = builder.WriteRune(c)
+
+	for {
+		c, _, err := scanner.ReadRune()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !unicode.IsDigit(c) {
+			_ = scanner.UnreadRune()
+			break
+		}
+
+		_, _ = builder.WriteRune(c)
+	}
+
+	return slgr.NewToken("num", builder.String()), nil
+}
+
+// sumRules is the grammar of sums of parenthesized numbers.
+var sumRules = []*slgr.Rule{
+	slgr.NewRule("E", "E", "plus", "T"),
+	slgr.NewRule("E", "T"),
+	slgr.NewRule("T", "num"),
+	slgr.NewRule("T", "op", "E", "cl"),
+}
+
+// sumDefinitions returns the definitions of the lexers and parsers of sums.
+func sumDefinitions(t *testing.T) (*sllx.Definition, *slpx.Definition) {
+	t.Helper()
+
+	table, err := slpx.NewTable(sumRules)
+	if err != nil {
+		t.Fatalf("NewTable() returned an error: %v", err)
+	}
+
+	var lb sllx.Builder
+
+	_ = lb.SetLexOneFn(lexSum)
+
+	var pb slpx.Builder
+
+	_ = pb.SetTable(table)
+
+	return lb.Compile(), pb.Compile()
+}
+
+// tokenString returns the data of the given tokens and their offsets.
+func tokenString(tokens []*slgr.Token) string {
+	parts := make([]string, 0, len(tokens))
+
+	for _, tk := range tokens {
+		parts = append(parts, fmt.Sprintf("%s@%d", tk.Data, tk.Pos.Offset))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func TestResultReparse(t *testing.T) {
+	ld, pd := sumDefinitions(t)
+
+	r := NewResult[*slgr.Token]([]byte("1 + 2 + 3")).Lex(ld.NewLexer()).Parse(pd.NewParser())
+	if r.HasError() {
+		t.Fatalf("Parse() returned an error: %v", r.GetError())
+	}
+
+	edits := []slgr.Edit{
+		{Offset: 4, Deleted: 1, Inserted: "(20 + 30)"},
+		{Offset: 0, Deleted: 0, Inserted: "100 + "},
+	}
+
+	r_new := r.Reparse(ld.NewLexer(), pd.NewParser(), edits)
+	if r_new.HasError() {
+		t.Fatalf("Reparse() returned an error: %v", r_new.GetError())
+	}
+
+	data, _ := r_new.GetData()
+	if want := "100 + 1 + (20 + 30) + 3"; string(data) != want {
+		t.Errorf("data = %q, want %q", data, want)
+	}
+
+	want := NewResult[*slgr.Token](data).Lex(ld.NewLexer()).Parse(pd.NewParser())
+
+	got_tokens, _ := r_new.GetTokens()
+	want_tokens, _ := want.GetTokens()
+
+	if got, want := tokenString(got_tokens), tokenString(want_tokens); got != want {
+		t.Errorf("tokens = %s, want %s", got, want)
+	}
+
+	got_root, _ := r_new.GetRoot()
+	want_root, _ := want.GetRoot()
+
+	if got, want := slgr.TreeToString(got_root), slgr.TreeToString(want_root); got != want {
+		t.Errorf("root =\n%s\nwant\n%s", got, want)
+	}
+}